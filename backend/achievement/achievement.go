@@ -0,0 +1,86 @@
+package achievement
+
+import (
+	"buzzerbeater/db"
+	"buzzerbeater/model"
+	"time"
+)
+
+// definition 成就定义，query 统计用户的进度（第一个参数为用户 ID，withNow 时第二个参数为当前时间）
+// fromCounts 的进度就是关注数据本身，用户未公开关注数据时不返回进度
+type definition struct {
+	code        string
+	name        string
+	description string
+	target      int
+	query       string
+	withNow     bool
+	fromCounts  bool
+}
+
+// definitions 全部成就，进度由已有数据实时统计，不单独存储
+var definitions = []definition{
+	{
+		code: "pickup_first", name: "初次约球", description: "参加 1 次约球", target: 1, withNow: true,
+		query: `SELECT COUNT(*) FROM pickup_rsvps r JOIN pickup_events e ON e.id = r.event_id
+			WHERE r.user_id = ? AND r.status = 'going' AND e.status = 'scheduled' AND e.ends_at <= ?`,
+	},
+	{
+		code: "pickup_regular", name: "约球达人", description: "参加 10 次约球", target: 10, withNow: true,
+		query: `SELECT COUNT(*) FROM pickup_rsvps r JOIN pickup_events e ON e.id = r.event_id
+			WHERE r.user_id = ? AND r.status = 'going' AND e.status = 'scheduled' AND e.ends_at <= ?`,
+	},
+	{
+		code: "pickup_organizer", name: "组局高手", description: "发起 5 次约球", target: 5, withNow: true,
+		query: `SELECT COUNT(*) FROM pickup_events WHERE organizer_id = ? AND status = 'scheduled' AND ends_at <= ?`,
+	},
+	{
+		code: "court_critic", name: "球场点评家", description: "发表 5 条球场评价", target: 5,
+		query: `SELECT COUNT(*) FROM court_reviews WHERE user_id = ? AND status = 'visible'`,
+	},
+	{
+		code: "helpful_reviewer", name: "热心球友", description: "球场评价累计获得 50 个“有用”", target: 50,
+		query: `SELECT COALESCE(SUM(helpful_count), 0) FROM court_reviews WHERE user_id = ? AND status = 'visible'`,
+	},
+	{
+		code: "prediction_hit", name: "预测达人", description: "竞猜命中 10 次", target: 10,
+		query: `SELECT COUNT(*) FROM prediction_stakes s JOIN prediction_markets m ON m.id = s.market_id
+			WHERE s.user_id = ? AND m.status = 'settled' AND s.option_id = m.winning_option_id`,
+	},
+	{
+		code: "fantasy_manager", name: "Fantasy 经理", description: "加入 1 个 Fantasy 联赛", target: 1,
+		query: `SELECT COUNT(*) FROM fantasy_teams WHERE user_id = ?`,
+	},
+	{
+		code: "popular", name: "人气球友", description: "粉丝达到 100 人", target: 100, fromCounts: true,
+		query: `SELECT COUNT(*) FROM user_follows WHERE followee_id = ?`,
+	},
+}
+
+// ForUser 用户的全部成就及进度，showCounts 为 false 时隐藏由关注数据得出的进度
+func ForUser(userID int, now time.Time, showCounts bool) ([]model.Achievement, error) {
+	achievements := make([]model.Achievement, 0, len(definitions))
+	for _, d := range definitions {
+		args := []interface{}{userID}
+		if d.withNow {
+			args = append(args, now.UTC())
+		}
+		var count int
+		if err := db.GetDB().QueryRow(d.query, args...).Scan(&count); err != nil {
+			return nil, err
+		}
+		a := model.Achievement{
+			Code:        d.code,
+			Name:        d.name,
+			Description: d.description,
+			Target:      d.target,
+			Achieved:    count >= d.target,
+		}
+		if showCounts || !d.fromCounts {
+			progress := min(count, d.target)
+			a.Progress = &progress
+		}
+		achievements = append(achievements, a)
+	}
+	return achievements, nil
+}
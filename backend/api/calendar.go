@@ -0,0 +1,138 @@
+package api
+
+import (
+	"buzzerbeater/db"
+	"buzzerbeater/util"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// gameDuration 日历中每场比赛占用的时长
+const gameDuration = 150 * time.Minute
+
+// CalendarSubscription 日历订阅信息
+type CalendarSubscription struct {
+	URL       string `json:"url"`
+	WebcalURL string `json:"webcal_url"` // 点击即可在日历 App 中订阅
+}
+
+// GetCalendarSubscription 获取（首次调用时创建）当前用户的主队日历订阅地址
+func GetCalendarSubscription(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		util.ErrorResponse(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	var token string
+	err := db.GetDB().QueryRow("SELECT token FROM calendar_tokens WHERE user_id = ?", userID).Scan(&token)
+	if err == sql.ErrNoRows {
+		token = newCalendarToken()
+		_, err = db.GetDB().Exec("INSERT INTO calendar_tokens (user_id, token) VALUES (?, ?)", userID, token)
+	}
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "获取日历订阅失败")
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, calendarSubscription(c, token))
+}
+
+// ResetCalendarToken 重置日历订阅地址（旧地址立即失效）
+func ResetCalendarToken(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		util.ErrorResponse(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	token := newCalendarToken()
+	_, err := db.GetDB().Exec(`
+		INSERT INTO calendar_tokens (user_id, token) VALUES (?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET token = excluded.token, created_at = CURRENT_TIMESTAMP
+	`, userID, token)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "重置日历订阅失败")
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, calendarSubscription(c, token))
+}
+
+// GetCalendarFeed 主队赛程日历（.ics），通过 Token 鉴权，供日历 App 订阅
+func GetCalendarFeed(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+
+	var userID int
+	err := db.GetDB().QueryRow("SELECT user_id FROM calendar_tokens WHERE token = ?", token).Scan(&userID)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusNotFound, "日历不存在")
+		return
+	}
+
+	ctx := c.Request.Context()
+	team, err := loadHomeTeam(ctx, userID)
+	if err != nil {
+		homeTeamErrorResponse(c, err)
+		return
+	}
+
+	games, _, err := loadGames(ctx, currentSeason(), team.ID)
+	if err != nil {
+		nbaErrorResponse(c, "获取赛程失败", err)
+		return
+	}
+
+	now := time.Now()
+	events := []util.CalendarEvent{}
+	for _, g := range buildSchedule(*team, games) {
+		event := util.CalendarEvent{
+			UID:      fmt.Sprintf("nba-game-%d@buzzerbeater", g.GameID),
+			Summary:  scheduleSummary(*team, g),
+			Location: g.Venue,
+			Updated:  now,
+		}
+		if start, err := time.Parse(time.RFC3339, g.Datetime); err == nil {
+			event.Start, event.End = start, start.Add(gameDuration)
+		} else if day, err := time.Parse("2006-01-02", g.Date); err == nil {
+			event.Start, event.AllDay = day, true
+		} else {
+			continue
+		}
+		if g.Postseason {
+			event.Description = "季后赛"
+		}
+		events = append(events, event)
+	}
+
+	name := team.FullName
+	if team.FullNameZh != "" {
+		name = team.FullNameZh
+	}
+	c.Header("Content-Disposition", `inline; filename="schedule.ics"`)
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(util.BuildICalendar(name+" 赛程", events)))
+}
+
+// newCalendarToken 生成订阅 Token
+func newCalendarToken() string {
+	return strings.ReplaceAll(uuid.New().String(), "-", "")
+}
+
+// calendarSubscription 根据请求地址拼出订阅链接
+func calendarSubscription(c *gin.Context, token string) CalendarSubscription {
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	path := fmt.Sprintf("%s/api/calendar/%s.ics", c.Request.Host, token)
+	return CalendarSubscription{
+		URL:       scheme + "://" + path,
+		WebcalURL: "webcal://" + path,
+	}
+}
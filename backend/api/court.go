@@ -0,0 +1,499 @@
+package api
+
+import (
+	"buzzerbeater/db"
+	"buzzerbeater/geo"
+	"buzzerbeater/model"
+	"buzzerbeater/review"
+	"buzzerbeater/util"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 球场搜索参数限制
+const (
+	courtDefaultRadius = 5000  // 默认搜索半径（米）
+	courtMaxRadius     = 50000 // 最大搜索半径（米）
+	courtMaxBoxDegrees = 5     // 矩形搜索的最大跨度（度）
+	courtSearchCells   = 16    // 覆盖搜索范围的 geohash 前缀数上限
+	courtDefaultLimit  = 50
+	courtMaxLimit      = 200
+	courtMaxPhotos     = 9
+)
+
+// courtWeekdays 营业时间的键
+var courtWeekdays = []string{"mon", "tue", "wed", "thu", "fri", "sat", "sun"}
+
+const courtColumns = `
+	id, name, address, latitude, longitude, surface, indoor, free, price, opening_hours, created_by, created_at, updated_at,
+	COALESCE((SELECT review_count FROM court_rating_stats WHERE court_id = courts.id), 0),
+	COALESCE((SELECT rating_total FROM court_rating_stats WHERE court_id = courts.id), 0)
+`
+
+func scanCourt(row interface{ Scan(...interface{}) error }) (*model.Court, error) {
+	var court model.Court
+	var hours sql.NullString
+	var ratingTotal int
+	if err := row.Scan(&court.ID, &court.Name, &court.Address, &court.Latitude, &court.Longitude, &court.Surface,
+		&court.Indoor, &court.Free, &court.Price, &hours, &court.CreatedBy, &court.CreatedAt, &court.UpdatedAt,
+		&court.ReviewCount, &ratingTotal); err != nil {
+		return nil, err
+	}
+	court.Rating = review.Average(court.ReviewCount, ratingTotal)
+	if hours.Valid {
+		if err := json.Unmarshal([]byte(hours.String), &court.OpeningHours); err != nil {
+			return nil, err
+		}
+	}
+	court.Photos = []string{}
+	return &court, nil
+}
+
+// loadCourtPhotos 批量填充球场照片
+func loadCourtPhotos(courts []*model.Court) error {
+	if len(courts) == 0 {
+		return nil
+	}
+	byID := make(map[int]*model.Court, len(courts))
+	args := make([]interface{}, 0, len(courts))
+	for _, c := range courts {
+		byID[c.ID] = c
+		args = append(args, c.ID)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(courts)), ",")
+	rows, err := db.GetDB().Query(
+		"SELECT court_id, url FROM court_photos WHERE court_id IN ("+placeholders+") ORDER BY court_id, position, id",
+		args...,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var courtID int
+		var url string
+		if err := rows.Scan(&courtID, &url); err != nil {
+			return err
+		}
+		byID[courtID].Photos = append(byID[courtID].Photos, url)
+	}
+	return rows.Err()
+}
+
+// loadCourt 获取球场及照片
+func loadCourt(id int) (*model.Court, error) {
+	court, err := scanCourt(db.GetDB().QueryRow("SELECT "+courtColumns+" FROM courts WHERE id = ?", id))
+	if err != nil {
+		return nil, err
+	}
+	return court, loadCourtPhotos([]*model.Court{court})
+}
+
+// queryFloat 解析可选的浮点数查询参数
+func queryFloat(c *gin.Context, name string) (float64, bool, error) {
+	value := c.Query(name)
+	if value == "" {
+		return 0, false, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	return f, true, err
+}
+
+// queryBool 解析可选的 true/false 查询参数
+func queryBool(c *gin.Context, name string) (*bool, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// SearchCourts 搜索球场
+// 参数：lat、lng、radius（米，默认 5000）按半径搜索；min_lat、min_lng、max_lat、max_lng 按矩形（地图可视区域）搜索，
+// 同时传 lat、lng 时按到该点的距离排序，否则按到矩形中心的距离排序；
+// surface、indoor、free 过滤；limit 最多返回条数
+func SearchCourts(c *gin.Context) {
+	lat, hasLat, err1 := queryFloat(c, "lat")
+	lng, hasLng, err2 := queryFloat(c, "lng")
+	minLat, hasMinLat, err3 := queryFloat(c, "min_lat")
+	minLng, hasMinLng, err4 := queryFloat(c, "min_lng")
+	maxLat, hasMaxLat, err5 := queryFloat(c, "max_lat")
+	maxLng, hasMaxLng, err6 := queryFloat(c, "max_lng")
+	radius, hasRadius, err7 := queryFloat(c, "radius")
+	if err := errors.Join(err1, err2, err3, err4, err5, err6, err7); err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "无效的坐标参数")
+		return
+	}
+
+	hasCenter := hasLat && hasLng
+	hasBox := hasMinLat && hasMinLng && hasMaxLat && hasMaxLng
+	if hasCenter && !geo.ValidCoordinate(lat, lng) {
+		util.ErrorResponse(c, http.StatusBadRequest, "无效的坐标")
+		return
+	}
+
+	var box geo.Box
+	switch {
+	case hasBox:
+		box = geo.Box{MinLat: minLat, MinLng: minLng, MaxLat: maxLat, MaxLng: maxLng}
+		if !geo.ValidCoordinate(minLat, minLng) || !geo.ValidCoordinate(maxLat, maxLng) ||
+			minLat > maxLat || minLng > maxLng {
+			util.ErrorResponse(c, http.StatusBadRequest, "无效的搜索范围")
+			return
+		}
+		if maxLat-minLat > courtMaxBoxDegrees || maxLng-minLng > courtMaxBoxDegrees {
+			util.ErrorResponse(c, http.StatusBadRequest, "搜索范围过大，请放大地图")
+			return
+		}
+		if !hasCenter {
+			lat, lng = box.Center()
+		}
+	case hasCenter:
+		if !hasRadius {
+			radius = courtDefaultRadius
+		}
+		if radius <= 0 || radius > courtMaxRadius {
+			util.ErrorResponse(c, http.StatusBadRequest, "搜索半径必须在 0-50000 米之间")
+			return
+		}
+		box = geo.BoxAround(lat, lng, radius)
+	default:
+		util.ErrorResponse(c, http.StatusBadRequest, "需要 lat/lng 或 min_lat/min_lng/max_lat/max_lng 参数")
+		return
+	}
+
+	limit := courtDefaultLimit
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > courtMaxLimit {
+			util.ErrorResponse(c, http.StatusBadRequest, "limit 必须在 1-200 之间")
+			return
+		}
+		limit = n
+	}
+
+	// geohash 前缀范围走索引，经纬度范围精确过滤
+	cells := geo.Cover(box, courtSearchCells)
+	ranges := make([]string, len(cells))
+	args := make([]interface{}, 0, len(cells)*2+8)
+	for i, cell := range cells {
+		ranges[i] = "(geohash >= ? AND geohash < ?)"
+		args = append(args, cell, cell+"~")
+	}
+	conditions := []string{
+		"(" + strings.Join(ranges, " OR ") + ")",
+		"latitude BETWEEN ? AND ?",
+		"longitude BETWEEN ? AND ?",
+	}
+	args = append(args, box.MinLat, box.MaxLat, box.MinLng, box.MaxLng)
+
+	if surface := c.Query("surface"); surface != "" {
+		conditions = append(conditions, "surface = ?")
+		args = append(args, surface)
+	}
+	indoor, err := queryBool(c, "indoor")
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "indoor 必须为 true 或 false")
+		return
+	}
+	if indoor != nil {
+		conditions = append(conditions, "indoor = ?")
+		args = append(args, *indoor)
+	}
+	free, err := queryBool(c, "free")
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "free 必须为 true 或 false")
+		return
+	}
+	if free != nil {
+		conditions = append(conditions, "free = ?")
+		args = append(args, *free)
+	}
+
+	rows, err := db.GetDB().Query("SELECT "+courtColumns+" FROM courts WHERE "+strings.Join(conditions, " AND "), args...)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "搜索球场失败")
+		return
+	}
+	defer rows.Close()
+
+	courts := []*model.Court{}
+	for rows.Next() {
+		court, err := scanCourt(rows)
+		if err != nil {
+			util.ErrorResponse(c, http.StatusInternalServerError, "搜索球场失败")
+			return
+		}
+		distance := geo.Distance(lat, lng, court.Latitude, court.Longitude)
+		if !hasBox && distance > radius {
+			continue
+		}
+		court.Distance = &distance
+		courts = append(courts, court)
+	}
+	if err := rows.Err(); err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "搜索球场失败")
+		return
+	}
+
+	sort.SliceStable(courts, func(i, j int) bool {
+		return *courts[i].Distance < *courts[j].Distance
+	})
+	if len(courts) > limit {
+		courts = courts[:limit]
+	}
+	if err := loadCourtPhotos(courts); err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "搜索球场失败")
+		return
+	}
+	util.SuccessResponse(c, http.StatusOK, courts)
+}
+
+// GetCourt 球场详情
+func GetCourt(c *gin.Context) {
+	courtID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "无效的球场ID")
+		return
+	}
+	court, err := loadCourt(courtID)
+	if errors.Is(err, sql.ErrNoRows) {
+		util.ErrorResponse(c, http.StatusNotFound, "球场不存在")
+		return
+	}
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "查询球场失败")
+		return
+	}
+	util.SuccessResponse(c, http.StatusOK, court)
+}
+
+// CourtRequest 创建/更新球场请求
+type CourtRequest struct {
+	Name         string             `json:"name" binding:"required"`
+	Address      string             `json:"address"`
+	Latitude     *float64           `json:"latitude" binding:"required"`
+	Longitude    *float64           `json:"longitude" binding:"required"`
+	Surface      string             `json:"surface" binding:"required,oneof=wood acrylic rubber concrete asphalt other"`
+	Indoor       bool               `json:"indoor"`
+	Free         *bool              `json:"free"` // 默认免费
+	Price        string             `json:"price"`
+	OpeningHours model.OpeningHours `json:"opening_hours"` // 不传表示未知
+	Photos       []string           `json:"photos"`        // 图片地址（http/https 或本站 /uploads/ 路径）
+}
+
+// validate 校验并规范化请求
+func (req *CourtRequest) validate() error {
+	req.Name, req.Address, req.Price = strings.TrimSpace(req.Name), strings.TrimSpace(req.Address), strings.TrimSpace(req.Price)
+	if req.Name == "" {
+		return errors.New("球场名称不能为空")
+	}
+	if !geo.ValidCoordinate(*req.Latitude, *req.Longitude) {
+		return errors.New("无效的坐标")
+	}
+	if req.Free == nil {
+		free := true
+		req.Free = &free
+	}
+	if *req.Free {
+		req.Price = ""
+	}
+
+	for day, periods := range req.OpeningHours {
+		known := false
+		for _, d := range courtWeekdays {
+			known = known || d == day
+		}
+		if !known {
+			return fmt.Errorf("无效的营业日: %s", day)
+		}
+		for _, p := range periods {
+			open, ok1 := parseClock(p.Open)
+			close, ok2 := parseClock(p.Close)
+			if !ok1 || !ok2 || open >= close {
+				return fmt.Errorf("无效的营业时段: %s %s-%s", day, p.Open, p.Close)
+			}
+		}
+	}
+
+	if len(req.Photos) > courtMaxPhotos {
+		return fmt.Errorf("最多 %d 张照片", courtMaxPhotos)
+	}
+	for _, url := range req.Photos {
+		if !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "/uploads/") {
+			return fmt.Errorf("无效的照片地址: %s", url)
+		}
+	}
+	return nil
+}
+
+// parseClock 解析 HH:MM（允许 24:00），返回当天的分钟数
+func parseClock(s string) (int, bool) {
+	var h, m int
+	if len(s) != 5 || s[2] != ':' {
+		return 0, false
+	}
+	if _, err := fmt.Sscanf(s, "%02d:%02d", &h, &m); err != nil {
+		return 0, false
+	}
+	if h < 0 || m < 0 || m > 59 || h > 24 || (h == 24 && m != 0) {
+		return 0, false
+	}
+	return h*60 + m, true
+}
+
+// saveCourtPhotos 替换球场照片
+func saveCourtPhotos(tx *sql.Tx, courtID int, photos []string) error {
+	if _, err := tx.Exec("DELETE FROM court_photos WHERE court_id = ?", courtID); err != nil {
+		return err
+	}
+	for i, url := range photos {
+		if _, err := tx.Exec("INSERT INTO court_photos (court_id, url, position) VALUES (?, ?, ?)", courtID, url, i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// openingHoursJSON 营业时间序列化，未知时存 NULL
+func openingHoursJSON(hours model.OpeningHours) interface{} {
+	if hours == nil {
+		return nil
+	}
+	data, _ := json.Marshal(hours)
+	return string(data)
+}
+
+// CreateCourt 新增球场
+func CreateCourt(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req CourtRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+	if err := req.validate(); err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tx, err := db.GetDB().Begin()
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "新增球场失败")
+		return
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		INSERT INTO courts (name, address, latitude, longitude, geohash, surface, indoor, free, price, opening_hours, created_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, req.Name, req.Address, *req.Latitude, *req.Longitude, geo.Encode(*req.Latitude, *req.Longitude, geo.Precision),
+		req.Surface, req.Indoor, *req.Free, req.Price, openingHoursJSON(req.OpeningHours), userID)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "新增球场失败")
+		return
+	}
+	courtID, _ := result.LastInsertId()
+	if err := saveCourtPhotos(tx, int(courtID), req.Photos); err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "新增球场失败")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "新增球场失败")
+		return
+	}
+
+	court, err := loadCourt(int(courtID))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "查询球场失败")
+		return
+	}
+	util.SuccessResponse(c, http.StatusCreated, court)
+}
+
+// UpdateCourt 更新球场信息（仅创建者）
+func UpdateCourt(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	courtID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "无效的球场ID")
+		return
+	}
+
+	var createdBy int
+	err = db.GetDB().QueryRow("SELECT created_by FROM courts WHERE id = ?", courtID).Scan(&createdBy)
+	if errors.Is(err, sql.ErrNoRows) {
+		util.ErrorResponse(c, http.StatusNotFound, "球场不存在")
+		return
+	}
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "查询球场失败")
+		return
+	}
+	if createdBy != userID {
+		util.ErrorResponse(c, http.StatusForbidden, "只有创建者可以修改球场信息")
+		return
+	}
+
+	var req CourtRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+	if err := req.validate(); err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tx, err := db.GetDB().Begin()
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "更新球场失败")
+		return
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		UPDATE courts SET name = ?, address = ?, latitude = ?, longitude = ?, geohash = ?, surface = ?,
+			indoor = ?, free = ?, price = ?, opening_hours = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, req.Name, req.Address, *req.Latitude, *req.Longitude, geo.Encode(*req.Latitude, *req.Longitude, geo.Precision),
+		req.Surface, req.Indoor, *req.Free, req.Price, openingHoursJSON(req.OpeningHours), courtID); err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "更新球场失败")
+		return
+	}
+	if err := saveCourtPhotos(tx, courtID, req.Photos); err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "更新球场失败")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "更新球场失败")
+		return
+	}
+
+	court, err := loadCourt(courtID)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "查询球场失败")
+		return
+	}
+	util.SuccessResponse(c, http.StatusOK, court)
+}
@@ -0,0 +1,278 @@
+package api
+
+import (
+	"buzzerbeater/review"
+	"buzzerbeater/util"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 评价参数限制
+const (
+	reviewMaxPhotos   = 6
+	reviewMaxContent  = 1000 // 字数
+	reviewDefaultPage = 20
+	reviewMaxPage     = 100
+)
+
+// GetCourtReviews 球场评价列表（登录时不展示与当前用户存在拉黑关系的用户的评价）
+// 参数：sort（recent 默认 / helpful / rating）、limit、offset
+func GetCourtReviews(c *gin.Context) {
+	courtID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "无效的球场ID")
+		return
+	}
+	sort := c.DefaultQuery("sort", review.SortRecent)
+	if !review.ValidSort(sort) {
+		util.ErrorResponse(c, http.StatusBadRequest, "sort 必须为 recent、helpful 或 rating")
+		return
+	}
+	limit, offset, ok := parsePage(c, reviewDefaultPage, reviewMaxPage)
+	if !ok {
+		return
+	}
+
+	reviews, err := review.List(courtID, viewerID(c), sort, limit, offset)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "查询评价失败")
+		return
+	}
+	util.SuccessResponse(c, http.StatusOK, reviews)
+}
+
+// parseReviewForm 解析评价表单（rating、content）
+func parseReviewForm(c *gin.Context) (int, string, error) {
+	rating, err := strconv.Atoi(c.PostForm("rating"))
+	if err != nil || rating < 1 || rating > 5 {
+		return 0, "", errors.New("评分必须为 1-5 星")
+	}
+	content := strings.TrimSpace(c.PostForm("content"))
+	if len([]rune(content)) > reviewMaxContent {
+		return 0, "", fmt.Errorf("评价内容不能超过 %d 字", reviewMaxContent)
+	}
+	return rating, content, nil
+}
+
+// saveReviewPhotos 保存表单中的 photos 图片（与头像相同的大小和格式校验）；没有上传时返回 nil
+func saveReviewPhotos(c *gin.Context) ([]string, error) {
+	form, err := c.MultipartForm()
+	if err != nil || len(form.File["photos"]) == 0 {
+		return nil, nil
+	}
+	files := form.File["photos"]
+	if len(files) > reviewMaxPhotos {
+		return nil, fmt.Errorf("最多上传 %d 张照片", reviewMaxPhotos)
+	}
+
+	photos := make([]string, 0, len(files))
+	for _, file := range files {
+		path, err := util.SaveImage(file, "reviews")
+		if err != nil {
+			deleteReviewPhotos(photos)
+			return nil, err
+		}
+		photos = append(photos, path)
+	}
+	return photos, nil
+}
+
+// deleteReviewPhotos 清理不再使用的评价照片
+func deleteReviewPhotos(photos []string) {
+	for _, path := range photos {
+		util.DeleteUpload(path)
+	}
+}
+
+// CreateCourtReview 发表球场评价（multipart：rating、content、photos 可多张），每人每个球场一条
+func CreateCourtReview(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	courtID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "无效的球场ID")
+		return
+	}
+	rating, content, err := parseReviewForm(c)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	photos, err := saveReviewPhotos(c)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	reviewID, err := review.Create(courtID, userID, rating, content, photos)
+	if err != nil {
+		deleteReviewPhotos(photos)
+	}
+	switch {
+	case errors.Is(err, review.ErrNotFound):
+		util.ErrorResponse(c, http.StatusNotFound, "球场不存在")
+		return
+	case errors.Is(err, review.ErrExists):
+		util.ErrorResponse(c, http.StatusConflict, "你已经评价过这个球场，可以修改原评价")
+		return
+	case err != nil:
+		util.ErrorResponse(c, http.StatusInternalServerError, "发表评价失败")
+		return
+	}
+
+	r, err := review.Get(reviewID)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "查询评价失败")
+		return
+	}
+	util.SuccessResponse(c, http.StatusCreated, r)
+}
+
+// reviewErrorResponse 作者操作评价时的错误响应
+func reviewErrorResponse(c *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, review.ErrNotFound):
+		util.ErrorResponse(c, http.StatusNotFound, "评价不存在")
+	case errors.Is(err, review.ErrNotAuthor):
+		util.ErrorResponse(c, http.StatusForbidden, "只能操作自己的评价")
+	case errors.Is(err, review.ErrRemoved):
+		util.ErrorResponse(c, http.StatusConflict, "评价已被管理员下架")
+	case errors.Is(err, review.ErrOwnReview):
+		util.ErrorResponse(c, http.StatusBadRequest, "不能给自己的评价点“有用”")
+	default:
+		util.ErrorResponse(c, http.StatusInternalServerError, msg)
+	}
+}
+
+// UpdateCourtReview 修改评价（multipart：rating、content；上传 photos 时替换全部照片，不上传则保留）
+func UpdateCourtReview(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	reviewID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "无效的评价ID")
+		return
+	}
+	rating, content, err := parseReviewForm(c)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	photos, err := saveReviewPhotos(c)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	replaced, err := review.Update(reviewID, userID, rating, content, photos)
+	if err != nil {
+		deleteReviewPhotos(photos)
+		reviewErrorResponse(c, err, "修改评价失败")
+		return
+	}
+	deleteReviewPhotos(replaced)
+
+	r, err := review.Get(reviewID)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "查询评价失败")
+		return
+	}
+	util.SuccessResponse(c, http.StatusOK, r)
+}
+
+// DeleteCourtReview 删除自己的评价
+func DeleteCourtReview(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	reviewID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "无效的评价ID")
+		return
+	}
+
+	photos, err := review.Delete(reviewID, userID)
+	if err != nil {
+		reviewErrorResponse(c, err, "删除评价失败")
+		return
+	}
+	deleteReviewPhotos(photos)
+	c.Status(http.StatusNoContent)
+}
+
+// setReviewHelpful 投或取消“有用”
+func setReviewHelpful(c *gin.Context, helpful bool) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	reviewID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "无效的评价ID")
+		return
+	}
+
+	count, err := review.SetHelpful(reviewID, userID, helpful)
+	if err != nil {
+		reviewErrorResponse(c, err, "操作失败")
+		return
+	}
+	util.SuccessResponse(c, http.StatusOK, gin.H{"review_id": reviewID, "helpful": helpful, "helpful_count": count})
+}
+
+// VoteCourtReviewHelpful 给评价点“有用”（重复点不重复计数）
+func VoteCourtReviewHelpful(c *gin.Context) {
+	setReviewHelpful(c, true)
+}
+
+// UnvoteCourtReviewHelpful 取消“有用”
+func UnvoteCourtReviewHelpful(c *gin.Context) {
+	setReviewHelpful(c, false)
+}
+
+// TakeDownCourtReviewRequest 下架评价请求
+type TakeDownCourtReviewRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// TakeDownCourtReview 管理员下架评价，不再展示也不计入球场评分
+func TakeDownCourtReview(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	reviewID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "无效的评价ID")
+		return
+	}
+	var req TakeDownCourtReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "请填写下架原因")
+		return
+	}
+
+	err = review.TakeDown(reviewID, userID, strings.TrimSpace(req.Reason))
+	switch {
+	case errors.Is(err, review.ErrNotFound):
+		util.ErrorResponse(c, http.StatusNotFound, "评价不存在")
+		return
+	case errors.Is(err, review.ErrRemoved):
+		util.ErrorResponse(c, http.StatusConflict, "评价已下架")
+		return
+	case err != nil:
+		util.ErrorResponse(c, http.StatusInternalServerError, "下架评价失败")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
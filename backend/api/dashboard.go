@@ -0,0 +1,231 @@
+package api
+
+import (
+	"buzzerbeater/external"
+	"buzzerbeater/stats"
+	"buzzerbeater/util"
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 各版块的超时时间，某个数据源慢不会拖住整个首页
+const (
+	dashboardSectionTimeout = 3 * time.Second
+	dashboardFeedSize       = 5
+)
+
+// 版块状态
+const (
+	sectionOK      = "ok"
+	sectionTimeout = "timeout"
+	sectionError   = "error"
+)
+
+// DashboardGames 主队最近一场和下一场比赛
+type DashboardGames struct {
+	Last *ScheduleGame `json:"last"`
+	Next *ScheduleGame `json:"next"`
+}
+
+// DashboardFeedItem 首页动态
+type DashboardFeedItem struct {
+	Type   string `json:"type"` // game_result
+	Title  string `json:"title"`
+	Date   string `json:"date"`
+	GameID int    `json:"game_id,omitempty"`
+}
+
+// DashboardResponse 主队首页响应
+type DashboardResponse struct {
+	Season   int                 `json:"season"`
+	Team     *external.NBATeam   `json:"team"`
+	Games    *DashboardGames     `json:"games"`
+	Standing *stats.Standing     `json:"standing"`
+	Roster   []RosterPlayer      `json:"roster"`
+	Feed     []DashboardFeedItem `json:"feed"`
+	Sections map[string]string   `json:"sections"` // 各版块状态：ok / timeout / error
+}
+
+// fetchSection 在独立超时内获取一个版块，超时或出错时返回零值和对应状态
+func fetchSection[T any](ctx context.Context, fn func(context.Context) (T, error)) (T, string) {
+	ctx, cancel := context.WithTimeout(ctx, dashboardSectionTimeout)
+	defer cancel()
+
+	type result struct {
+		value T
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		value, err := fn(ctx)
+		done <- result{value, err}
+	}()
+
+	var zero T
+	select {
+	case r := <-done:
+		if r.err != nil {
+			if errors.Is(r.err, context.DeadlineExceeded) {
+				return zero, sectionTimeout
+			}
+			return zero, sectionError
+		}
+		return r.value, sectionOK
+	case <-ctx.Done():
+		return zero, sectionTimeout
+	}
+}
+
+// GetDashboard 主队个性化首页：下一场/上一场比赛、排名、阵容、动态
+func GetDashboard(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		util.ErrorResponse(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	ctx := c.Request.Context()
+	team, err := loadHomeTeam(ctx, userID)
+	if err != nil {
+		homeTeamErrorResponse(c, err)
+		return
+	}
+
+	season := currentSeason()
+	response := DashboardResponse{Season: season, Team: team, Sections: map[string]string{}}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	setStatus := func(section, status string) {
+		mu.Lock()
+		response.Sections[section] = status
+		mu.Unlock()
+	}
+
+	wg.Add(4)
+	go func() {
+		defer wg.Done()
+		games, status := fetchSection(ctx, func(ctx context.Context) (*DashboardGames, error) {
+			return dashboardGames(ctx, season, *team)
+		})
+		response.Games = games
+		setStatus("games", status)
+	}()
+	go func() {
+		defer wg.Done()
+		standing, status := fetchSection(ctx, func(ctx context.Context) (*stats.Standing, error) {
+			return dashboardStanding(ctx, season, *team)
+		})
+		response.Standing = standing
+		setStatus("standing", status)
+	}()
+	go func() {
+		defer wg.Done()
+		roster, status := fetchSection(ctx, func(ctx context.Context) ([]RosterPlayer, error) {
+			return dashboardRoster(ctx, season, team.ID)
+		})
+		response.Roster = roster
+		setStatus("roster", status)
+	}()
+	go func() {
+		defer wg.Done()
+		feed, status := fetchSection(ctx, func(ctx context.Context) ([]DashboardFeedItem, error) {
+			return dashboardFeed(ctx, season, *team)
+		})
+		response.Feed = feed
+		setStatus("feed", status)
+	}()
+	wg.Wait()
+
+	util.SuccessResponse(c, http.StatusOK, response)
+}
+
+// dashboardGames 主队最近一场已结束和下一场未结束的比赛
+func dashboardGames(ctx context.Context, season int, team external.NBATeam) (*DashboardGames, error) {
+	games, _, err := loadGames(ctx, season, team.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DashboardGames{}
+	for _, g := range buildSchedule(team, games) {
+		if g.Result != "" {
+			last := g
+			result.Last = &last
+		} else if result.Next == nil {
+			next := g
+			result.Next = &next
+		}
+	}
+	return result, nil
+}
+
+// dashboardStanding 主队在所在联盟的排名
+func dashboardStanding(ctx context.Context, season int, team external.NBATeam) (*stats.Standing, error) {
+	teams, _, err := loadTeams(ctx)
+	if err != nil {
+		return nil, err
+	}
+	games, _, err := loadGames(ctx, season, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, group := range stats.ComputeStandings(teams, games, "conference") {
+		for _, s := range group.Teams {
+			if s.Team.ID == team.ID {
+				return s, nil
+			}
+		}
+	}
+	return nil, errHomeTeamNotFound
+}
+
+// dashboardRoster 主队现役阵容（按得分降序）
+func dashboardRoster(ctx context.Context, season int, teamID int) ([]RosterPlayer, error) {
+	roster, _, _, err := loadRoster(ctx, season, teamID)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(roster, func(i, j int) bool {
+		return rosterPoints(roster[i]) > rosterPoints(roster[j])
+	})
+	return roster, nil
+}
+
+func rosterPoints(p RosterPlayer) float64 {
+	if p.Stats == nil {
+		return -1
+	}
+	return p.Stats.Pts
+}
+
+// dashboardFeed 主队近期动态（目前为最近几场比赛的赛果）
+func dashboardFeed(ctx context.Context, season int, team external.NBATeam) ([]DashboardFeedItem, error) {
+	games, _, err := loadGames(ctx, season, team.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule := buildSchedule(team, games)
+	feed := []DashboardFeedItem{}
+	for i := len(schedule) - 1; i >= 0 && len(feed) < dashboardFeedSize; i-- {
+		g := schedule[i]
+		if g.Result == "" {
+			continue
+		}
+		feed = append(feed, DashboardFeedItem{
+			Type:   "game_result",
+			Title:  scheduleSummary(team, g),
+			Date:   g.Date,
+			GameID: g.GameID,
+		})
+	}
+	return feed, nil
+}
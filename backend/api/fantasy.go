@@ -0,0 +1,810 @@
+package api
+
+import (
+	"buzzerbeater/db"
+	"buzzerbeater/external"
+	"buzzerbeater/fantasy"
+	"buzzerbeater/model"
+	"buzzerbeater/util"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 联赛参数限制
+const (
+	fantasyMinTeams     = 2
+	fantasyMaxTeams     = 20
+	fantasyDefaultTeams = 10
+	fantasyMaxWeeks     = 26
+	fantasyDefaultWeeks = 20
+)
+
+// CreateFantasyLeagueRequest 创建联赛请求
+type CreateFantasyLeagueRequest struct {
+	Name        string                `json:"name" binding:"required"`
+	TeamName    string                `json:"team_name" binding:"required"` // 创建者自己的球队名
+	ScoringType string                `json:"scoring_type" binding:"required,oneof=points category"`
+	Scoring     *model.FantasyScoring `json:"scoring"`      // 不传时使用默认权重/类别
+	RosterSlots map[string]int        `json:"roster_slots"` // 不传时使用默认阵容配置
+	MaxTeams    int                   `json:"max_teams"`
+	Weeks       int                   `json:"weeks"`
+	StartDate   string                `json:"start_date"` // 任意日期，取所在周的周一；默认下周一
+}
+
+// CreateFantasyLeague 创建联赛，创建者自动加入并成为管理员
+func CreateFantasyLeague(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req CreateFantasyLeagueRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+	req.Name, req.TeamName = strings.TrimSpace(req.Name), strings.TrimSpace(req.TeamName)
+	if req.Name == "" || req.TeamName == "" {
+		util.ErrorResponse(c, http.StatusBadRequest, "联赛名和球队名不能为空")
+		return
+	}
+
+	if req.MaxTeams == 0 {
+		req.MaxTeams = fantasyDefaultTeams
+	}
+	if req.MaxTeams < fantasyMinTeams || req.MaxTeams > fantasyMaxTeams {
+		util.ErrorResponse(c, http.StatusBadRequest, "联赛球队数必须在2到20之间")
+		return
+	}
+	if req.Weeks == 0 {
+		req.Weeks = fantasyDefaultWeeks
+	}
+	if req.Weeks < 1 || req.Weeks > fantasyMaxWeeks {
+		util.ErrorResponse(c, http.StatusBadRequest, "联赛周数必须在1到26之间")
+		return
+	}
+
+	start := fantasy.WeekStart(time.Now()).AddDate(0, 0, 7)
+	if req.StartDate != "" {
+		date, err := time.Parse("2006-01-02", req.StartDate)
+		if err != nil {
+			util.ErrorResponse(c, http.StatusBadRequest, "无效的开始日期")
+			return
+		}
+		start = fantasy.WeekStart(date)
+	}
+
+	var scoring model.FantasyScoring
+	if req.Scoring != nil {
+		scoring = *req.Scoring
+	}
+	scoring, err := fantasy.NormalizeScoring(req.ScoringType, scoring)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	slots, err := fantasy.NormalizeRosterSlots(req.RosterSlots)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	scoringJSON, _ := json.Marshal(scoring)
+	slotsJSON, _ := json.Marshal(slots)
+
+	tx, err := db.GetDB().Begin()
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "创建联赛失败")
+		return
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		INSERT INTO fantasy_leagues (name, owner_id, season, scoring_type, scoring, roster_slots, max_teams, start_date, weeks)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, req.Name, userID, currentSeason(), req.ScoringType, string(scoringJSON), string(slotsJSON),
+		req.MaxTeams, start.Format("2006-01-02"), req.Weeks)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "创建联赛失败")
+		return
+	}
+	leagueID, _ := result.LastInsertId()
+
+	if _, err := tx.Exec("INSERT INTO fantasy_teams (league_id, user_id, name) VALUES (?, ?, ?)",
+		leagueID, userID, req.TeamName); err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "创建联赛失败")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "创建联赛失败")
+		return
+	}
+
+	league, err := loadFantasyLeague(int(leagueID))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "查询联赛失败")
+		return
+	}
+	util.SuccessResponse(c, http.StatusCreated, league)
+}
+
+// loadFantasyLeague 联赛及其球队（按战绩排名）
+func loadFantasyLeague(id int) (*model.FantasyLeague, error) {
+	league, err := fantasy.GetLeague(id)
+	if err != nil {
+		return nil, err
+	}
+	if league.Teams, err = fantasy.LeagueTeams(id); err != nil {
+		return nil, err
+	}
+	return league, nil
+}
+
+// fantasyLeagueForMember 解析路径中的联赛 ID 并确认当前用户是联赛成员，失败时已写入错误响应
+func fantasyLeagueForMember(c *gin.Context, userID int) (*model.FantasyLeague, bool) {
+	leagueID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "无效的联赛ID")
+		return nil, false
+	}
+	league, err := loadFantasyLeague(leagueID)
+	if errors.Is(err, fantasy.ErrNotFound) {
+		util.ErrorResponse(c, http.StatusNotFound, "联赛不存在")
+		return nil, false
+	}
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "查询联赛失败")
+		return nil, false
+	}
+	for _, t := range league.Teams {
+		if t.UserID == userID {
+			return league, true
+		}
+	}
+	// 不暴露非成员看不到的联赛是否存在
+	util.ErrorResponse(c, http.StatusNotFound, "联赛不存在")
+	return nil, false
+}
+
+// GetFantasyLeagues 当前用户参加的联赛
+func GetFantasyLeagues(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	leagues, err := fantasy.UserLeagues(userID)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "查询联赛失败")
+		return
+	}
+	util.SuccessResponse(c, http.StatusOK, leagues)
+}
+
+// GetFantasyLeague 联赛详情（仅成员可见）
+func GetFantasyLeague(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	league, ok := fantasyLeagueForMember(c, userID)
+	if !ok {
+		return
+	}
+	util.SuccessResponse(c, http.StatusOK, league)
+}
+
+// StartFantasyLeague 管理员开始联赛：生成整个赛季的每周对阵，之后不能再加入新球队
+func StartFantasyLeague(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	league, ok := fantasyLeagueForMember(c, userID)
+	if !ok {
+		return
+	}
+	if league.OwnerID != userID {
+		util.ErrorResponse(c, http.StatusForbidden, "只有联赛管理员可以开始联赛")
+		return
+	}
+	if league.Status != model.FantasyLeaguePending {
+		util.ErrorResponse(c, http.StatusConflict, "联赛已经开始")
+		return
+	}
+	if len(league.Teams) < fantasyMinTeams {
+		util.ErrorResponse(c, http.StatusBadRequest, "至少需要2支球队才能开始联赛")
+		return
+	}
+
+	err := fantasy.StartLeague(league, league.Teams)
+	if errors.Is(err, fantasy.ErrNotFound) {
+		util.ErrorResponse(c, http.StatusConflict, "联赛已经开始")
+		return
+	}
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "生成赛程失败")
+		return
+	}
+
+	league.Status = model.FantasyLeagueActive
+	util.SuccessResponse(c, http.StatusOK, league)
+}
+
+// FantasyInvitationRequest 邀请请求
+type FantasyInvitationRequest struct {
+	UserID int `json:"user_id" binding:"required"`
+}
+
+// CreateFantasyInvitation 管理员邀请用户加入联赛（仅招募中的联赛）
+func CreateFantasyInvitation(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	league, ok := fantasyLeagueForMember(c, userID)
+	if !ok {
+		return
+	}
+	if league.OwnerID != userID {
+		util.ErrorResponse(c, http.StatusForbidden, "只有联赛管理员可以邀请")
+		return
+	}
+	if league.Status != model.FantasyLeaguePending {
+		util.ErrorResponse(c, http.StatusConflict, "联赛已经开始，不能再邀请")
+		return
+	}
+
+	var req FantasyInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+
+	var exists bool
+	db.GetDB().QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE id = ?)", req.UserID).Scan(&exists)
+	if !exists {
+		util.ErrorResponse(c, http.StatusNotFound, "用户不存在")
+		return
+	}
+	for _, t := range league.Teams {
+		if t.UserID == req.UserID {
+			util.ErrorResponse(c, http.StatusConflict, "该用户已在联赛中")
+			return
+		}
+	}
+	db.GetDB().QueryRow(
+		"SELECT EXISTS(SELECT 1 FROM fantasy_invitations WHERE league_id = ? AND invitee_id = ? AND status = ?)",
+		league.ID, req.UserID, model.FantasyInvitationPending,
+	).Scan(&exists)
+	if exists {
+		util.ErrorResponse(c, http.StatusConflict, "已邀请过该用户")
+		return
+	}
+
+	result, err := db.GetDB().Exec(
+		"INSERT INTO fantasy_invitations (league_id, inviter_id, invitee_id) VALUES (?, ?, ?)",
+		league.ID, userID, req.UserID,
+	)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "邀请失败")
+		return
+	}
+	id, _ := result.LastInsertId()
+
+	invitation, err := loadFantasyInvitation(int(id))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "查询邀请失败")
+		return
+	}
+	util.SuccessResponse(c, http.StatusCreated, invitation)
+}
+
+const fantasyInvitationQuery = `
+	SELECT i.id, i.league_id, l.name, i.inviter_id, i.invitee_id, i.status, i.created_at, i.responded_at
+	FROM fantasy_invitations i
+	JOIN fantasy_leagues l ON l.id = i.league_id
+`
+
+func scanFantasyInvitation(row interface{ Scan(...interface{}) error }) (*model.FantasyInvitation, error) {
+	var inv model.FantasyInvitation
+	var respondedAt sql.NullTime
+	if err := row.Scan(&inv.ID, &inv.LeagueID, &inv.LeagueName, &inv.InviterID, &inv.InviteeID,
+		&inv.Status, &inv.CreatedAt, &respondedAt); err != nil {
+		return nil, err
+	}
+	if respondedAt.Valid {
+		inv.RespondedAt = &respondedAt.Time
+	}
+	return &inv, nil
+}
+
+func loadFantasyInvitation(id int) (*model.FantasyInvitation, error) {
+	return scanFantasyInvitation(db.GetDB().QueryRow(fantasyInvitationQuery+" WHERE i.id = ?", id))
+}
+
+// GetFantasyInvitations 当前用户收到的待处理邀请
+func GetFantasyInvitations(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	rows, err := db.GetDB().Query(fantasyInvitationQuery+" WHERE i.invitee_id = ? AND i.status = ? ORDER BY i.created_at DESC, i.id DESC",
+		userID, model.FantasyInvitationPending)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "查询邀请失败")
+		return
+	}
+	defer rows.Close()
+
+	invitations := []model.FantasyInvitation{}
+	for rows.Next() {
+		inv, err := scanFantasyInvitation(rows)
+		if err != nil {
+			util.ErrorResponse(c, http.StatusInternalServerError, "查询邀请失败")
+			return
+		}
+		invitations = append(invitations, *inv)
+	}
+	util.SuccessResponse(c, http.StatusOK, invitations)
+}
+
+// pendingInvitationForUser 解析路径中的邀请 ID 并确认是发给当前用户的待处理邀请，失败时已写入错误响应
+func pendingInvitationForUser(c *gin.Context, userID int) (*model.FantasyInvitation, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "无效的邀请ID")
+		return nil, false
+	}
+	inv, err := loadFantasyInvitation(id)
+	if err == sql.ErrNoRows || (err == nil && inv.InviteeID != userID) {
+		util.ErrorResponse(c, http.StatusNotFound, "邀请不存在")
+		return nil, false
+	}
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "查询邀请失败")
+		return nil, false
+	}
+	if inv.Status != model.FantasyInvitationPending {
+		util.ErrorResponse(c, http.StatusConflict, "邀请已处理")
+		return nil, false
+	}
+	return inv, true
+}
+
+// AcceptFantasyInvitationRequest 接受邀请请求
+type AcceptFantasyInvitationRequest struct {
+	TeamName string `json:"team_name" binding:"required"`
+}
+
+// AcceptFantasyInvitation 接受邀请并以指定球队名加入联赛
+func AcceptFantasyInvitation(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	inv, ok := pendingInvitationForUser(c, userID)
+	if !ok {
+		return
+	}
+
+	var req AcceptFantasyInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.TeamName) == "" {
+		util.ErrorResponse(c, http.StatusBadRequest, "请填写球队名")
+		return
+	}
+
+	tx, err := db.GetDB().Begin()
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "加入联赛失败")
+		return
+	}
+	defer tx.Rollback()
+
+	// 联赛状态和球队数在事务内读取，避免与开始选秀或其他人加入并发时越过检查
+	var status string
+	var maxTeams, teamCount int
+	err = tx.QueryRow(`
+		SELECT l.status, l.max_teams, (SELECT COUNT(*) FROM fantasy_teams WHERE league_id = l.id)
+		FROM fantasy_leagues l WHERE l.id = ?
+	`, inv.LeagueID).Scan(&status, &maxTeams, &teamCount)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "查询联赛失败")
+		return
+	}
+	if status != model.FantasyLeaguePending {
+		util.ErrorResponse(c, http.StatusConflict, "联赛已经开始")
+		return
+	}
+	if teamCount >= maxTeams {
+		util.ErrorResponse(c, http.StatusConflict, "联赛已满")
+		return
+	}
+
+	result, err := tx.Exec(
+		"UPDATE fantasy_invitations SET status = ?, responded_at = CURRENT_TIMESTAMP WHERE id = ? AND status = ?",
+		model.FantasyInvitationAccepted, inv.ID, model.FantasyInvitationPending,
+	)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "加入联赛失败")
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		util.ErrorResponse(c, http.StatusConflict, "邀请已处理")
+		return
+	}
+	if _, err := tx.Exec("INSERT INTO fantasy_teams (league_id, user_id, name) VALUES (?, ?, ?)",
+		inv.LeagueID, userID, strings.TrimSpace(req.TeamName)); err != nil {
+		util.ErrorResponse(c, http.StatusConflict, "已在联赛中")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "加入联赛失败")
+		return
+	}
+
+	joined, err := loadFantasyLeague(inv.LeagueID)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "查询联赛失败")
+		return
+	}
+	util.SuccessResponse(c, http.StatusOK, joined)
+}
+
+// DeclineFantasyInvitation 拒绝邀请
+func DeclineFantasyInvitation(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	inv, ok := pendingInvitationForUser(c, userID)
+	if !ok {
+		return
+	}
+
+	_, err := db.GetDB().Exec(
+		"UPDATE fantasy_invitations SET status = ?, responded_at = CURRENT_TIMESTAMP WHERE id = ? AND status = ?",
+		model.FantasyInvitationDeclined, inv.ID, model.FantasyInvitationPending,
+	)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "拒绝邀请失败")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// FantasyRosterEntry 阵容中的球员
+type FantasyRosterEntry struct {
+	Slot       string              `json:"slot"`
+	Player     *external.NBAPlayer `json:"player"`
+	AcquiredAt time.Time           `json:"acquired_at"`
+}
+
+// FantasyTeamResponse 球队详情响应
+type FantasyTeamResponse struct {
+	*model.FantasyTeam
+	Roster []FantasyRosterEntry `json:"roster"`
+}
+
+// fantasyTeamForMember 解析路径中的球队 ID，确认当前用户是所在联赛的成员，失败时已写入错误响应
+func fantasyTeamForMember(c *gin.Context, userID int) (*model.FantasyTeam, *model.FantasyLeague, bool) {
+	teamID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "无效的球队ID")
+		return nil, nil, false
+	}
+	team, err := fantasy.GetTeam(teamID)
+	if errors.Is(err, fantasy.ErrNotFound) {
+		util.ErrorResponse(c, http.StatusNotFound, "球队不存在")
+		return nil, nil, false
+	}
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "查询球队失败")
+		return nil, nil, false
+	}
+	if _, err := fantasy.UserTeam(team.LeagueID, userID); err != nil {
+		util.ErrorResponse(c, http.StatusNotFound, "球队不存在")
+		return nil, nil, false
+	}
+	league, err := fantasy.GetLeague(team.LeagueID)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "查询联赛失败")
+		return nil, nil, false
+	}
+	return team, league, true
+}
+
+// GetFantasyTeam 球队详情及阵容（联赛成员可见）
+func GetFantasyTeam(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	team, _, ok := fantasyTeamForMember(c, userID)
+	if !ok {
+		return
+	}
+
+	spots, err := fantasy.Roster(team.ID)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "查询阵容失败")
+		return
+	}
+
+	ctx := c.Request.Context()
+	roster := make([]FantasyRosterEntry, 0, len(spots))
+	for _, s := range spots {
+		player, _, err := loadPlayer(ctx, s.PlayerID)
+		if err != nil {
+			// 上游暂时不可用时只返回 ID，不影响阵容展示
+			player = &external.NBAPlayer{ID: s.PlayerID}
+		}
+		roster = append(roster, FantasyRosterEntry{Slot: s.Slot, Player: player, AcquiredAt: s.AcquiredAt})
+	}
+	util.SuccessResponse(c, http.StatusOK, FantasyTeamResponse{FantasyTeam: team, Roster: roster})
+}
+
+// FantasyRosterRequest 签入球员/调整位置请求
+type FantasyRosterRequest struct {
+	PlayerID int    `json:"player_id"`
+	Slot     string `json:"slot" binding:"required"`
+}
+
+// checkRosterSlot 检查位置是否可用：球员位置符合、该位置还有空位（exclude 为正在调整位置的球员），失败时已写入错误响应
+func checkRosterSlot(c *gin.Context, league *model.FantasyLeague, teamID int, player *external.NBAPlayer, slot string, exclude int) bool {
+	capacity, ok := league.RosterSlots[slot]
+	if !ok || capacity == 0 {
+		util.ErrorResponse(c, http.StatusBadRequest, "联赛没有该位置")
+		return false
+	}
+	if !fantasy.Eligible(slot, player.Position) {
+		util.ErrorResponse(c, http.StatusBadRequest, "球员位置不符合")
+		return false
+	}
+
+	var used int
+	db.GetDB().QueryRow("SELECT COUNT(*) FROM fantasy_rosters WHERE team_id = ? AND slot = ? AND player_id != ?",
+		teamID, slot, exclude).Scan(&used)
+	if used >= capacity {
+		util.ErrorResponse(c, http.StatusConflict, "该位置已满")
+		return false
+	}
+	return true
+}
+
+// fantasyLockWindow 开赛后多久内未结束的比赛视为进行中（上游状态更新不及时时也会解锁）
+const fantasyLockWindow = 6 * time.Hour
+
+// checkFantasyPlayerUnlocked 球员所在球队的比赛已开赛且未结束时不能签入、调整或裁掉该球员，失败时已写入错误响应
+func checkFantasyPlayerUnlocked(c *gin.Context, league *model.FantasyLeague, player *external.NBAPlayer) bool {
+	if player.Team.ID == 0 {
+		return true
+	}
+	games, _, err := loadGames(c.Request.Context(), league.Season, player.Team.ID)
+	if err != nil {
+		nbaErrorResponse(c, "获取比赛信息失败", err)
+		return false
+	}
+	now := time.Now()
+	for i := range games {
+		g := &games[i]
+		if g.IsFinal() || g.IsCancelled() {
+			continue
+		}
+		tipOff, err := g.TipOff()
+		if err != nil || tipOff.After(now) || now.Sub(tipOff) > fantasyLockWindow {
+			continue
+		}
+		util.ErrorResponse(c, http.StatusConflict, "球员的比赛已开赛，赛后才能调整")
+		return false
+	}
+	return true
+}
+
+// ownFantasyTeam 确认球队属于当前用户且联赛未结束，失败时已写入错误响应
+func ownFantasyTeam(c *gin.Context, userID int) (*model.FantasyTeam, *model.FantasyLeague, bool) {
+	team, league, ok := fantasyTeamForMember(c, userID)
+	if !ok {
+		return nil, nil, false
+	}
+	if team.UserID != userID {
+		util.ErrorResponse(c, http.StatusForbidden, "只能管理自己的球队")
+		return nil, nil, false
+	}
+	if league.Status == model.FantasyLeagueFinished {
+		util.ErrorResponse(c, http.StatusConflict, "联赛已结束")
+		return nil, nil, false
+	}
+	return team, league, true
+}
+
+// AddFantasyPlayer 签入自由球员到指定位置
+func AddFantasyPlayer(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	team, league, ok := ownFantasyTeam(c, userID)
+	if !ok {
+		return
+	}
+
+	var req FantasyRosterRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.PlayerID <= 0 {
+		util.ErrorResponse(c, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+
+	player, _, err := loadPlayer(c.Request.Context(), req.PlayerID)
+	if err != nil {
+		nbaErrorResponse(c, "球员不存在", err)
+		return
+	}
+
+	var rosterSize int
+	db.GetDB().QueryRow("SELECT COUNT(*) FROM fantasy_rosters WHERE team_id = ?", team.ID).Scan(&rosterSize)
+	if rosterSize >= fantasy.RosterSize(league.RosterSlots) {
+		util.ErrorResponse(c, http.StatusConflict, "阵容已满")
+		return
+	}
+	if !checkRosterSlot(c, league, team.ID, player, req.Slot, 0) || !checkFantasyPlayerUnlocked(c, league, player) {
+		return
+	}
+
+	err = fantasy.AddPlayer(league.ID, team.ID, req.PlayerID, req.Slot)
+	if errors.Is(err, fantasy.ErrPlayerTaken) {
+		util.ErrorResponse(c, http.StatusConflict, "球员已被联赛中其他球队签下")
+		return
+	}
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "签入球员失败")
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusCreated, FantasyRosterEntry{Slot: req.Slot, Player: player, AcquiredAt: time.Now()})
+}
+
+// rosterPlayerParam 解析路径中的球员 ID 并确认球员在该队阵容中，失败时已写入错误响应
+func rosterPlayerParam(c *gin.Context, teamID int) (int, bool) {
+	playerID, err := strconv.Atoi(c.Param("player_id"))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "无效的球员ID")
+		return 0, false
+	}
+	var exists bool
+	db.GetDB().QueryRow("SELECT EXISTS(SELECT 1 FROM fantasy_rosters WHERE team_id = ? AND player_id = ?)",
+		teamID, playerID).Scan(&exists)
+	if !exists {
+		util.ErrorResponse(c, http.StatusNotFound, "球员不在阵容中")
+		return 0, false
+	}
+	return playerID, true
+}
+
+// MoveFantasyPlayer 调整球员位置（首发/替补）
+func MoveFantasyPlayer(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	team, league, ok := ownFantasyTeam(c, userID)
+	if !ok {
+		return
+	}
+	playerID, ok := rosterPlayerParam(c, team.ID)
+	if !ok {
+		return
+	}
+
+	var req FantasyRosterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+
+	player, _, err := loadPlayer(c.Request.Context(), playerID)
+	if err != nil {
+		nbaErrorResponse(c, "获取球员信息失败", err)
+		return
+	}
+	if !checkRosterSlot(c, league, team.ID, player, req.Slot, playerID) || !checkFantasyPlayerUnlocked(c, league, player) {
+		return
+	}
+
+	if err := fantasy.MovePlayer(league.ID, team.ID, playerID, req.Slot); err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "调整位置失败")
+		return
+	}
+	util.SuccessResponse(c, http.StatusOK, gin.H{"player_id": playerID, "slot": req.Slot})
+}
+
+// DropFantasyPlayer 裁掉球员（成为自由球员）
+func DropFantasyPlayer(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	team, league, ok := ownFantasyTeam(c, userID)
+	if !ok {
+		return
+	}
+	playerID, ok := rosterPlayerParam(c, team.ID)
+	if !ok {
+		return
+	}
+
+	player, _, err := loadPlayer(c.Request.Context(), playerID)
+	if err != nil {
+		nbaErrorResponse(c, "获取球员信息失败", err)
+		return
+	}
+	if !checkFantasyPlayerUnlocked(c, league, player) {
+		return
+	}
+
+	if err := fantasy.DropPlayer(team.ID, playerID); err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "裁员失败")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetFantasyMatchups 联赛对阵（可选 week 参数）
+func GetFantasyMatchups(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	league, ok := fantasyLeagueForMember(c, userID)
+	if !ok {
+		return
+	}
+
+	week := 0
+	if weekStr := c.Query("week"); weekStr != "" {
+		w, err := strconv.Atoi(weekStr)
+		if err != nil || w < 1 || w > league.Weeks {
+			util.ErrorResponse(c, http.StatusBadRequest, "无效的周数")
+			return
+		}
+		week = w
+	}
+
+	matchups, err := fantasy.Matchups(league.ID, week)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "查询对阵失败")
+		return
+	}
+	util.SuccessResponse(c, http.StatusOK, matchups)
+}
+
+// FantasyStanding 联赛排名中的一支球队
+type FantasyStanding struct {
+	Rank int `json:"rank"`
+	model.FantasyTeam
+}
+
+// GetFantasyStandings 联赛排名（由计分任务根据已结束的对阵汇总）
+func GetFantasyStandings(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	league, ok := fantasyLeagueForMember(c, userID)
+	if !ok {
+		return
+	}
+
+	standings := make([]FantasyStanding, len(league.Teams))
+	for i, t := range league.Teams {
+		standings[i] = FantasyStanding{Rank: i + 1, FantasyTeam: t}
+	}
+	util.SuccessResponse(c, http.StatusOK, standings)
+}
@@ -0,0 +1,292 @@
+package api
+
+import (
+	"buzzerbeater/db"
+	"buzzerbeater/external"
+	"buzzerbeater/model"
+	"buzzerbeater/util"
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 关注参数
+const (
+	followMaxPerType         = 50 // 每人最多关注的球员数、球队数
+	followFeedGamesPerPlayer = 5
+	followFeedWindowDays     = 21 // 关注动态只看最近三周的比赛
+	followFeedLimit          = 50
+)
+
+// FollowedPlayer 关注的球员
+type FollowedPlayer struct {
+	Player     *external.NBAPlayer `json:"player"`
+	FollowedAt time.Time           `json:"followed_at"`
+}
+
+// FollowedTeam 关注的球队
+type FollowedTeam struct {
+	Team       *external.NBATeam `json:"team"`
+	FollowedAt time.Time         `json:"followed_at"`
+}
+
+// FollowsResponse 关注列表响应
+type FollowsResponse struct {
+	Players []FollowedPlayer `json:"players"`
+	Teams   []FollowedTeam   `json:"teams"`
+}
+
+// FollowFeedItem 关注动态（球员单场数据）
+type FollowFeedItem struct {
+	Player *external.NBAPlayer `json:"player"`
+	PlayerGameLog
+}
+
+// listFollows 查询用户的关注记录（按关注时间倒序）
+func listFollows(userID interface{}, targetType string) ([]model.NBAFollow, error) {
+	rows, err := db.GetDB().Query(`
+		SELECT user_id, target_type, target_id, created_at
+		FROM nba_follows
+		WHERE user_id = ? AND target_type = ?
+		ORDER BY created_at DESC, target_id
+	`, userID, targetType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	follows := []model.NBAFollow{}
+	for rows.Next() {
+		var f model.NBAFollow
+		if err := rows.Scan(&f.UserID, &f.TargetType, &f.TargetID, &f.CreatedAt); err != nil {
+			return nil, err
+		}
+		follows = append(follows, f)
+	}
+	return follows, rows.Err()
+}
+
+// followTargetIDs 关注记录中的目标 ID
+func followTargetIDs(follows []model.NBAFollow) []int {
+	ids := make([]int, len(follows))
+	for i, f := range follows {
+		ids[i] = f.TargetID
+	}
+	return ids
+}
+
+// GetFollows 获取当前用户关注的球员和球队
+func GetFollows(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		util.ErrorResponse(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	playerFollows, err := listFollows(userID, model.FollowTargetPlayer)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "获取关注列表失败")
+		return
+	}
+	teamFollows, err := listFollows(userID, model.FollowTargetTeam)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "获取关注列表失败")
+		return
+	}
+
+	ctx := c.Request.Context()
+	response := FollowsResponse{Players: []FollowedPlayer{}, Teams: []FollowedTeam{}}
+	players := loadPlayersByID(ctx, followTargetIDs(playerFollows))
+	for _, f := range playerFollows {
+		player, ok := players[f.TargetID]
+		if !ok {
+			// 上游暂时不可用时只返回 ID，不影响列表
+			player = &external.NBAPlayer{ID: f.TargetID}
+		}
+		response.Players = append(response.Players, FollowedPlayer{Player: player, FollowedAt: f.CreatedAt})
+	}
+
+	teams, _, _ := loadTeams(ctx)
+	for _, f := range teamFollows {
+		team, ok := findTeam(teams, f.TargetID)
+		if !ok {
+			team = &external.NBATeam{ID: f.TargetID}
+		}
+		response.Teams = append(response.Teams, FollowedTeam{Team: team, FollowedAt: f.CreatedAt})
+	}
+
+	util.SuccessResponse(c, http.StatusOK, response)
+}
+
+// FollowRequest 关注请求
+type FollowRequest struct {
+	Type string `json:"type" binding:"required,oneof=player team"`
+	ID   int    `json:"id" binding:"required"`
+}
+
+// CreateFollow 关注球员或球队
+func CreateFollow(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		util.ErrorResponse(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	var req FollowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+
+	// 验证目标存在
+	ctx := c.Request.Context()
+	if req.Type == model.FollowTargetPlayer {
+		if _, _, err := loadPlayer(ctx, req.ID); err != nil {
+			nbaErrorResponse(c, "球员不存在", err)
+			return
+		}
+	} else {
+		teams, _, err := loadTeams(ctx)
+		if err != nil {
+			nbaErrorResponse(c, "获取球队列表失败", err)
+			return
+		}
+		if _, ok := findTeam(teams, req.ID); !ok {
+			util.ErrorResponse(c, http.StatusNotFound, "球队不存在")
+			return
+		}
+	}
+
+	// 已关注时保持幂等；数量检查和写入在同一条语句中，并发关注也不会超过上限
+	result, err := db.GetDB().Exec(`
+		INSERT OR IGNORE INTO nba_follows (user_id, target_type, target_id)
+		SELECT ?, ?, ?
+		WHERE (SELECT COUNT(*) FROM nba_follows WHERE user_id = ? AND target_type = ?) < ?
+	`, userID, req.Type, req.ID, userID, req.Type, followMaxPerType)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "关注失败")
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		var followed bool
+		if err := db.GetDB().QueryRow(
+			"SELECT EXISTS(SELECT 1 FROM nba_follows WHERE user_id = ? AND target_type = ? AND target_id = ?)",
+			userID, req.Type, req.ID,
+		).Scan(&followed); err != nil {
+			util.ErrorResponse(c, http.StatusInternalServerError, "关注失败")
+			return
+		}
+		if !followed {
+			util.ErrorResponse(c, http.StatusConflict, fmt.Sprintf("最多关注 %d 名球员和 %d 支球队", followMaxPerType, followMaxPerType))
+			return
+		}
+	}
+
+	util.SuccessResponse(c, http.StatusCreated, gin.H{"type": req.Type, "id": req.ID})
+}
+
+// DeleteFollow 取消关注
+func DeleteFollow(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		util.ErrorResponse(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	targetType := c.Param("type")
+	if targetType != model.FollowTargetPlayer && targetType != model.FollowTargetTeam {
+		util.ErrorResponse(c, http.StatusBadRequest, "无效的关注类型")
+		return
+	}
+	targetID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "无效的ID")
+		return
+	}
+
+	_, err = db.GetDB().Exec(
+		"DELETE FROM nba_follows WHERE user_id = ? AND target_type = ? AND target_id = ?",
+		userID, targetType, targetID,
+	)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "取消关注失败")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetFollowFeed 关注球员的近期单场数据（按比赛日期倒序）
+func GetFollowFeed(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		util.ErrorResponse(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	follows, err := listFollows(userID, model.FollowTargetPlayer)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "获取关注列表失败")
+		return
+	}
+
+	ctx := c.Request.Context()
+	teams, _, err := loadTeams(ctx)
+	if err != nil {
+		nbaErrorResponse(c, "获取球队列表失败", err)
+		return
+	}
+	abbreviations := make(map[int]string, len(teams))
+	for _, t := range teams {
+		abbreviations[t.ID] = t.Abbreviation
+	}
+
+	items := followFeedItems(ctx, follows, abbreviations)
+	util.SuccessResponse(c, http.StatusOK, items)
+}
+
+// followFeedItems 批量获取关注球员最近几场数据并合并排序，获取失败时返回空列表
+func followFeedItems(ctx context.Context, follows []model.NBAFollow, abbreviations map[int]string) []FollowFeedItem {
+	items := []FollowFeedItem{}
+	ids := followTargetIDs(follows)
+	since := time.Now().AddDate(0, 0, -followFeedWindowDays).Format("2006-01-02")
+	lines, _, err := loadRecentStats(ctx, ids, since)
+	if err != nil {
+		return items
+	}
+	players := loadPlayersByID(ctx, ids)
+
+	byPlayer := map[int][]external.NBAStat{}
+	for _, s := range lines {
+		byPlayer[s.Player.ID] = append(byPlayer[s.Player.ID], s)
+	}
+	for _, id := range ids {
+		player, ok := players[id]
+		if !ok {
+			continue
+		}
+		logs := buildGameLogs(byPlayer[id], abbreviations)
+		if len(logs) > followFeedGamesPerPlayer {
+			logs = logs[:followFeedGamesPerPlayer]
+		}
+		for _, log := range logs {
+			items = append(items, FollowFeedItem{Player: player, PlayerGameLog: log})
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].Date != items[j].Date {
+			return items[i].Date > items[j].Date
+		}
+		return items[i].Pts > items[j].Pts
+	})
+	if len(items) > followFeedLimit {
+		items = items[:followFeedLimit]
+	}
+	return items
+}
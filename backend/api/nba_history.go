@@ -0,0 +1,155 @@
+package api
+
+import (
+	"buzzerbeater/external"
+	"buzzerbeater/stats"
+	"buzzerbeater/util"
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 多赛季查询最多跨越的赛季数
+// 未同步的赛季球队要从上游拉全部比赛和单场数据，每个赛季几十次请求，因此范围很小且逐个赛季获取
+const (
+	playerRangeMaxSeasons = careerMaxSeasons
+	teamRangeMaxSeasons   = 3
+)
+
+// TeamHistoryResponse 球队队史响应
+type TeamHistoryResponse struct {
+	Team *external.NBATeam       `json:"team"`
+	Eras []external.FranchiseEra `json:"eras"` // 历史球队为空
+}
+
+// GetNBATeamHistory 获取球队的搬迁和改名历史
+func GetNBATeamHistory(c *gin.Context) {
+	teamID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "无效的球队ID")
+		return
+	}
+
+	teams, stale, err := loadAllTeams(c.Request.Context())
+	if err != nil {
+		nbaErrorResponse(c, "获取球队列表失败", err)
+		return
+	}
+	team, ok := findTeam(teams, teamID)
+	if !ok {
+		util.ErrorResponse(c, http.StatusNotFound, "球队不存在")
+		return
+	}
+	markStale(c, stale)
+
+	eras := []external.FranchiseEra{}
+	if !team.Historical {
+		if history := external.FranchiseHistory(team.Abbreviation); history != nil {
+			eras = history
+		}
+	}
+	util.SuccessResponse(c, http.StatusOK, TeamHistoryResponse{Team: team, Eras: eras})
+}
+
+// teamsInSeason 某赛季存在的球队，名称换成当时使用的城市和队名
+func teamsInSeason(teams []external.NBATeam, season int) []external.NBATeam {
+	result := make([]external.NBATeam, 0, len(teams))
+	for _, t := range teams {
+		if named, ok := external.TeamInSeason(t, season); ok {
+			result = append(result, named)
+		}
+	}
+	return result
+}
+
+// seasonRangeRequested 是否按 season_from/season_to 查询多个赛季
+func seasonRangeRequested(c *gin.Context) bool {
+	return c.Query("season") == "" && (c.Query("season_from") != "" || c.Query("season_to") != "")
+}
+
+// parseBoundedSeasonRange 解析赛季范围并检查跨度，失败时已写入错误响应
+func parseBoundedSeasonRange(c *gin.Context, maxSeasons int) (int, int, bool) {
+	from, to, ok := parseSeasonRange(c)
+	if !ok {
+		util.ErrorResponse(c, http.StatusBadRequest, "无效的赛季范围")
+		return 0, 0, false
+	}
+	if to-from+1 > maxSeasons {
+		util.ErrorResponse(c, http.StatusBadRequest, "赛季范围最多"+strconv.Itoa(maxSeasons)+"个赛季")
+		return 0, 0, false
+	}
+	return from, to, true
+}
+
+// getNBAPlayerStatsRange 球员多个赛季的场均数据（没有数据的赛季跳过）
+func getNBAPlayerStatsRange(c *gin.Context, playerID int) {
+	from, to, ok := parseBoundedSeasonRange(c, playerRangeMaxSeasons)
+	if !ok {
+		return
+	}
+
+	lines, stale := loadSeasonRange(c.Request.Context(), playerID, from, to)
+	markStale(c, stale)
+	util.SuccessResponse(c, http.StatusOK, lines)
+}
+
+// TeamSeasonStats 球队某赛季的名称和场均数据
+type TeamSeasonStats struct {
+	Season int                       `json:"season"`
+	Team   external.NBATeam          `json:"team"` // 当时使用的城市和队名
+	Stats  *stats.TeamSeasonAverages `json:"stats"`
+}
+
+// getNBATeamStatsRange 球队多个赛季的场均数据（球队尚未成立的赛季跳过）
+func getNBATeamStatsRange(c *gin.Context, teamID int) {
+	from, to, ok := parseBoundedSeasonRange(c, teamRangeMaxSeasons)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	teams, teamsStale, err := loadTeams(ctx)
+	if err != nil {
+		nbaErrorResponse(c, "获取球队列表失败", err)
+		return
+	}
+	team, ok := findTeam(teams, teamID)
+	if !ok {
+		util.ErrorResponse(c, http.StatusNotFound, "球队不存在")
+		return
+	}
+
+	seasons := []*TeamSeasonStats{}
+	anyStale := teamsStale
+	for season := from; season <= to; season++ {
+		named, exists := external.TeamInSeason(*team, season)
+		if !exists {
+			continue
+		}
+		avg, stale, err := loadTeamSeasonAverages(ctx, season, teamID)
+		if err != nil {
+			nbaErrorResponse(c, "获取球队数据失败", err)
+			return
+		}
+		seasons = append(seasons, &TeamSeasonStats{Season: season, Team: named, Stats: avg})
+		anyStale = anyStale || stale
+	}
+	markStale(c, anyStale)
+	util.SuccessResponse(c, http.StatusOK, seasons)
+}
+
+// loadTeamSeasonAverages 获取比赛和单场数据并计算球队赛季场均
+func loadTeamSeasonAverages(ctx context.Context, season int, teamID int) (*stats.TeamSeasonAverages, bool, error) {
+	games, gamesStale, err := loadGames(ctx, season, teamID)
+	if err != nil {
+		return nil, false, err
+	}
+	lines, statsStale, err := loadTeamGameStats(ctx, season, teamID, games)
+	if err != nil {
+		return nil, false, err
+	}
+	avg := stats.ComputeTeamAverages(teamID, season, games, lines)
+	return &avg, gamesStale || statsStale, nil
+}
@@ -0,0 +1,210 @@
+package api
+
+import (
+	"buzzerbeater/external"
+	"buzzerbeater/stats"
+	"buzzerbeater/util"
+	"buzzerbeater/warehouse"
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 排行榜参数默认值
+const (
+	leadersDefaultMinGames = 10
+	leadersDefaultLimit    = 20
+	leadersMaxLimit        = 100
+	leadersOverviewLimit   = 5
+)
+
+// leaderRanking 某赛季各类别预先排好序的排行（过滤条件在此基础上应用）
+type leaderRanking struct {
+	byCategory map[string][]stats.LeaderEntry
+	expiresAt  time.Time
+}
+
+var (
+	leaderRankings   = map[int]*leaderRanking{}
+	leaderRankingsMu sync.Mutex
+)
+
+// loadLeaderRanking 获取某赛季的预计算排行，过期后基于最新的联盟数据重新排序
+func loadLeaderRanking(ctx context.Context, season int) (map[string][]stats.LeaderEntry, error) {
+	leaderRankingsMu.Lock()
+	ranking, ok := leaderRankings[season]
+	leaderRankingsMu.Unlock()
+	if ok && time.Now().Before(ranking.expiresAt) {
+		return ranking.byCategory, nil
+	}
+
+	pool, err := loadLeagueAverages(ctx, season)
+	if err != nil {
+		return nil, err
+	}
+
+	byCategory := make(map[string][]stats.LeaderEntry, len(stats.Categories))
+	for _, category := range stats.Categories {
+		byCategory[category.Key] = stats.RankLeaders(pool, category)
+	}
+
+	leaderRankingsMu.Lock()
+	leaderRankings[season] = &leaderRanking{byCategory: byCategory, expiresAt: time.Now().Add(leagueAveragesTTL)}
+	leaderRankingsMu.Unlock()
+	return byCategory, nil
+}
+
+// LeaderRow 排行榜中的一行
+type LeaderRow struct {
+	Rank        int                 `json:"rank"`
+	Player      *external.NBAPlayer `json:"player"`
+	GamesPlayed int                 `json:"games_played"`
+	Value       float64             `json:"value"`
+}
+
+// Leaderboard 某类别排行榜
+type Leaderboard struct {
+	Category stats.Category `json:"category"`
+	Leaders  []LeaderRow    `json:"leaders"`
+}
+
+// LeadersResponse 排行榜响应
+type LeadersResponse struct {
+	Season   int           `json:"season"`
+	MinGames int           `json:"min_games"`
+	Boards   []Leaderboard `json:"boards"`
+}
+
+// leaderFilter 排行榜过滤条件
+type leaderFilter struct {
+	minGames    int
+	teamID      int
+	teamPlayers map[int]bool // 该赛季为 teamID 出场过的球员；为 nil 时按球员当前所在球队过滤
+	position    string
+	limit       int
+}
+
+// GetNBALeaders 联盟数据排行榜
+// 参数：category（不传则返回全部类别的前 5 名）、season、min_games（最少出场数）、team_id、position（G/F/C）、limit
+// 命中率类别另有场均出手门槛（见类别的 min_attempts）；team_id 按球员在该赛季效力的球队过滤，
+// 本地仓库未同步该赛季时按球员当前所在球队过滤
+func GetNBALeaders(c *gin.Context) {
+	season := currentSeason()
+	if seasonStr := c.Query("season"); seasonStr != "" {
+		if s, err := strconv.Atoi(seasonStr); err == nil {
+			season = s
+		}
+	}
+
+	filter := leaderFilter{
+		minGames: leadersDefaultMinGames,
+		position: strings.ToUpper(c.Query("position")),
+		limit:    leadersDefaultLimit,
+	}
+	if v, err := strconv.Atoi(c.Query("min_games")); err == nil && v >= 0 {
+		filter.minGames = v
+	}
+	if v, err := strconv.Atoi(c.Query("team_id")); err == nil {
+		filter.teamID = v
+	}
+	if v, err := strconv.Atoi(c.Query("limit")); err == nil && v > 0 {
+		filter.limit = min(v, leadersMaxLimit)
+	}
+
+	categories := stats.Categories
+	if key := c.Query("category"); key != "" {
+		category, ok := stats.CategoryByKey(key)
+		if !ok {
+			util.ErrorResponse(c, http.StatusBadRequest, "不支持的统计类别")
+			return
+		}
+		categories = []stats.Category{category}
+	} else if c.Query("limit") == "" {
+		filter.limit = leadersOverviewLimit
+	}
+
+	ctx := c.Request.Context()
+	ranking, err := loadLeaderRanking(ctx, season)
+	if errors.Is(err, errLeagueSeasonNotSynced) {
+		util.ErrorResponse(c, http.StatusBadRequest, "该赛季数据尚未同步，暂不支持查看排行榜")
+		return
+	}
+	if err != nil {
+		nbaErrorResponse(c, "获取排行榜失败", err)
+		return
+	}
+
+	players, err := loadPlayerIndex(ctx)
+	if err != nil {
+		nbaErrorResponse(c, "获取球员列表失败", err)
+		return
+	}
+	if filter.teamID > 0 && warehouse.Ready(warehouse.StatsResource(season)) {
+		if filter.teamPlayers, err = warehouse.SeasonTeamPlayers(filter.teamID, season); err != nil {
+			util.ErrorResponse(c, http.StatusInternalServerError, "获取球队球员失败")
+			return
+		}
+	}
+
+	response := LeadersResponse{Season: season, MinGames: filter.minGames, Boards: []Leaderboard{}}
+	for _, category := range categories {
+		response.Boards = append(response.Boards, Leaderboard{
+			Category: category,
+			Leaders:  filterLeaders(ranking[category.Key], players, filter),
+		})
+	}
+
+	util.SuccessResponse(c, http.StatusOK, response)
+}
+
+// filterLeaders 在预排序的结果上应用过滤条件，数值相同的球员名次相同
+func filterLeaders(entries []stats.LeaderEntry, players map[int]external.NBAPlayer, filter leaderFilter) []LeaderRow {
+	rows := []LeaderRow{}
+	for _, e := range entries {
+		if e.GamesPlayed < filter.minGames {
+			continue
+		}
+		player, ok := players[e.PlayerID]
+		if !ok {
+			continue
+		}
+		switch {
+		case filter.teamID == 0:
+		case filter.teamPlayers != nil:
+			if !filter.teamPlayers[e.PlayerID] {
+				continue
+			}
+		case player.Team.ID != filter.teamID:
+			continue
+		}
+		if filter.position != "" && !matchPosition(player.Position, filter.position) {
+			continue
+		}
+
+		rank := len(rows) + 1
+		if n := len(rows); n > 0 && rows[n-1].Value == e.Value {
+			rank = rows[n-1].Rank
+		}
+		if rank > filter.limit {
+			break
+		}
+		rows = append(rows, LeaderRow{Rank: rank, Player: &player, GamesPlayed: e.GamesPlayed, Value: e.Value})
+	}
+	return rows
+}
+
+// matchPosition 位置是否匹配（"G-F" 同时匹配 G 和 F）
+func matchPosition(position string, want string) bool {
+	for _, p := range strings.Split(position, "-") {
+		if p == want {
+			return true
+		}
+	}
+	return false
+}
@@ -1,13 +1,27 @@
 package api
 
 import (
+	"buzzerbeater/external"
+	"buzzerbeater/stats"
 	"buzzerbeater/util"
+	"buzzerbeater/warehouse"
+	"context"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// currentSeason 未指定赛季时默认查询的赛季（按日期推算）
+func currentSeason() int {
+	return external.CurrentSeason(time.Now())
+}
+
+// careerMaxSeasons 生涯数据最多回溯的赛季数
+const careerMaxSeasons = 15
+
 // GetNBAPlayers 获取 NBA 球员列表
 func GetNBAPlayers(c *gin.Context) {
 	// 可选的球队ID过滤
@@ -19,16 +33,18 @@ func GetNBAPlayers(c *gin.Context) {
 		}
 	}
 
-	players, err := getNBAClient().GetPlayers(teamID)
+	players, stale, err := loadPlayers(c.Request.Context(), teamID)
 	if err != nil {
-		util.ErrorResponse(c, http.StatusInternalServerError, "获取球员列表失败: "+err.Error())
+		nbaErrorResponse(c, "获取球员列表失败", err)
 		return
 	}
+	markStale(c, stale)
 
 	util.SuccessResponse(c, http.StatusOK, players)
 }
 
 // GetNBAPlayerStats 获取球员赛季数据
+// 参数：season（默认当前赛季），或 season_from/season_to 返回多个赛季的列表
 func GetNBAPlayerStats(c *gin.Context) {
 	playerIDStr := c.Param("id")
 	playerID, err := strconv.Atoi(playerIDStr)
@@ -37,19 +53,253 @@ func GetNBAPlayerStats(c *gin.Context) {
 		return
 	}
 
-	// 默认获取 2024 赛季数据
-	season := 2024
+	if seasonRangeRequested(c) {
+		getNBAPlayerStatsRange(c, playerID)
+		return
+	}
+
+	season := currentSeason()
+	if seasonStr := c.Query("season"); seasonStr != "" {
+		if s, err := strconv.Atoi(seasonStr); err == nil {
+			season = s
+		}
+	}
+
+	avg, stale, err := loadSeasonAverage(c.Request.Context(), playerID, season)
+	if err != nil {
+		nbaErrorResponse(c, "获取球员数据失败", err)
+		return
+	}
+	markStale(c, stale)
+
+	util.SuccessResponse(c, http.StatusOK, withAdvanced(avg))
+}
+
+// SeasonStatLine 场均数据及由其计算的进阶数据
+type SeasonStatLine struct {
+	*external.NBASeasonAverage
+	Advanced stats.Advanced `json:"advanced"`
+}
+
+// withAdvanced 为场均数据附加进阶数据
+func withAdvanced(avg *external.NBASeasonAverage) *SeasonStatLine {
+	if avg == nil {
+		return nil
+	}
+	return &SeasonStatLine{NBASeasonAverage: avg, Advanced: stats.Compute(avg)}
+}
+
+// PlayerGameLog 球员单场数据
+type PlayerGameLog struct {
+	GameID     int    `json:"game_id"`
+	Date       string `json:"date"`
+	Home       bool   `json:"home"`
+	OpponentID int    `json:"opponent_id"`
+	Opponent   string `json:"opponent"` // 对手缩写
+	Result     string `json:"result"`   // 如 "W 112-104"，未结束的比赛为空
+	Min        string `json:"min"`
+	Pts        int    `json:"pts"`
+	Reb        int    `json:"reb"`
+	Ast        int    `json:"ast"`
+	Stl        int    `json:"stl"`
+	Blk        int    `json:"blk"`
+	Turnover   int    `json:"turnover"`
+	Fgm        int    `json:"fgm"`
+	Fga        int    `json:"fga"`
+	Fg3m       int    `json:"fg3m"`
+	Fg3a       int    `json:"fg3a"`
+	Ftm        int    `json:"ftm"`
+	Fta        int    `json:"fta"`
+}
+
+// PlayerSplits 分项数据
+type PlayerSplits struct {
+	Home     *SeasonStatLine `json:"home"`
+	Away     *SeasonStatLine `json:"away"`
+	LastN    *SeasonStatLine `json:"last_n"`
+	LastNNum int             `json:"last_n_games"`
+}
+
+// PlayerDetailResponse 球员详情响应
+type PlayerDetailResponse struct {
+	Player   *external.NBAPlayer `json:"player"`
+	Season   int                 `json:"season"`
+	GameLogs []PlayerGameLog     `json:"game_logs"`
+	Career   []*SeasonStatLine   `json:"career"`
+	Splits   PlayerSplits        `json:"splits"`
+}
+
+// GetNBAPlayer 获取球员详情（基本信息、赛季单场数据、生涯数据、主客场及近 N 场分项）
+func GetNBAPlayer(c *gin.Context) {
+	playerID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "无效的球员ID")
+		return
+	}
+
+	season := currentSeason()
 	if seasonStr := c.Query("season"); seasonStr != "" {
 		if s, err := strconv.Atoi(seasonStr); err == nil {
 			season = s
 		}
 	}
 
-	stats, err := getNBAClient().GetPlayerSeasonAverages(playerID, season)
+	lastN := 10
+	if nStr := c.Query("last_n"); nStr != "" {
+		if n, err := strconv.Atoi(nStr); err == nil && n > 0 {
+			lastN = n
+		}
+	}
+
+	ctx := c.Request.Context()
+	player, stale, err := loadPlayer(ctx, playerID)
 	if err != nil {
-		util.ErrorResponse(c, http.StatusInternalServerError, "获取球员数据失败: "+err.Error())
+		nbaErrorResponse(c, "获取球员信息失败", err)
 		return
 	}
 
-	util.SuccessResponse(c, http.StatusOK, stats)
+	lines, statsStale, err := loadPlayerGameStats(ctx, playerID, season)
+	if err != nil {
+		nbaErrorResponse(c, "获取球员比赛数据失败", err)
+		return
+	}
+
+	teams, _, err := loadTeams(ctx)
+	if err != nil {
+		nbaErrorResponse(c, "获取球队列表失败", err)
+		return
+	}
+	abbreviations := make(map[int]string, len(teams))
+	for _, t := range teams {
+		abbreviations[t.ID] = t.Abbreviation
+	}
+
+	career, careerStale := loadCareer(ctx, playerID, player.DraftYear, season)
+	markStale(c, stale || statsStale || careerStale)
+
+	util.SuccessResponse(c, http.StatusOK, PlayerDetailResponse{
+		Player:   player,
+		Season:   season,
+		GameLogs: buildGameLogs(lines, abbreviations),
+		Career:   career,
+		Splits:   buildSplits(lines, lastN),
+	})
+}
+
+// loadCareer 获取各赛季平均数据，没有数据的赛季（未进入联盟、缺席整季）会被跳过
+func loadCareer(ctx context.Context, playerID int, draftYear int, lastSeason int) ([]*SeasonStatLine, bool) {
+	first := lastSeason - careerMaxSeasons + 1
+	if draftYear > first {
+		first = draftYear
+	}
+	if first > lastSeason {
+		first = lastSeason
+	}
+	return loadSeasonRange(ctx, playerID, first, lastSeason)
+}
+
+// loadSeasonRange 获取 first 到 last 各赛季的平均数据，获取失败或没有数据的赛季跳过
+// 本地仓库已同步的赛季直接读取；其余赛季请求上游，同时最多 upstreamConcurrency 个
+func loadSeasonRange(ctx context.Context, playerID int, first int, last int) ([]*SeasonStatLine, bool) {
+	results := make([]*external.NBASeasonAverage, last-first+1)
+	staleFlags := make([]bool, len(results))
+	var remote []int
+	for i := range results {
+		season := first + i
+		if !warehouse.Ready(warehouse.StatsResource(season)) {
+			remote = append(remote, i)
+			continue
+		}
+		// 已同步的赛季查不到说明该赛季没有出场，不再请求上游
+		if avg, err := warehouse.SeasonAverage(playerID, season); err == nil {
+			results[i] = avg
+		}
+	}
+	forEachBounded(len(remote), upstreamConcurrency, func(j int) {
+		i := remote[j]
+		avg, stale, err := getNBAClient().GetPlayerSeasonAverages(ctx, playerID, first+i)
+		if err == nil {
+			results[i] = avg
+			staleFlags[i] = stale
+		}
+	})
+
+	career := []*SeasonStatLine{}
+	anyStale := false
+	for i, avg := range results {
+		if avg != nil {
+			career = append(career, withAdvanced(avg))
+			anyStale = anyStale || staleFlags[i]
+		}
+	}
+	return career, anyStale
+}
+
+// buildGameLogs 生成单场数据列表（最近的比赛在前）
+func buildGameLogs(lines []external.NBAStat, abbreviations map[int]string) []PlayerGameLog {
+	logs := make([]PlayerGameLog, 0, len(lines))
+	for i := len(lines) - 1; i >= 0; i-- {
+		s := lines[i]
+		home := s.Team.ID == s.Game.HomeTeamID
+		opponentID, own, opp := s.Game.HomeTeamID, s.Game.VisitorTeamScore, s.Game.HomeTeamScore
+		if home {
+			opponentID, own, opp = s.Game.VisitorTeamID, s.Game.HomeTeamScore, s.Game.VisitorTeamScore
+		}
+
+		result := ""
+		if s.Game.Status == "Final" {
+			outcome := "L"
+			if own > opp {
+				outcome = "W"
+			}
+			result = fmt.Sprintf("%s %d-%d", outcome, own, opp)
+		}
+
+		logs = append(logs, PlayerGameLog{
+			GameID:     s.Game.ID,
+			Date:       s.Game.Date,
+			Home:       home,
+			OpponentID: opponentID,
+			Opponent:   abbreviations[opponentID],
+			Result:     result,
+			Min:        s.Min,
+			Pts:        s.Pts,
+			Reb:        s.Reb,
+			Ast:        s.Ast,
+			Stl:        s.Stl,
+			Blk:        s.Blk,
+			Turnover:   s.Turnover,
+			Fgm:        s.Fgm,
+			Fga:        s.Fga,
+			Fg3m:       s.Fg3m,
+			Fg3a:       s.Fg3a,
+			Ftm:        s.Ftm,
+			Fta:        s.Fta,
+		})
+	}
+	return logs
+}
+
+// buildSplits 由单场数据计算主客场和近 N 场分项（lines 按日期升序）
+func buildSplits(lines []external.NBAStat, lastN int) PlayerSplits {
+	var home, away []external.NBAStat
+	for _, s := range lines {
+		if s.Team.ID == s.Game.HomeTeamID {
+			home = append(home, s)
+		} else {
+			away = append(away, s)
+		}
+	}
+
+	recent := lines
+	if len(recent) > lastN {
+		recent = recent[len(recent)-lastN:]
+	}
+
+	return PlayerSplits{
+		Home:     withAdvanced(warehouse.AverageStats(home)),
+		Away:     withAdvanced(warehouse.AverageStats(away)),
+		LastN:    withAdvanced(warehouse.AverageStats(recent)),
+		LastNNum: lastN,
+	}
 }
@@ -0,0 +1,192 @@
+package api
+
+import (
+	"buzzerbeater/external"
+	"buzzerbeater/stats"
+	"buzzerbeater/util"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 对比参数限制
+const (
+	compareMinPlayers = 2
+	compareMaxPlayers = 5
+	compareMaxSeasons = 5
+)
+
+// percentileMinGames 计入联盟百分位样本的最少出场数（排除打了几场垃圾时间的球员）
+const percentileMinGames = 10
+
+// ComparedPlayer 参与对比的球员
+type ComparedPlayer struct {
+	Player      *external.NBAPlayer `json:"player"`
+	Stats       *SeasonStatLine     `json:"stats"`       // 所选赛季无数据时为 null
+	Percentiles map[string]float64  `json:"percentiles"` // 各类别在联盟中的百分位，联盟数据不可用时为空
+}
+
+// PlayerComparisonResponse 球员对比响应
+type PlayerComparisonResponse struct {
+	SeasonFrom int              `json:"season_from"`
+	SeasonTo   int              `json:"season_to"`
+	Categories []stats.Category `json:"categories"`
+	Players    []ComparedPlayer `json:"players"`
+	Leaders    map[string][]int `json:"leaders"` // 各类别领先的球员ID（并列时有多个）
+}
+
+// CompareNBAPlayers 球员对比
+// 参数：ids=1,2,3（2-5 名球员）；season=2024 或 season_from=2020&season_to=2024（最多 5 个赛季，按出场数加权合并）
+func CompareNBAPlayers(c *gin.Context) {
+	var playerIDs []int
+	seen := map[int]bool{}
+	for _, part := range strings.Split(c.Query("ids"), ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || id <= 0 {
+			util.ErrorResponse(c, http.StatusBadRequest, "无效的球员ID")
+			return
+		}
+		if !seen[id] {
+			seen[id] = true
+			playerIDs = append(playerIDs, id)
+		}
+	}
+	if len(playerIDs) < compareMinPlayers || len(playerIDs) > compareMaxPlayers {
+		util.ErrorResponse(c, http.StatusBadRequest, "请选择2到5名球员进行对比")
+		return
+	}
+
+	seasonFrom, seasonTo, ok := parseSeasonRange(c)
+	if !ok {
+		util.ErrorResponse(c, http.StatusBadRequest, "无效的赛季范围")
+		return
+	}
+	if seasonTo-seasonFrom+1 > compareMaxSeasons {
+		util.ErrorResponse(c, http.StatusBadRequest, "赛季范围最多5个赛季")
+		return
+	}
+
+	ctx := c.Request.Context()
+	anyStale := false
+
+	// 每个赛季一次批量请求，再按出场数合并
+	perSeason := map[int][]*external.NBASeasonAverage{}
+	var leaguePool []*external.NBASeasonAverage
+	leagueComplete := true
+	for season := seasonFrom; season <= seasonTo; season++ {
+		averages, stale, err := loadSeasonAverages(ctx, season, playerIDs)
+		if err != nil {
+			nbaErrorResponse(c, "获取球员数据失败", err)
+			return
+		}
+		anyStale = anyStale || stale
+		for _, id := range playerIDs {
+			if avg, ok := averages[id]; ok {
+				perSeason[id] = append(perSeason[id], avg)
+			}
+		}
+
+		// 联盟数据只用于百分位，获取失败（含历史赛季未同步）时不影响对比结果；
+		// 任一赛季缺失时不计算百分位，避免与所选范围不一致的样本
+		if !leagueComplete {
+			continue
+		}
+		league, err := loadLeagueAverages(ctx, season)
+		if err != nil {
+			leagueComplete, leaguePool = false, nil
+			continue
+		}
+		for i := range league {
+			if league[i].GamesPlayed >= percentileMinGames {
+				leaguePool = append(leaguePool, &league[i])
+			}
+		}
+	}
+
+	response := PlayerComparisonResponse{
+		SeasonFrom: seasonFrom,
+		SeasonTo:   seasonTo,
+		Categories: stats.Categories,
+		Players:    make([]ComparedPlayer, 0, len(playerIDs)),
+		Leaders:    map[string][]int{},
+	}
+
+	for _, id := range playerIDs {
+		player, stale, err := loadPlayer(ctx, id)
+		if err != nil {
+			nbaErrorResponse(c, "获取球员信息失败", err)
+			return
+		}
+		anyStale = anyStale || stale
+
+		compared := ComparedPlayer{Player: player, Percentiles: map[string]float64{}}
+		if combined := stats.Combine(perSeason[id]); combined != nil {
+			if seasonFrom == seasonTo {
+				combined.Season = seasonFrom
+			}
+			compared.Stats = withAdvanced(combined)
+			if len(leaguePool) > 0 {
+				for _, category := range stats.Categories {
+					pool := make([]float64, len(leaguePool))
+					for i, avg := range leaguePool {
+						pool[i] = category.Value(avg)
+					}
+					compared.Percentiles[category.Key] = stats.Percentile(pool, category.Value(combined), category.LowerIsBetter)
+				}
+			}
+		}
+		response.Players = append(response.Players, compared)
+	}
+
+	for _, category := range stats.Categories {
+		var leaders []int
+		var best float64
+		for _, p := range response.Players {
+			if p.Stats == nil {
+				continue
+			}
+			v := category.Value(p.Stats.NBASeasonAverage)
+			switch {
+			case leaders == nil || category.Better(v, best):
+				leaders, best = []int{p.Player.ID}, v
+			case v == best:
+				leaders = append(leaders, p.Player.ID)
+			}
+		}
+		if leaders != nil {
+			response.Leaders[category.Key] = leaders
+		}
+	}
+
+	markStale(c, anyStale)
+	util.SuccessResponse(c, http.StatusOK, response)
+}
+
+// parseSeasonRange 解析 season 或 season_from/season_to 参数
+func parseSeasonRange(c *gin.Context) (int, int, bool) {
+	if seasonStr := c.Query("season"); seasonStr != "" {
+		season, err := strconv.Atoi(seasonStr)
+		return season, season, err == nil
+	}
+
+	fromStr, toStr := c.Query("season_from"), c.Query("season_to")
+	if fromStr == "" && toStr == "" {
+		return currentSeason(), currentSeason(), true
+	}
+
+	from, to := currentSeason(), currentSeason()
+	var err error
+	if fromStr != "" {
+		if from, err = strconv.Atoi(fromStr); err != nil {
+			return 0, 0, false
+		}
+	}
+	if toStr != "" {
+		if to, err = strconv.Atoi(toStr); err != nil {
+			return 0, 0, false
+		}
+	}
+	return from, to, from <= to
+}
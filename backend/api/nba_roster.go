@@ -0,0 +1,176 @@
+package api
+
+import (
+	"buzzerbeater/external"
+	"buzzerbeater/stats"
+	"buzzerbeater/util"
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// statusActive 没有伤病记录的球员状态
+const statusActive = "Active"
+
+var (
+	injuryProvider     external.InjuryProvider
+	injuryProviderOnce sync.Once
+)
+
+// getInjuryProvider 获取伤病数据来源（延迟初始化）
+func getInjuryProvider() external.InjuryProvider {
+	injuryProviderOnce.Do(func() {
+		injuryProvider = external.NewInjuryProvider(getNBAClient())
+	})
+	return injuryProvider
+}
+
+// RosterPlayer 阵容中的球员
+type RosterPlayer struct {
+	Player    external.NBAPlayer     `json:"player"`
+	Stats     *SeasonStatLine        `json:"stats"`
+	Status    string                 `json:"status"` // Active 或伤病状态
+	Injury    *external.InjuryReport `json:"injury"`
+	DepthRank int                    `json:"depth_rank"` // 同位置内的轮换顺位（1 为首发）
+}
+
+// RosterResponse 阵容响应
+type RosterResponse struct {
+	Season  int              `json:"season"`
+	Team    external.NBATeam `json:"team"`
+	Players []RosterPlayer   `json:"players"`
+	Depth   map[string][]int `json:"depth"` // 位置（G/F/C） -> 按顺位排列的球员ID
+}
+
+// loadRoster 获取球队现役阵容，合并赛季场均和伤病状态，并按位置排出轮换顺位
+// 伤病数据获取失败不影响阵容返回
+func loadRoster(ctx context.Context, season int, teamID int) ([]RosterPlayer, map[string][]int, bool, error) {
+	players, stale, err := loadActivePlayers(ctx, teamID)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	ids := make([]int, len(players))
+	for i, p := range players {
+		ids[i] = p.ID
+	}
+	averages, averagesStale, err := loadSeasonAverages(ctx, season, ids)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	reports, err := getInjuryProvider().Injuries(ctx, teamID)
+	if err != nil {
+		reports = nil
+	}
+
+	roster, depth := buildRoster(players, averages, reports)
+	return roster, depth, stale || averagesStale, nil
+}
+
+// buildRoster 合并场均数据和伤病状态（没有伤病记录的为 Active），并排出轮换顺位
+func buildRoster(players []external.NBAPlayer, averages map[int]*external.NBASeasonAverage,
+	reports []external.InjuryReport) ([]RosterPlayer, map[string][]int) {
+	injuries := make(map[int]external.InjuryReport, len(reports))
+	for _, r := range reports {
+		injuries[r.PlayerID] = r
+	}
+
+	roster := make([]RosterPlayer, 0, len(players))
+	for _, p := range players {
+		rp := RosterPlayer{Player: p, Stats: withAdvanced(averages[p.ID]), Status: statusActive}
+		if injury, ok := injuries[p.ID]; ok {
+			rp.Status = injury.Status
+			rp.Injury = &injury
+		}
+		roster = append(roster, rp)
+	}
+	return roster, assignDepth(roster)
+}
+
+// assignDepth 按主要位置分组，组内按场均上场时间排序（确定缺阵的排到最后）
+func assignDepth(roster []RosterPlayer) map[string][]int {
+	groups := map[string][]int{}
+	for i, p := range roster {
+		pos := primaryPosition(p.Player.Position)
+		groups[pos] = append(groups[pos], i)
+	}
+
+	depth := map[string][]int{}
+	for pos, indexes := range groups {
+		sort.SliceStable(indexes, func(a, b int) bool {
+			pa, pb := roster[indexes[a]], roster[indexes[b]]
+			if oa, ob := pa.Status == "Out", pb.Status == "Out"; oa != ob {
+				return !oa
+			}
+			return rosterMinutes(pa) > rosterMinutes(pb)
+		})
+		for rank, i := range indexes {
+			roster[i].DepthRank = rank + 1
+			depth[pos] = append(depth[pos], roster[i].Player.ID)
+		}
+	}
+	return depth
+}
+
+// primaryPosition 主要位置（"G-F" 取 G），未知位置归为 "N/A"
+func primaryPosition(position string) string {
+	if p := strings.SplitN(position, "-", 2)[0]; p != "" {
+		return p
+	}
+	return "N/A"
+}
+
+func rosterMinutes(p RosterPlayer) float64 {
+	if p.Stats == nil {
+		return 0
+	}
+	return stats.ParseMinutes(p.Stats.Min).Minutes()
+}
+
+// GetNBATeamRoster 获取球队现役阵容（含场均数据、伤病状态和轮换顺位）
+func GetNBATeamRoster(c *gin.Context) {
+	teamID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "无效的球队ID")
+		return
+	}
+
+	season := currentSeason()
+	if seasonStr := c.Query("season"); seasonStr != "" {
+		if s, err := strconv.Atoi(seasonStr); err == nil {
+			season = s
+		}
+	}
+
+	ctx := c.Request.Context()
+	teams, teamsStale, err := loadTeams(ctx)
+	if err != nil {
+		nbaErrorResponse(c, "获取球队列表失败", err)
+		return
+	}
+	team, ok := findTeam(teams, teamID)
+	if !ok {
+		util.ErrorResponse(c, http.StatusNotFound, "球队不存在")
+		return
+	}
+
+	roster, depth, rosterStale, err := loadRoster(ctx, season, teamID)
+	if err != nil {
+		nbaErrorResponse(c, "获取阵容失败", err)
+		return
+	}
+	markStale(c, teamsStale || rosterStale)
+
+	util.SuccessResponse(c, http.StatusOK, RosterResponse{
+		Season:  season,
+		Team:    *team,
+		Players: roster,
+		Depth:   depth,
+	})
+}
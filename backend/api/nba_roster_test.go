@@ -0,0 +1,87 @@
+package api
+
+import (
+	"buzzerbeater/external"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const injuryFixture = `{
+  "10": [
+    {"player_id": 2, "status": "Out", "return_date": "Dec 1", "description": "Right knee sprain"},
+    {"player_id": 3, "status": "Day-To-Day", "return_date": "Nov 20", "description": "Left ankle soreness"}
+  ],
+  "14": [
+    {"player_id": 9, "status": "Out", "return_date": "", "description": "Illness"}
+  ]
+}`
+
+func TestBuildRosterMergesFixtureInjuries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "injuries.json")
+	if err := os.WriteFile(path, []byte(injuryFixture), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	reports, err := (&external.FixtureInjuryProvider{Path: path}).Injuries(context.Background(), 10)
+	if err != nil {
+		t.Fatalf("Injuries: %v", err)
+	}
+
+	players := []external.NBAPlayer{
+		{ID: 1, Position: "G"},
+		{ID: 2, Position: "G"},
+		{ID: 3, Position: "F"},
+	}
+	averages := map[int]*external.NBASeasonAverage{
+		1: {PlayerID: 1, Min: "20:00"},
+		2: {PlayerID: 2, Min: "34:00"},
+		3: {PlayerID: 3, Min: "30:00"},
+	}
+	roster, depth := buildRoster(players, averages, reports)
+
+	tests := []struct {
+		id          int
+		status      string
+		description string
+		depthRank   int
+	}{
+		{1, statusActive, "", 1},
+		{2, "Out", "Right knee sprain", 2}, // 确定缺阵，排在上场时间更少的球员之后
+		{3, "Day-To-Day", "Left ankle soreness", 1},
+	}
+	for i, tt := range tests {
+		rp := roster[i]
+		if rp.Player.ID != tt.id {
+			t.Fatalf("roster[%d] = player %d, want %d", i, rp.Player.ID, tt.id)
+		}
+		if rp.Status != tt.status {
+			t.Errorf("player %d status = %q, want %q", tt.id, rp.Status, tt.status)
+		}
+		if tt.description == "" && rp.Injury != nil {
+			t.Errorf("player %d injury = %+v, want nil", tt.id, rp.Injury)
+		}
+		if tt.description != "" && (rp.Injury == nil || rp.Injury.Description != tt.description) {
+			t.Errorf("player %d injury = %+v, want %q", tt.id, rp.Injury, tt.description)
+		}
+		if rp.DepthRank != tt.depthRank {
+			t.Errorf("player %d depth rank = %d, want %d", tt.id, rp.DepthRank, tt.depthRank)
+		}
+	}
+	if got := depth["G"]; len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Errorf("guard depth = %v, want [1 2]", got)
+	}
+}
+
+func TestFixtureInjuryProviderMissingFile(t *testing.T) {
+	p := &external.FixtureInjuryProvider{Path: filepath.Join(t.TempDir(), "missing.json")}
+	reports, err := p.Injuries(context.Background(), 10)
+	if err != nil || len(reports) != 0 {
+		t.Fatalf("Injuries = %v, %v; want no injuries", reports, err)
+	}
+
+	roster, _ := buildRoster([]external.NBAPlayer{{ID: 1, Position: "C"}}, nil, reports)
+	if roster[0].Status != statusActive || roster[0].Injury != nil {
+		t.Errorf("player 1 = %q %+v, want Active without injury", roster[0].Status, roster[0].Injury)
+	}
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"buzzerbeater/external"
+	"buzzerbeater/util"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ScheduleGame 赛程中的一场比赛（从指定球队视角）
+type ScheduleGame struct {
+	GameID        int              `json:"game_id"`
+	Date          string           `json:"date"`
+	Datetime      string           `json:"datetime"` // 开赛时间（UTC），未定时为空
+	Home          bool             `json:"home"`
+	Opponent      external.NBATeam `json:"opponent"`
+	Venue         string           `json:"venue"`
+	Status        string           `json:"status"`
+	Postseason    bool             `json:"postseason"`
+	TeamScore     int              `json:"team_score"`
+	OpponentScore int              `json:"opponent_score"`
+	Result        string           `json:"result"` // W / L，未结束为空
+}
+
+// ScheduleResponse 赛程响应
+type ScheduleResponse struct {
+	Season int              `json:"season"`
+	Team   external.NBATeam `json:"team"`
+	Games  []ScheduleGame   `json:"games"`
+}
+
+// findTeam 在球队列表中按 ID 查找
+func findTeam(teams []external.NBATeam, id int) (*external.NBATeam, bool) {
+	for i := range teams {
+		if teams[i].ID == id {
+			return &teams[i], true
+		}
+	}
+	return nil, false
+}
+
+// buildSchedule 把比赛转换为指定球队视角的赛程（games 按日期升序）
+func buildSchedule(team external.NBATeam, games []external.NBAGame) []ScheduleGame {
+	schedule := make([]ScheduleGame, 0, len(games))
+	for _, g := range games {
+		home := g.HomeTeam.ID == team.ID
+		if !home && g.VisitorTeam.ID != team.ID {
+			continue
+		}
+
+		item := ScheduleGame{
+			GameID:     g.ID,
+			Date:       g.Date,
+			Datetime:   g.Datetime,
+			Home:       home,
+			Venue:      external.Arena(g.HomeTeam.Abbreviation),
+			Status:     g.Status,
+			Postseason: g.Postseason,
+		}
+		if home {
+			item.Opponent, item.TeamScore, item.OpponentScore = g.VisitorTeam, g.HomeTeamScore, g.VisitorTeamScore
+		} else {
+			item.Opponent, item.TeamScore, item.OpponentScore = g.HomeTeam, g.VisitorTeamScore, g.HomeTeamScore
+		}
+		if g.IsFinal() {
+			item.Result = "L"
+			if item.TeamScore > item.OpponentScore {
+				item.Result = "W"
+			}
+		}
+		schedule = append(schedule, item)
+	}
+	return schedule
+}
+
+// GetNBATeamSchedule 获取球队赛季赛程
+func GetNBATeamSchedule(c *gin.Context) {
+	teamID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "无效的球队ID")
+		return
+	}
+
+	season := currentSeason()
+	if seasonStr := c.Query("season"); seasonStr != "" {
+		if s, err := strconv.Atoi(seasonStr); err == nil {
+			season = s
+		}
+	}
+
+	ctx := c.Request.Context()
+	teams, teamsStale, err := loadTeams(ctx)
+	if err != nil {
+		nbaErrorResponse(c, "获取球队列表失败", err)
+		return
+	}
+	team, ok := findTeam(teams, teamID)
+	if !ok {
+		util.ErrorResponse(c, http.StatusNotFound, "球队不存在")
+		return
+	}
+
+	games, gamesStale, err := loadGames(ctx, season, teamID)
+	if err != nil {
+		nbaErrorResponse(c, "获取赛程失败", err)
+		return
+	}
+	markStale(c, teamsStale || gamesStale)
+
+	util.SuccessResponse(c, http.StatusOK, ScheduleResponse{
+		Season: season,
+		Team:   *team,
+		Games:  buildSchedule(*team, games),
+	})
+}
+
+// scheduleSummary 日历事件标题，如 "湖人 vs 勇士" / "湖人 @ 勇士"
+func scheduleSummary(team external.NBATeam, g ScheduleGame) string {
+	name := func(t external.NBATeam) string {
+		if t.FullNameZh != "" {
+			return t.FullNameZh
+		}
+		return t.FullName
+	}
+	sep := "@"
+	if g.Home {
+		sep = "vs"
+	}
+	summary := fmt.Sprintf("%s %s %s", name(team), sep, name(g.Opponent))
+	if g.Result != "" {
+		summary += fmt.Sprintf("（%s %d-%d）", g.Result, g.TeamScore, g.OpponentScore)
+	}
+	return summary
+}
@@ -0,0 +1,405 @@
+package api
+
+import (
+	"buzzerbeater/db"
+	"buzzerbeater/external"
+	"buzzerbeater/util"
+	"buzzerbeater/warehouse"
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 以下函数优先读取本地数据仓库，未同步完成时退回到在线接口
+
+// upstreamConcurrency 单个请求内同时访问上游的最大数量，避免一次请求占满全局限流
+const upstreamConcurrency = 3
+
+// forEachBounded 并发执行 fn(0) 到 fn(n-1)，同时最多 limit 个，全部完成后返回
+func forEachBounded(n, limit int, fn func(i int)) {
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// loadTeams 获取现役球队
+func loadTeams(ctx context.Context) ([]external.NBATeam, bool, error) {
+	if warehouse.Ready("teams") {
+		if teams, err := warehouse.Teams(); err == nil && len(teams) > 0 {
+			return teams, false, nil
+		}
+	}
+	return getNBAClient().GetTeams(ctx)
+}
+
+// loadAllTeams 获取包括已解散历史球队在内的所有球队
+// 本地仓库只存现役球队；历史球队不会变化，直接走客户端的缓存
+func loadAllTeams(ctx context.Context) ([]external.NBATeam, bool, error) {
+	return getNBAClient().GetAllTeams(ctx)
+}
+
+// loadPlayers 获取球员列表（可按球队筛选）
+func loadPlayers(ctx context.Context, teamID int) ([]external.NBAPlayer, bool, error) {
+	if warehouse.Ready("players") {
+		if players, err := warehouse.Players(teamID, 25); err == nil {
+			return players, false, nil
+		}
+	}
+	return getNBAClient().GetPlayers(ctx, teamID)
+}
+
+// loadActivePlayers 获取现役球员（teamID 为 0 时获取全联盟）
+// 本地仓库按本赛季的出场记录判定现役，赛季还没有比赛数据时退回到在线接口
+func loadActivePlayers(ctx context.Context, teamID int) ([]external.NBAPlayer, bool, error) {
+	season := currentSeason()
+	if warehouse.Ready("players") && warehouse.Ready(warehouse.StatsResource(season)) {
+		if players, err := warehouse.ActivePlayers(teamID, season); err == nil && len(players) > 0 {
+			return players, false, nil
+		}
+	}
+	return getNBAClient().GetActivePlayers(ctx, teamID)
+}
+
+// loadSeasonAverage 获取球员赛季平均数据
+func loadSeasonAverage(ctx context.Context, playerID int, season int) (*external.NBASeasonAverage, bool, error) {
+	if warehouse.Ready(warehouse.StatsResource(season)) {
+		if avg, err := warehouse.SeasonAverage(playerID, season); err == nil {
+			return avg, false, nil
+		}
+	}
+	return getNBAClient().GetPlayerSeasonAverages(ctx, playerID, season)
+}
+
+// loadPlayer 获取单个球员
+func loadPlayer(ctx context.Context, playerID int) (*external.NBAPlayer, bool, error) {
+	if warehouse.Ready("players") {
+		if player, err := warehouse.Player(playerID); err == nil {
+			return player, false, nil
+		}
+	}
+	return getNBAClient().GetPlayer(ctx, playerID)
+}
+
+// loadPlayersByID 批量获取球员，返回 球员ID -> 球员，获取失败的球员跳过
+// 本地仓库没有的球员请求上游，同时最多 upstreamConcurrency 个
+func loadPlayersByID(ctx context.Context, ids []int) map[int]*external.NBAPlayer {
+	result := make(map[int]*external.NBAPlayer, len(ids))
+	missing := ids
+	if warehouse.Ready("players") {
+		if players, err := warehouse.PlayersByID(ids); err == nil {
+			for i := range players {
+				result[players[i].ID] = &players[i]
+			}
+			missing = nil
+			for _, id := range ids {
+				if result[id] == nil {
+					missing = append(missing, id)
+				}
+			}
+		}
+	}
+
+	var mu sync.Mutex
+	forEachBounded(len(missing), upstreamConcurrency, func(i int) {
+		player, _, err := getNBAClient().GetPlayer(ctx, missing[i])
+		if err != nil {
+			return
+		}
+		mu.Lock()
+		result[player.ID] = player
+		mu.Unlock()
+	})
+	return result
+}
+
+// loadRecentStats 获取一批球员本赛季 startDate（含）之后的单场数据（按日期升序），在线模式下为一次批量查询
+func loadRecentStats(ctx context.Context, playerIDs []int, startDate string) ([]external.NBAStat, bool, error) {
+	if len(playerIDs) == 0 {
+		return []external.NBAStat{}, false, nil
+	}
+	season := currentSeason()
+	if warehouse.Ready(warehouse.StatsResource(season)) {
+		if stats, err := warehouse.StatsBetween(playerIDs, startDate, time.Now().AddDate(0, 0, 1).Format("2006-01-02")); err == nil {
+			return stats, false, nil
+		}
+	}
+
+	stats, stale, err := getNBAClient().GetStats(ctx, external.GameQuery{
+		Seasons:   []int{season},
+		PlayerIDs: playerIDs,
+		StartDate: startDate,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Game.Date != stats[j].Game.Date {
+			return stats[i].Game.Date < stats[j].Game.Date
+		}
+		return stats[i].Game.ID < stats[j].Game.ID
+	})
+	return stats, stale, nil
+}
+
+// loadPlayerGameStats 获取球员某赛季的全部单场数据（按日期升序）
+func loadPlayerGameStats(ctx context.Context, playerID int, season int) ([]external.NBAStat, bool, error) {
+	if warehouse.Ready(warehouse.StatsResource(season)) {
+		if stats, err := warehouse.PlayerStats(playerID, season); err == nil {
+			return stats, false, nil
+		}
+	}
+
+	stats, stale, err := getNBAClient().GetStats(ctx, external.GameQuery{
+		Seasons:   []int{season},
+		PlayerIDs: []int{playerID},
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Game.Date != stats[j].Game.Date {
+			return stats[i].Game.Date < stats[j].Game.Date
+		}
+		return stats[i].Game.ID < stats[j].Game.ID
+	})
+	return stats, stale, nil
+}
+
+// loadGames 获取某赛季的比赛（teamID 为 0 时获取全联盟），按日期升序
+func loadGames(ctx context.Context, season int, teamID int) ([]external.NBAGame, bool, error) {
+	if warehouse.Ready(warehouse.GamesResource(season)) {
+		if games, err := warehouse.Games(warehouse.GameFilter{Season: season, TeamID: teamID}); err == nil {
+			return games, false, nil
+		}
+	}
+
+	query := external.GameQuery{Seasons: []int{season}}
+	if teamID > 0 {
+		query.TeamIDs = []int{teamID}
+	}
+	games, stale, err := getNBAClient().GetGames(ctx, query)
+	if err != nil {
+		return nil, false, err
+	}
+	sort.SliceStable(games, func(i, j int) bool {
+		if games[i].Date != games[j].Date {
+			return games[i].Date < games[j].Date
+		}
+		return games[i].Datetime < games[j].Datetime
+	})
+	return games, stale, nil
+}
+
+// statsGameBatchSize 按比赛批量请求单场数据时每批的比赛数
+const statsGameBatchSize = 25
+
+// loadTeamGameStats 获取某队球员某赛季已结束比赛的单场数据
+// 上游 stats 接口不支持按球队过滤，在线模式下按比赛 ID 分批请求后再过滤
+func loadTeamGameStats(ctx context.Context, season int, teamID int, games []external.NBAGame) ([]external.NBAStat, bool, error) {
+	if warehouse.Ready(warehouse.StatsResource(season)) {
+		if lines, err := warehouse.TeamStats(teamID, season); err == nil {
+			return lines, false, nil
+		}
+	}
+
+	var gameIDs []int
+	for _, g := range games {
+		if g.IsFinal() && (g.HomeTeam.ID == teamID || g.VisitorTeam.ID == teamID) {
+			gameIDs = append(gameIDs, g.ID)
+		}
+	}
+
+	var lines []external.NBAStat
+	anyStale := false
+	for start := 0; start < len(gameIDs); start += statsGameBatchSize {
+		end := min(start+statsGameBatchSize, len(gameIDs))
+		batch, stale, err := getNBAClient().GetStats(ctx, external.GameQuery{GameIDs: gameIDs[start:end]})
+		if err != nil {
+			return nil, false, err
+		}
+		anyStale = anyStale || stale
+		for _, s := range batch {
+			if s.Team.ID == teamID {
+				lines = append(lines, s)
+			}
+		}
+	}
+	return lines, anyStale, nil
+}
+
+// seasonAveragesBatchSize 单次批量请求的球员数（控制 URL 长度）
+const seasonAveragesBatchSize = 100
+
+// loadSeasonAverages 批量获取多名球员的赛季平均数据，返回 球员ID -> 数据
+func loadSeasonAverages(ctx context.Context, season int, playerIDs []int) (map[int]*external.NBASeasonAverage, bool, error) {
+	result := make(map[int]*external.NBASeasonAverage, len(playerIDs))
+	if warehouse.Ready(warehouse.StatsResource(season)) {
+		for _, id := range playerIDs {
+			if avg, err := warehouse.SeasonAverage(id, season); err == nil {
+				result[id] = avg
+			}
+		}
+		return result, false, nil
+	}
+
+	anyStale := false
+	for start := 0; start < len(playerIDs); start += seasonAveragesBatchSize {
+		end := min(start+seasonAveragesBatchSize, len(playerIDs))
+		averages, stale, err := getNBAClient().GetSeasonAveragesBatch(ctx, season, playerIDs[start:end])
+		if err != nil {
+			return nil, false, err
+		}
+		anyStale = anyStale || stale
+		for i := range averages {
+			result[averages[i].PlayerID] = &averages[i]
+		}
+	}
+	return result, anyStale, nil
+}
+
+// leagueAveragesTTL 全联盟赛季平均数据的内存缓存时长
+const leagueAveragesTTL = time.Hour
+
+var (
+	leagueAveragesCache   = map[int]leagueAveragesEntry{}
+	leagueAveragesCacheMu sync.Mutex
+)
+
+// leagueAveragesEntry 全联盟赛季平均数据缓存条目
+type leagueAveragesEntry struct {
+	averages  []external.NBASeasonAverage
+	expiresAt time.Time
+}
+
+// errLeagueSeasonNotSynced 历史赛季的全联盟数据只能从本地仓库获取，该赛季尚未同步
+var errLeagueSeasonNotSynced = errors.New("league averages for this season are not synced")
+
+// loadLeagueAverages 获取全联盟球员的赛季平均数据（用于百分位、排行榜）
+// 本地仓库有该赛季数据时直接聚合；当前赛季未同步时按现役球员名单批量请求 season_averages；
+// 历史赛季的球员群体与现役名单不同，未同步时返回 errLeagueSeasonNotSynced
+func loadLeagueAverages(ctx context.Context, season int) ([]external.NBASeasonAverage, error) {
+	leagueAveragesCacheMu.Lock()
+	entry, ok := leagueAveragesCache[season]
+	leagueAveragesCacheMu.Unlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.averages, nil
+	}
+
+	var averages []external.NBASeasonAverage
+	if warehouse.Ready(warehouse.StatsResource(season)) {
+		var err error
+		if averages, err = warehouse.LeagueAverages(season); err != nil {
+			return nil, err
+		}
+	} else if season != currentSeason() {
+		return nil, errLeagueSeasonNotSynced
+	} else {
+		players, _, err := loadActivePlayers(ctx, 0)
+		if err != nil {
+			return nil, err
+		}
+		ids := make([]int, len(players))
+		for i, p := range players {
+			ids[i] = p.ID
+		}
+		byPlayer, _, err := loadSeasonAverages(ctx, season, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			if avg, ok := byPlayer[id]; ok {
+				averages = append(averages, *avg)
+			}
+		}
+	}
+
+	leagueAveragesCacheMu.Lock()
+	leagueAveragesCache[season] = leagueAveragesEntry{averages: averages, expiresAt: time.Now().Add(leagueAveragesTTL)}
+	leagueAveragesCacheMu.Unlock()
+	return averages, nil
+}
+
+// loadPlayerIndex 球员ID -> 球员（用于补全排行榜等场景的球员信息）
+func loadPlayerIndex(ctx context.Context) (map[int]external.NBAPlayer, error) {
+	var players []external.NBAPlayer
+	if warehouse.Ready("players") {
+		var err error
+		if players, err = warehouse.Players(0, -1); err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		if players, _, err = getNBAClient().GetActivePlayers(ctx, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	index := make(map[int]external.NBAPlayer, len(players))
+	for _, p := range players {
+		index[p.ID] = p
+	}
+	return index, nil
+}
+
+// errHomeTeamNotFound 用户主队在 NBA 球队中找不到
+var errHomeTeamNotFound = errors.New("home team not found")
+
+// loadHomeTeam 获取用户主队对应的 NBA 球队（本地 teams.code 与 NBA 球队缩写一致）
+func loadHomeTeam(ctx context.Context, userID interface{}) (*external.NBATeam, error) {
+	var code string
+	err := db.GetDB().QueryRow(
+		"SELECT t.code FROM users u JOIN teams t ON u.team_id = t.id WHERE u.id = ?",
+		userID,
+	).Scan(&code)
+	if err != nil {
+		return nil, err
+	}
+
+	teams, _, err := loadTeams(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range teams {
+		if teams[i].Abbreviation == code {
+			return &teams[i], nil
+		}
+	}
+	return nil, errHomeTeamNotFound
+}
+
+// homeTeamErrorResponse loadHomeTeam 失败时的响应：未设置主队或主队不是 NBA 球队时返回 404
+func homeTeamErrorResponse(c *gin.Context, err error) {
+	if errors.Is(err, sql.ErrNoRows) || errors.Is(err, errHomeTeamNotFound) {
+		util.ErrorResponse(c, http.StatusNotFound, "未设置主队")
+		return
+	}
+	nbaErrorResponse(c, "获取主队失败", err)
+}
+
+// GetNBASyncStatus 查看本地数据仓库同步状态
+func GetNBASyncStatus(c *gin.Context) {
+	checkpoints, err := warehouse.ListCheckpoints()
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "获取同步状态失败")
+		return
+	}
+
+	util.SuccessResponse(c, http.StatusOK, checkpoints)
+}
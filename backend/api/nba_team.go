@@ -2,33 +2,152 @@ package api
 
 import (
 	"buzzerbeater/external"
+	"buzzerbeater/stats"
 	"buzzerbeater/util"
+	"context"
+	"errors"
 	"net/http"
-	"sync"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
 
-var (
-	nbaClient     *external.NBAClient
-	nbaClientOnce sync.Once
-)
-
-// getNBAClient 获取 NBA 客户端实例（延迟初始化）
+// getNBAClient 获取 NBA 客户端实例（与数据同步共享）
 func getNBAClient() *external.NBAClient {
-	nbaClientOnce.Do(func() {
-		nbaClient = external.NewNBAClient()
-	})
-	return nbaClient
+	return external.Default()
+}
+
+// markStale 上游不可用时返回的是缓存旧数据，通过响应头告知客户端
+func markStale(c *gin.Context, stale bool) {
+	if stale {
+		c.Header("Warning", `110 - "Response is Stale"`)
+		c.Header("X-Data-Stale", "true")
+	}
+}
+
+// nbaErrorResponse 根据上游错误类型返回合适的状态码
+func nbaErrorResponse(c *gin.Context, message string, err error) {
+	var apiErr *external.APIError
+	switch {
+	case errors.Is(err, context.Canceled):
+		// 客户端已断开，无需再写响应体
+		c.AbortWithStatus(499)
+	case errors.Is(err, external.ErrFixtureNotFound):
+		util.ErrorResponse(c, http.StatusServiceUnavailable, message+": 离线模式下没有该请求的录制数据")
+	case errors.Is(err, external.ErrCircuitOpen):
+		util.ErrorResponse(c, http.StatusServiceUnavailable, message+": NBA 数据服务暂时不可用")
+	case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusTooManyRequests:
+		if apiErr.RetryAfter > 0 {
+			c.Header("Retry-After", strconv.Itoa(int(apiErr.RetryAfter.Seconds())))
+		}
+		util.ErrorResponse(c, http.StatusTooManyRequests, message+": 请求过于频繁，请稍后再试")
+	case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound:
+		util.ErrorResponse(c, http.StatusNotFound, message+": "+err.Error())
+	case errors.As(err, &apiErr), errors.Is(err, context.DeadlineExceeded):
+		util.ErrorResponse(c, http.StatusBadGateway, message+": "+err.Error())
+	default:
+		util.ErrorResponse(c, http.StatusInternalServerError, message+": "+err.Error())
+	}
 }
 
 // GetNBATeams 获取 NBA 球队列表
+// 参数：season 返回该赛季存在的球队及当时的队名；include_historical=true 同时返回已解散的历史球队
 func GetNBATeams(c *gin.Context) {
-	teams, err := getNBAClient().GetTeams()
+	load := loadTeams
+	if c.Query("include_historical") == "true" {
+		load = loadAllTeams
+	}
+	teams, stale, err := load(c.Request.Context())
 	if err != nil {
-		util.ErrorResponse(c, http.StatusInternalServerError, "获取球队列表失败: "+err.Error())
+		nbaErrorResponse(c, "获取球队列表失败", err)
 		return
 	}
+	markStale(c, stale)
+
+	if seasonStr := c.Query("season"); seasonStr != "" {
+		season, err := strconv.Atoi(seasonStr)
+		if err != nil {
+			util.ErrorResponse(c, http.StatusBadRequest, "无效的赛季")
+			return
+		}
+		teams = teamsInSeason(teams, season)
+	}
 
 	util.SuccessResponse(c, http.StatusOK, teams)
 }
+
+// StandingsResponse 排名响应
+type StandingsResponse struct {
+	Season int                   `json:"season"`
+	Group  string                `json:"group"`
+	Groups []stats.StandingGroup `json:"groups"`
+}
+
+// GetNBAStandings 获取联盟排名（由比赛结果计算）
+// 历史赛季使用当时的队名，但东西部和赛区按现在的划分
+// 参数：season（默认当前赛季）、group=conference|division（默认 conference）
+func GetNBAStandings(c *gin.Context) {
+	season := currentSeason()
+	if seasonStr := c.Query("season"); seasonStr != "" {
+		if s, err := strconv.Atoi(seasonStr); err == nil {
+			season = s
+		}
+	}
+
+	group := c.DefaultQuery("group", "conference")
+	if group != "conference" && group != "division" {
+		util.ErrorResponse(c, http.StatusBadRequest, "group 只能是 conference 或 division")
+		return
+	}
+
+	ctx := c.Request.Context()
+	teams, teamsStale, err := loadTeams(ctx)
+	if err != nil {
+		nbaErrorResponse(c, "获取球队列表失败", err)
+		return
+	}
+
+	games, gamesStale, err := loadGames(ctx, season, 0)
+	if err != nil {
+		nbaErrorResponse(c, "获取比赛数据失败", err)
+		return
+	}
+	markStale(c, teamsStale || gamesStale)
+
+	util.SuccessResponse(c, http.StatusOK, StandingsResponse{
+		Season: season,
+		Group:  group,
+		Groups: stats.ComputeStandings(teamsInSeason(teams, season), games, group),
+	})
+}
+
+// GetNBATeamStats 获取球队赛季场均数据
+// 参数：season（默认当前赛季），或 season_from/season_to 返回多个赛季的列表（附当时的队名）
+func GetNBATeamStats(c *gin.Context) {
+	teamID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "无效的球队ID")
+		return
+	}
+
+	if seasonRangeRequested(c) {
+		getNBATeamStatsRange(c, teamID)
+		return
+	}
+
+	season := currentSeason()
+	if seasonStr := c.Query("season"); seasonStr != "" {
+		if s, err := strconv.Atoi(seasonStr); err == nil {
+			season = s
+		}
+	}
+
+	avg, stale, err := loadTeamSeasonAverages(c.Request.Context(), season, teamID)
+	if err != nil {
+		nbaErrorResponse(c, "获取球队数据失败", err)
+		return
+	}
+	markStale(c, stale)
+
+	util.SuccessResponse(c, http.StatusOK, avg)
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"buzzerbeater/notify"
+	"buzzerbeater/util"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 通知列表分页
+const (
+	notificationDefaultPage = 20
+	notificationMaxPage     = 100
+)
+
+// GetNotifications 我的通知（新的在前）和未读数，参数：unread=true 只看未读、limit、offset
+func GetNotifications(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	limit, offset, ok := parsePage(c, notificationDefaultPage, notificationMaxPage)
+	if !ok {
+		return
+	}
+
+	inbox, err := notify.Inbox(userID, c.Query("unread") == "true", limit, offset)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "查询通知失败")
+		return
+	}
+	util.SuccessResponse(c, http.StatusOK, inbox)
+}
+
+// MarkNotificationRead 标记通知为已读
+func MarkNotificationRead(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "无效的通知ID")
+		return
+	}
+
+	err = notify.MarkRead(userID, id, time.Now())
+	if errors.Is(err, notify.ErrNotFound) {
+		util.ErrorResponse(c, http.StatusNotFound, "通知不存在")
+		return
+	}
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "操作失败")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// MarkAllNotificationsRead 全部标记为已读
+func MarkAllNotificationsRead(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	count, err := notify.MarkAllRead(userID, time.Now())
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "操作失败")
+		return
+	}
+	util.SuccessResponse(c, http.StatusOK, gin.H{"marked": count})
+}
+
+// GetNotificationPreferences 各类型通知的开关
+func GetNotificationPreferences(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	prefs, err := notify.Preferences(userID)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "查询通知设置失败")
+		return
+	}
+	util.SuccessResponse(c, http.StatusOK, prefs)
+}
+
+// UpdateNotificationPreferences 更新通知开关，请求体为 {"类型": true/false}，不传的类型保持不变
+func UpdateNotificationPreferences(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req map[string]bool
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+	for t := range req {
+		if !notify.ValidType(t) {
+			util.ErrorResponse(c, http.StatusBadRequest, "未知的通知类型："+t)
+			return
+		}
+	}
+
+	if err := notify.SetPreferences(userID, req); err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "更新通知设置失败")
+		return
+	}
+	prefs, err := notify.Preferences(userID)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "查询通知设置失败")
+		return
+	}
+	util.SuccessResponse(c, http.StatusOK, prefs)
+}
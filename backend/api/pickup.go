@@ -0,0 +1,333 @@
+package api
+
+import (
+	"buzzerbeater/model"
+	"buzzerbeater/notify"
+	"buzzerbeater/pickup"
+	"buzzerbeater/util"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 约球参数限制
+const (
+	pickupDefaultDuration = 120     // 默认时长（分钟）
+	pickupMaxAdvance      = 60 * 24 // 最多提前 60 天发起（小时）
+	pickupMaxCapacity     = 30
+)
+
+// pickupMinCapacity 各赛制的最少人数
+var pickupMinCapacity = map[string]int{
+	model.PickupFormat3v3: 6,
+	model.PickupFormat5v5: 10,
+}
+
+// GetPickupEvents 约球列表（登录时不展示与当前用户存在拉黑关系的发起人的约球）
+// 参数：court_id、from/to（RFC3339，默认从现在起）、skill_level、format、status（默认 scheduled）
+func GetPickupEvents(c *gin.Context) {
+	filter := pickup.EventFilter{
+		ViewerID:   viewerID(c),
+		SkillLevel: c.Query("skill_level"),
+		Format:     c.Query("format"),
+		Status:     c.DefaultQuery("status", model.PickupScheduled),
+		From:       time.Now(),
+	}
+	if v := c.Query("court_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			util.ErrorResponse(c, http.StatusBadRequest, "无效的球场ID")
+			return
+		}
+		filter.CourtID = id
+	}
+	for name, dst := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		if v := c.Query(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				util.ErrorResponse(c, http.StatusBadRequest, "无效的时间，格式为 RFC3339")
+				return
+			}
+			*dst = t
+		}
+	}
+	if filter.Status != model.PickupScheduled && filter.Status != model.PickupCancelled {
+		util.ErrorResponse(c, http.StatusBadRequest, "无效的约球状态")
+		return
+	}
+
+	events, err := pickup.ListEvents(filter)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "查询约球失败")
+		return
+	}
+	util.SuccessResponse(c, http.StatusOK, events)
+}
+
+// GetPickupEvent 约球详情（含报名和候补名单）
+func GetPickupEvent(c *gin.Context) {
+	eventID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "无效的约球ID")
+		return
+	}
+	event, err := pickup.GetEvent(eventID, viewerID(c))
+	if errors.Is(err, pickup.ErrNotFound) {
+		util.ErrorResponse(c, http.StatusNotFound, "约球不存在")
+		return
+	}
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "查询约球失败")
+		return
+	}
+	util.SuccessResponse(c, http.StatusOK, event)
+}
+
+// CreatePickupEventRequest 发起约球请求
+type CreatePickupEventRequest struct {
+	CourtID    int       `json:"court_id" binding:"required"`
+	Title      string    `json:"title" binding:"required"`
+	StartsAt   time.Time `json:"starts_at" binding:"required"`
+	Duration   int       `json:"duration_minutes"` // 默认 120 分钟
+	SkillLevel string    `json:"skill_level" binding:"omitempty,oneof=any beginner intermediate advanced"`
+	Format     string    `json:"format" binding:"required,oneof=3v3 5v5"`
+	Capacity   int       `json:"capacity"` // 默认为赛制的最少人数
+	Note       string    `json:"note"`
+}
+
+// pickupConflictResponse 时间冲突时返回冲突的约球，便于客户端提示
+func pickupConflictResponse(c *gin.Context, conflict *pickup.ConflictError) {
+	c.JSON(http.StatusConflict, gin.H{
+		"error": "与已报名的约球时间冲突",
+		"conflict": gin.H{
+			"event_id":  conflict.EventID,
+			"title":     conflict.Title,
+			"starts_at": conflict.StartsAt,
+			"ends_at":   conflict.EndsAt,
+		},
+	})
+}
+
+// CreatePickupEvent 发起约球，发起人自动报名
+func CreatePickupEvent(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req CreatePickupEventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+	req.Title, req.Note = strings.TrimSpace(req.Title), strings.TrimSpace(req.Note)
+	if req.Title == "" {
+		util.ErrorResponse(c, http.StatusBadRequest, "标题不能为空")
+		return
+	}
+	if req.SkillLevel == "" {
+		req.SkillLevel = model.PickupSkillAny
+	}
+	if req.Duration == 0 {
+		req.Duration = pickupDefaultDuration
+	}
+	if req.Duration < 30 || req.Duration > 360 {
+		util.ErrorResponse(c, http.StatusBadRequest, "时长必须在 30-360 分钟之间")
+		return
+	}
+	minCapacity := pickupMinCapacity[req.Format]
+	if req.Capacity == 0 {
+		req.Capacity = minCapacity
+	}
+	if req.Capacity < minCapacity || req.Capacity > pickupMaxCapacity {
+		util.ErrorResponse(c, http.StatusBadRequest, "人数必须在 "+strconv.Itoa(minCapacity)+"-30 之间")
+		return
+	}
+	now := time.Now()
+	startsAt := req.StartsAt.UTC().Truncate(time.Minute)
+	if !startsAt.After(now) || startsAt.After(now.Add(pickupMaxAdvance*time.Hour)) {
+		util.ErrorResponse(c, http.StatusBadRequest, "开始时间必须在未来 60 天内")
+		return
+	}
+
+	eventID, err := pickup.CreateEvent(pickup.EventSpec{
+		CourtID:     req.CourtID,
+		OrganizerID: userID,
+		Title:       req.Title,
+		StartsAt:    startsAt,
+		EndsAt:      startsAt.Add(time.Duration(req.Duration) * time.Minute),
+		SkillLevel:  req.SkillLevel,
+		Format:      req.Format,
+		Capacity:    req.Capacity,
+		Note:        req.Note,
+	})
+	var conflict *pickup.ConflictError
+	switch {
+	case errors.As(err, &conflict):
+		pickupConflictResponse(c, conflict)
+		return
+	case errors.Is(err, pickup.ErrNotFound):
+		util.ErrorResponse(c, http.StatusNotFound, "球场不存在")
+		return
+	case err != nil:
+		util.ErrorResponse(c, http.StatusInternalServerError, "发起约球失败")
+		return
+	}
+
+	event, err := pickup.GetEvent(eventID, userID)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "查询约球失败")
+		return
+	}
+	util.SuccessResponse(c, http.StatusCreated, event)
+}
+
+// JoinPickupEvent 报名约球，满员时进入候补；与已报名的约球时间重叠时返回 409
+func JoinPickupEvent(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	eventID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "无效的约球ID")
+		return
+	}
+
+	status, err := pickup.Join(eventID, userID, time.Now())
+	var conflict *pickup.ConflictError
+	switch {
+	case errors.As(err, &conflict):
+		pickupConflictResponse(c, conflict)
+		return
+	case errors.Is(err, pickup.ErrNotFound):
+		util.ErrorResponse(c, http.StatusNotFound, "约球不存在")
+		return
+	case errors.Is(err, pickup.ErrClosed):
+		util.ErrorResponse(c, http.StatusConflict, "约球已取消或已开始")
+		return
+	case errors.Is(err, pickup.ErrBlocked):
+		util.ErrorResponse(c, http.StatusForbidden, "你不能报名该约球")
+		return
+	case err != nil:
+		util.ErrorResponse(c, http.StatusInternalServerError, "报名失败")
+		return
+	}
+	util.SuccessResponse(c, http.StatusOK, gin.H{"event_id": eventID, "status": status})
+}
+
+// LeavePickupEvent 退出报名，候补第一位自动递补
+func LeavePickupEvent(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	eventID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "无效的约球ID")
+		return
+	}
+
+	promoted, err := pickup.Leave(eventID, userID, time.Now())
+	switch {
+	case errors.Is(err, pickup.ErrNotFound):
+		util.ErrorResponse(c, http.StatusNotFound, "约球不存在")
+		return
+	case errors.Is(err, pickup.ErrNotJoined):
+		util.ErrorResponse(c, http.StatusNotFound, "你没有报名该约球")
+		return
+	case errors.Is(err, pickup.ErrClosed):
+		util.ErrorResponse(c, http.StatusConflict, "约球已取消或已开始")
+		return
+	case errors.Is(err, pickup.ErrOrganizerLeave):
+		util.ErrorResponse(c, http.StatusConflict, "发起人不能退出，请取消约球")
+		return
+	case err != nil:
+		util.ErrorResponse(c, http.StatusInternalServerError, "退出报名失败")
+		return
+	}
+	if promoted != 0 {
+		notifyPromoted(eventID, promoted)
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// notifyPromoted 通知候补转正的用户
+func notifyPromoted(eventID, userID int) {
+	event, err := pickup.GetEvent(eventID, userID)
+	if err != nil {
+		log.Printf("Notify: load pickup event %d failed: %v", eventID, err)
+		return
+	}
+	notify.Emit(notify.RSVPPromoted(userID, eventID, event.Title, event.StartsAt))
+}
+
+// CancelPickupEventRequest 取消约球请求
+type CancelPickupEventRequest struct {
+	Reason string `json:"reason"`
+}
+
+// CancelPickupEvent 发起人取消约球
+func CancelPickupEvent(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	eventID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "无效的约球ID")
+		return
+	}
+	var req CancelPickupEventRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			util.ErrorResponse(c, http.StatusBadRequest, "请求参数错误")
+			return
+		}
+	}
+
+	err = pickup.Cancel(eventID, userID, strings.TrimSpace(req.Reason), time.Now())
+	switch {
+	case errors.Is(err, pickup.ErrNotFound):
+		util.ErrorResponse(c, http.StatusNotFound, "约球不存在")
+		return
+	case errors.Is(err, pickup.ErrNotOrganizer):
+		util.ErrorResponse(c, http.StatusForbidden, "只有发起人可以取消约球")
+		return
+	case errors.Is(err, pickup.ErrClosed):
+		util.ErrorResponse(c, http.StatusConflict, "约球已取消或已开始")
+		return
+	case err != nil:
+		util.ErrorResponse(c, http.StatusInternalServerError, "取消约球失败")
+		return
+	}
+
+	event, err := pickup.GetEvent(eventID, userID)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "查询约球失败")
+		return
+	}
+	for _, p := range event.Participants {
+		notify.Emit(notify.PickupCancelled(p.UserID, userID, eventID, event.Title, event.CancelReason))
+	}
+	util.SuccessResponse(c, http.StatusOK, event)
+}
+
+// GetMyPickupEvents 我报名的、尚未结束的约球（含已取消的，便于查看取消原因）
+func GetMyPickupEvents(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	events, err := pickup.UserEvents(userID, time.Now())
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "查询约球失败")
+		return
+	}
+	util.SuccessResponse(c, http.StatusOK, events)
+}
@@ -0,0 +1,432 @@
+package api
+
+import (
+	"buzzerbeater/feed"
+	"buzzerbeater/model"
+	"buzzerbeater/notify"
+	"buzzerbeater/post"
+	"buzzerbeater/util"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 帖子参数限制
+const (
+	postMaxContent     = 2000 // 字数
+	postMaxImages      = 9
+	postMaxTags        = 5
+	postDefaultPage    = 20
+	postMaxPage        = 50
+	commentMaxContent  = 500
+	commentDefaultPage = 20
+	commentMaxPage     = 100
+)
+
+// parseCursor 解析游标分页参数 cursor（上一页的 next_cursor）和 limit，参数错误时写入 400 响应
+func parseCursor(c *gin.Context) (cursor, limit int, ok bool) {
+	cursor, err := strconv.Atoi(c.DefaultQuery("cursor", "0"))
+	if err != nil || cursor < 0 {
+		util.ErrorResponse(c, http.StatusBadRequest, "无效的 cursor")
+		return 0, 0, false
+	}
+	limit, ok = parseLimit(c)
+	return cursor, limit, ok
+}
+
+// parseLimit 解析每页条数，无效时写入 400 响应
+func parseLimit(c *gin.Context) (int, bool) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(postDefaultPage)))
+	if err != nil || limit < 1 || limit > postMaxPage {
+		util.ErrorResponse(c, http.StatusBadRequest, fmt.Sprintf("limit 必须在 1-%d 之间", postMaxPage))
+		return 0, false
+	}
+	return limit, true
+}
+
+// GetFeed 关注流：自己和关注的用户发的帖子，以及带主队标签的帖子，按发布时间倒序
+// 参数：cursor、limit
+func GetFeed(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	cursor, limit, ok := parseCursor(c)
+	if !ok {
+		return
+	}
+
+	filter := post.FeedFilter{UserID: userID, Cursor: cursor, Limit: limit}
+	// 主队获取失败时只返回关注的用户的帖子
+	if team, err := loadHomeTeam(c.Request.Context(), userID); err != nil {
+		log.Printf("Failed to load home team for feed of user %d: %v", userID, err)
+	} else {
+		filter.HomeTeamID = team.ID
+	}
+
+	page, err := post.Feed(filter)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "查询动态失败")
+		return
+	}
+	util.SuccessResponse(c, http.StatusOK, page)
+}
+
+// GetRankedFeed 个性化推荐流：帖子、主队和关注球队的比赛结果、关注球员的单场表现按综合得分排序
+// 推荐流预先计算，第一页请求时如已过期则先重算；参数：cursor（上一页返回的 next_cursor）、limit
+func GetRankedFeed(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	cursor, err := feed.DecodeCursor(c.Query("cursor"))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "无效的 cursor")
+		return
+	}
+	limit, ok := parseLimit(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	if err := feed.Touch(userID, now); err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "查询推荐流失败")
+		return
+	}
+	computedAt, err := feed.ComputedAt(userID)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "查询推荐流失败")
+		return
+	}
+	if computedAt.IsZero() || (c.Query("cursor") == "" && now.Sub(computedAt) > feed.StaleAfter) {
+		if err := feed.Rebuild(userID, now); err != nil {
+			util.ErrorResponse(c, http.StatusInternalServerError, "计算推荐流失败")
+			return
+		}
+	}
+
+	page, err := feed.Page(userID, cursor, limit)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "查询推荐流失败")
+		return
+	}
+	util.SuccessResponse(c, http.StatusOK, page)
+}
+
+// GetUserPosts 用户发的帖子，参数：cursor、limit
+func GetUserPosts(c *gin.Context) {
+	userID, ok := targetUserID(c)
+	if !ok {
+		return
+	}
+	cursor, limit, ok := parseCursor(c)
+	if !ok {
+		return
+	}
+
+	page, err := post.UserPosts(userID, viewerID(c), cursor, limit)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "查询帖子失败")
+		return
+	}
+	util.SuccessResponse(c, http.StatusOK, page)
+}
+
+// GetPost 帖子详情
+func GetPost(c *gin.Context) {
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "无效的帖子ID")
+		return
+	}
+
+	p, err := post.Get(postID, viewerID(c))
+	if errors.Is(err, post.ErrNotFound) {
+		util.ErrorResponse(c, http.StatusNotFound, "帖子不存在")
+		return
+	}
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "查询帖子失败")
+		return
+	}
+	util.SuccessResponse(c, http.StatusOK, p)
+}
+
+// parsePostTags 解析 tags 表单字段（可多个，格式为 team:14、player:237、game:1037）
+func parsePostTags(values []string) ([]model.PostTag, error) {
+	if len(values) > postMaxTags {
+		return nil, fmt.Errorf("最多添加 %d 个标签", postMaxTags)
+	}
+	tags := make([]model.PostTag, 0, len(values))
+	for _, value := range values {
+		tagType, rawID, _ := strings.Cut(value, ":")
+		id, err := strconv.Atoi(rawID)
+		if err != nil || id <= 0 ||
+			(tagType != model.PostTagTeam && tagType != model.PostTagPlayer && tagType != model.PostTagGame) {
+			return nil, fmt.Errorf("无效的标签：%s", value)
+		}
+		tags = append(tags, model.PostTag{Type: tagType, ID: id})
+	}
+	return tags, nil
+}
+
+// checkPostTags 验证标签关联的球队、球员、比赛存在，失败时写入响应并返回 false
+func checkPostTags(c *gin.Context, tags []model.PostTag) bool {
+	ctx := c.Request.Context()
+	for _, tag := range tags {
+		switch tag.Type {
+		case model.PostTagTeam:
+			teams, _, err := loadTeams(ctx)
+			if err != nil {
+				nbaErrorResponse(c, "获取球队列表失败", err)
+				return false
+			}
+			if _, ok := findTeam(teams, tag.ID); !ok {
+				util.ErrorResponse(c, http.StatusBadRequest, fmt.Sprintf("球队 %d 不存在", tag.ID))
+				return false
+			}
+		case model.PostTagPlayer:
+			if _, _, err := loadPlayer(ctx, tag.ID); err != nil {
+				nbaErrorResponse(c, fmt.Sprintf("球员 %d 不存在", tag.ID), err)
+				return false
+			}
+		case model.PostTagGame:
+			if _, _, err := getNBAClient().GetGame(ctx, tag.ID); err != nil {
+				nbaErrorResponse(c, fmt.Sprintf("比赛 %d 不存在", tag.ID), err)
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// savePostImages 保存表单中的 images 图片；没有上传时返回 nil
+func savePostImages(c *gin.Context) ([]string, error) {
+	form, err := c.MultipartForm()
+	if err != nil || len(form.File["images"]) == 0 {
+		return nil, nil
+	}
+	files := form.File["images"]
+	if len(files) > postMaxImages {
+		return nil, fmt.Errorf("最多上传 %d 张图片", postMaxImages)
+	}
+
+	images := make([]string, 0, len(files))
+	for _, file := range files {
+		path, err := util.SaveImage(file, "posts")
+		if err != nil {
+			deletePostImages(images)
+			return nil, err
+		}
+		images = append(images, path)
+	}
+	return images, nil
+}
+
+// deletePostImages 清理发帖失败时已保存的图片
+func deletePostImages(images []string) {
+	for _, path := range images {
+		util.DeleteUpload(path)
+	}
+}
+
+// CreatePost 发帖（multipart：content、images 可多张、tags 可多个），文字和图片至少有一项
+func CreatePost(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	content := strings.TrimSpace(c.PostForm("content"))
+	if len([]rune(content)) > postMaxContent {
+		util.ErrorResponse(c, http.StatusBadRequest, fmt.Sprintf("内容不能超过 %d 字", postMaxContent))
+		return
+	}
+	tags, err := parsePostTags(c.PostFormArray("tags"))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !checkPostTags(c, tags) {
+		return
+	}
+	images, err := savePostImages(c)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if content == "" && len(images) == 0 {
+		util.ErrorResponse(c, http.StatusBadRequest, "内容和图片不能都为空")
+		return
+	}
+
+	postID, err := post.Create(post.Spec{UserID: userID, Content: content, Images: images, Tags: tags})
+	if err != nil {
+		deletePostImages(images)
+		util.ErrorResponse(c, http.StatusInternalServerError, "发帖失败")
+		return
+	}
+	p, err := post.Get(postID, userID)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "查询帖子失败")
+		return
+	}
+	util.SuccessResponse(c, http.StatusCreated, p)
+}
+
+// postErrorResponse 帖子和评论操作的错误响应
+func postErrorResponse(c *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, post.ErrNotFound):
+		util.ErrorResponse(c, http.StatusNotFound, "帖子不存在")
+	case errors.Is(err, post.ErrBlocked):
+		util.ErrorResponse(c, http.StatusForbidden, "你与作者存在拉黑关系")
+	case errors.Is(err, post.ErrNotAuthor):
+		util.ErrorResponse(c, http.StatusForbidden, "没有权限删除")
+	case errors.Is(err, post.ErrParentNotFound):
+		util.ErrorResponse(c, http.StatusBadRequest, "回复的评论不存在")
+	default:
+		util.ErrorResponse(c, http.StatusInternalServerError, msg)
+	}
+}
+
+// DeletePost 删除自己的帖子
+func DeletePost(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "无效的帖子ID")
+		return
+	}
+
+	if err := post.Delete(postID, userID, time.Now()); err != nil {
+		postErrorResponse(c, err, "删除帖子失败")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// setPostLiked 点赞或取消点赞
+func setPostLiked(c *gin.Context, liked bool) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "无效的帖子ID")
+		return
+	}
+
+	count, err := post.SetLiked(postID, userID, liked)
+	if err != nil {
+		postErrorResponse(c, err, "操作失败")
+		return
+	}
+	util.SuccessResponse(c, http.StatusOK, gin.H{"post_id": postID, "liked": liked, "like_count": count})
+}
+
+// LikePost 点赞（重复点赞不重复计数）
+func LikePost(c *gin.Context) {
+	setPostLiked(c, true)
+}
+
+// UnlikePost 取消点赞
+func UnlikePost(c *gin.Context) {
+	setPostLiked(c, false)
+}
+
+// GetPostComments 帖子的评论（一级评论分页，附带全部回复），参数：limit、offset
+func GetPostComments(c *gin.Context) {
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "无效的帖子ID")
+		return
+	}
+	limit, offset, ok := parsePage(c, commentDefaultPage, commentMaxPage)
+	if !ok {
+		return
+	}
+
+	viewer := viewerID(c)
+	if _, err := post.Get(postID, viewer); err != nil {
+		postErrorResponse(c, err, "查询评论失败")
+		return
+	}
+	comments, err := post.Comments(postID, viewer, limit, offset)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "查询评论失败")
+		return
+	}
+	util.SuccessResponse(c, http.StatusOK, comments)
+}
+
+// CreatePostCommentRequest 发表评论请求，回复评论时带 parent_id
+type CreatePostCommentRequest struct {
+	Content  string `json:"content" binding:"required"`
+	ParentID int    `json:"parent_id"`
+}
+
+// CreatePostComment 发表评论或回复
+func CreatePostComment(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "无效的帖子ID")
+		return
+	}
+	var req CreatePostCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+	content := strings.TrimSpace(req.Content)
+	if content == "" || len([]rune(content)) > commentMaxContent {
+		util.ErrorResponse(c, http.StatusBadRequest, fmt.Sprintf("评论内容必须为 1-%d 字", commentMaxContent))
+		return
+	}
+
+	comment, recipientID, err := post.AddComment(postID, userID, req.ParentID, content)
+	if err != nil {
+		postErrorResponse(c, err, "发表评论失败")
+		return
+	}
+	notify.Emit(notify.CommentReply(recipientID, userID, postID, comment.ID, content, req.ParentID != 0))
+	util.SuccessResponse(c, http.StatusCreated, comment)
+}
+
+// DeletePostComment 删除评论，评论作者和帖子作者都可以删除
+func DeletePostComment(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	commentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "无效的评论ID")
+		return
+	}
+
+	err = post.DeleteComment(commentID, userID, time.Now())
+	if errors.Is(err, post.ErrNotFound) {
+		util.ErrorResponse(c, http.StatusNotFound, "评论不存在")
+		return
+	}
+	if err != nil {
+		postErrorResponse(c, err, "删除评论失败")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,231 @@
+package api
+
+import (
+	"buzzerbeater/ledger"
+	"buzzerbeater/model"
+	"buzzerbeater/prediction"
+	"buzzerbeater/util"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// userStakeLimit 下注记录接口返回的记录数
+const userStakeLimit = 100
+
+// GetPredictionMarkets 竞猜大厅：按比赛日期、比赛或状态筛选盘口，不传条件时返回可下注的盘口
+func GetPredictionMarkets(c *gin.Context) {
+	filter := prediction.MarketFilter{
+		Date:   c.Query("date"),
+		Status: c.Query("status"),
+	}
+	if filter.Date != "" {
+		if _, err := time.Parse("2006-01-02", filter.Date); err != nil {
+			util.ErrorResponse(c, http.StatusBadRequest, "无效的日期")
+			return
+		}
+	}
+	if gameID := c.Query("game_id"); gameID != "" {
+		id, err := strconv.Atoi(gameID)
+		if err != nil {
+			util.ErrorResponse(c, http.StatusBadRequest, "无效的比赛ID")
+			return
+		}
+		filter.GameID = id
+	}
+	switch filter.Status {
+	case "":
+		if filter.Date == "" && filter.GameID == 0 {
+			filter.Status = model.PredictionOpen
+		}
+	case model.PredictionOpen, model.PredictionLocked, model.PredictionSettled, model.PredictionCancelled:
+	default:
+		util.ErrorResponse(c, http.StatusBadRequest, "无效的盘口状态")
+		return
+	}
+
+	markets, err := prediction.ListMarkets(filter)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "查询盘口失败")
+		return
+	}
+	util.SuccessResponse(c, http.StatusOK, markets)
+}
+
+// GetPredictionMarket 盘口详情（含各选项奖池和实时赔率）
+func GetPredictionMarket(c *gin.Context) {
+	marketID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "无效的盘口ID")
+		return
+	}
+	market, err := prediction.GetMarket(marketID)
+	if errors.Is(err, prediction.ErrNotFound) {
+		util.ErrorResponse(c, http.StatusNotFound, "盘口不存在")
+		return
+	}
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "查询盘口失败")
+		return
+	}
+	util.SuccessResponse(c, http.StatusOK, market)
+}
+
+// CreatePredictionMarketRequest 开设盘口请求
+type CreatePredictionMarketRequest struct {
+	GameID   int                     `json:"game_id" binding:"required"`
+	Type     string                  `json:"type" binding:"required,oneof=winner player_points"`
+	PlayerID int                     `json:"player_id"` // player_points 必填
+	Ranges   []prediction.PointRange `json:"ranges"`    // 不传时使用默认得分区间
+}
+
+// CreatePredictionMarket 为未开赛的比赛开设盘口；同一比赛的同类盘口已存在时返回已有盘口
+func CreatePredictionMarket(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req CreatePredictionMarketRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+
+	ctx := c.Request.Context()
+	game, _, err := getNBAClient().GetGame(ctx, req.GameID)
+	if err != nil {
+		nbaErrorResponse(c, "获取比赛失败", err)
+		return
+	}
+	if game.IsFinal() || game.IsCancelled() {
+		util.ErrorResponse(c, http.StatusConflict, "比赛已结束或已取消")
+		return
+	}
+
+	var spec prediction.MarketSpec
+	switch req.Type {
+	case model.PredictionWinner:
+		spec, err = prediction.WinnerMarket(game)
+	case model.PredictionPlayerPoints:
+		if req.PlayerID <= 0 {
+			util.ErrorResponse(c, http.StatusBadRequest, "缺少球员ID")
+			return
+		}
+		if req.Ranges == nil {
+			req.Ranges = prediction.DefaultPointRanges
+		}
+		if err := prediction.ValidatePointRanges(req.Ranges); err != nil {
+			util.ErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		player, _, perr := loadPlayer(ctx, req.PlayerID)
+		if perr != nil {
+			nbaErrorResponse(c, "获取球员失败", perr)
+			return
+		}
+		if player.Team.ID != game.HomeTeam.ID && player.Team.ID != game.VisitorTeam.ID {
+			util.ErrorResponse(c, http.StatusBadRequest, "该球员不属于比赛中的球队")
+			return
+		}
+		spec, err = prediction.PlayerPointsMarket(game, player, req.Ranges)
+	}
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadGateway, "比赛缺少开赛时间")
+		return
+	}
+	if !time.Now().Before(spec.LockAt) {
+		util.ErrorResponse(c, http.StatusConflict, "比赛已开始，不能再开设盘口")
+		return
+	}
+	spec.CreatedBy = userID
+
+	marketID, created, err := prediction.CreateMarket(spec)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "开设盘口失败")
+		return
+	}
+	market, err := prediction.GetMarket(marketID)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "查询盘口失败")
+		return
+	}
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	util.SuccessResponse(c, status, market)
+}
+
+// PredictionStakeRequest 下注请求
+type PredictionStakeRequest struct {
+	OptionID int `json:"option_id" binding:"required"`
+	Amount   int `json:"amount" binding:"required,min=1"`
+}
+
+// CreatePredictionStake 下注（开赛后封盘），返回更新后的盘口赔率
+// 客户端可通过 Idempotency-Key 请求头避免网络重试导致重复下注
+func CreatePredictionStake(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	marketID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "无效的盘口ID")
+		return
+	}
+
+	var req PredictionStakeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+
+	err = prediction.PlaceStake(userID, marketID, req.OptionID, req.Amount, c.GetHeader("Idempotency-Key"), time.Now().UTC())
+	status := http.StatusCreated
+	switch {
+	case errors.Is(err, ledger.ErrDuplicate):
+		// 重复提交（客户端重试），不会重复扣款
+		status = http.StatusOK
+	case errors.Is(err, prediction.ErrKeyReused):
+		util.ErrorResponse(c, http.StatusUnprocessableEntity, "Idempotency-Key 已用于另一笔下注")
+		return
+	case errors.Is(err, prediction.ErrNotFound):
+		util.ErrorResponse(c, http.StatusNotFound, "盘口或选项不存在")
+		return
+	case errors.Is(err, prediction.ErrMarketClosed):
+		util.ErrorResponse(c, http.StatusConflict, "盘口已封盘")
+		return
+	case errors.Is(err, ledger.ErrInsufficientCoins):
+		util.ErrorResponse(c, http.StatusPaymentRequired, "金币不足")
+		return
+	case err != nil:
+		util.ErrorResponse(c, http.StatusInternalServerError, "下注失败")
+		return
+	}
+
+	market, err := prediction.GetMarket(marketID)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "查询盘口失败")
+		return
+	}
+	util.SuccessResponse(c, status, market)
+}
+
+// GetMyPredictions 当前用户的下注记录
+func GetMyPredictions(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	stakes, err := prediction.UserStakes(userID, userStakeLimit)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "查询下注记录失败")
+		return
+	}
+	util.SuccessResponse(c, http.StatusOK, stakes)
+}
@@ -64,6 +64,9 @@ func CreateSession(c *gin.Context) {
 		return
 	}
 
+	// 每日登录奖励
+	claimLoginBonus(user.ID)
+
 	// 组装响应
 	user.Team = &team
 	user.Password = "" // 不返回密码
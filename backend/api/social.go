@@ -0,0 +1,392 @@
+package api
+
+import (
+	"buzzerbeater/achievement"
+	"buzzerbeater/db"
+	"buzzerbeater/model"
+	"buzzerbeater/notify"
+	"buzzerbeater/social"
+	"buzzerbeater/util"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 用户列表分页
+const (
+	userListDefaultPage = 50
+	userListMaxPage     = 200
+)
+
+// viewerID 可选认证接口的当前用户 ID，未登录时为 0
+func viewerID(c *gin.Context) int {
+	return c.GetInt("user_id")
+}
+
+// parsePage 解析 limit、offset 分页参数，无效时写入 400 响应
+func parsePage(c *gin.Context, defaultLimit, maxLimit int) (limit, offset int, ok bool) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultLimit)))
+	if err != nil || limit < 1 || limit > maxLimit {
+		util.ErrorResponse(c, http.StatusBadRequest, "limit 必须在 1-"+strconv.Itoa(maxLimit)+" 之间")
+		return 0, 0, false
+	}
+	offset, err = strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		util.ErrorResponse(c, http.StatusBadRequest, "无效的 offset")
+		return 0, 0, false
+	}
+	return limit, offset, true
+}
+
+// checkNotBlockedBy 目标用户拉黑了当前用户时按用户不存在处理，写入 404 响应并返回 false
+func checkNotBlockedBy(c *gin.Context, userID int) bool {
+	viewer := viewerID(c)
+	if viewer == 0 || viewer == userID {
+		return true
+	}
+	blockedBy, err := social.IsBlocking(userID, viewer)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "查询用户失败")
+		return false
+	}
+	if blockedBy {
+		util.ErrorResponse(c, http.StatusNotFound, "用户不存在")
+		return false
+	}
+	return true
+}
+
+// targetUserID 解析路径中的用户 ID；目标用户拉黑了当前用户时按不存在处理
+func targetUserID(c *gin.Context) (int, bool) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "无效的用户ID")
+		return 0, false
+	}
+	return userID, checkNotBlockedBy(c, userID)
+}
+
+// GetUserProfile 用户主页
+func GetUserProfile(c *gin.Context) {
+	if userID, ok := targetUserID(c); ok {
+		userProfileResponse(c, userID)
+	}
+}
+
+// GetUserProfileByNickname 按昵称查看用户主页
+func GetUserProfileByNickname(c *gin.Context) {
+	var userID int
+	err := db.GetDB().QueryRow("SELECT id FROM users WHERE nickname = ?", c.Param("nickname")).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		util.ErrorResponse(c, http.StatusNotFound, "用户不存在")
+		return
+	}
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "查询用户失败")
+		return
+	}
+	if checkNotBlockedBy(c, userID) {
+		userProfileResponse(c, userID)
+	}
+}
+
+// userProfileResponse 用户主页：昵称和头像总是公开，主队、注册时间、关注数据和成就按隐私设置返回，
+// 登录时附带与当前用户的关系
+func userProfileResponse(c *gin.Context, userID int) {
+	var profile model.UserProfile
+	var createdAt time.Time
+	var teamID sql.NullInt64
+	var teamName, teamCode, teamColor, teamAccent sql.NullString
+	err := db.GetDB().QueryRow(`
+		SELECT u.id, u.nickname, u.avatar, u.created_at, t.id, t.name, t.code, t.color, t.accent
+		FROM users u
+		LEFT JOIN teams t ON u.team_id = t.id
+		WHERE u.id = ?
+	`, userID).Scan(&profile.ID, &profile.Nickname, &profile.Avatar, &createdAt,
+		&teamID, &teamName, &teamCode, &teamColor, &teamAccent)
+	if errors.Is(err, sql.ErrNoRows) {
+		util.ErrorResponse(c, http.StatusNotFound, "用户不存在")
+		return
+	}
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "查询用户失败")
+		return
+	}
+
+	viewer := viewerID(c)
+	privacy := social.DefaultPrivacy
+	if viewer != userID {
+		if privacy, err = social.GetPrivacy(userID); err != nil {
+			util.ErrorResponse(c, http.StatusInternalServerError, "查询用户失败")
+			return
+		}
+	}
+
+	if privacy.ShowTeam && teamID.Valid {
+		profile.Team = &model.Team{ID: int(teamID.Int64), Name: teamName.String, Code: teamCode.String,
+			Color: teamColor.String, Accent: teamAccent.String}
+	}
+	if privacy.ShowJoinDate {
+		profile.CreatedAt = &createdAt
+	}
+	if privacy.ShowCounts {
+		counts, err := social.Counts(userID)
+		if err != nil {
+			util.ErrorResponse(c, http.StatusInternalServerError, "查询用户失败")
+			return
+		}
+		profile.Counts = &counts
+	}
+	if privacy.ShowAchievements {
+		if profile.Achievements, err = achievement.ForUser(userID, time.Now(), privacy.ShowCounts); err != nil {
+			util.ErrorResponse(c, http.StatusInternalServerError, "查询用户失败")
+			return
+		}
+	}
+	if viewer != 0 && viewer != userID {
+		relationship, err := social.GetRelationship(viewer, userID)
+		if err != nil {
+			util.ErrorResponse(c, http.StatusInternalServerError, "查询用户失败")
+			return
+		}
+		profile.Relationship = &relationship
+	}
+	util.SuccessResponse(c, http.StatusOK, profile)
+}
+
+// getUserList 粉丝、关注或好友列表
+func getUserList(c *gin.Context, kind string, userID int) {
+	limit, offset, ok := parsePage(c, userListDefaultPage, userListMaxPage)
+	if !ok {
+		return
+	}
+	users, err := social.List(kind, userID, viewerID(c), limit, offset)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "查询用户列表失败")
+		return
+	}
+	util.SuccessResponse(c, http.StatusOK, users)
+}
+
+// getPublicUserList 他人的粉丝或关注列表，用户隐藏了关注数据时返回 403
+func getPublicUserList(c *gin.Context, kind string) {
+	userID, ok := targetUserID(c)
+	if !ok {
+		return
+	}
+	if viewerID(c) != userID {
+		privacy, err := social.GetPrivacy(userID)
+		if err != nil {
+			util.ErrorResponse(c, http.StatusInternalServerError, "查询用户列表失败")
+			return
+		}
+		if !privacy.ShowCounts {
+			util.ErrorResponse(c, http.StatusForbidden, "该用户未公开关注列表")
+			return
+		}
+	}
+	getUserList(c, kind, userID)
+}
+
+// GetUserFollowers 用户的粉丝
+func GetUserFollowers(c *gin.Context) {
+	getPublicUserList(c, social.ListFollowers)
+}
+
+// GetUserFollowing 用户关注的人
+func GetUserFollowing(c *gin.Context) {
+	getPublicUserList(c, social.ListFollowing)
+}
+
+// GetMyFriends 我的好友（互相关注）
+func GetMyFriends(c *gin.Context) {
+	if userID, ok := currentUserID(c); ok {
+		getUserList(c, social.ListFriends, userID)
+	}
+}
+
+// GetMyBlocks 我的黑名单
+func GetMyBlocks(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	limit, offset, ok := parsePage(c, userListDefaultPage, userListMaxPage)
+	if !ok {
+		return
+	}
+	users, err := social.Blocks(userID, limit, offset)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "查询黑名单失败")
+		return
+	}
+	util.SuccessResponse(c, http.StatusOK, users)
+}
+
+// socialErrorResponse 关注、拉黑操作的错误响应
+func socialErrorResponse(c *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, social.ErrUserNotFound):
+		util.ErrorResponse(c, http.StatusNotFound, "用户不存在")
+	case errors.Is(err, social.ErrSelf):
+		util.ErrorResponse(c, http.StatusBadRequest, "不能对自己操作")
+	case errors.Is(err, social.ErrBlocked):
+		util.ErrorResponse(c, http.StatusForbidden, "你们之间存在拉黑关系")
+	default:
+		util.ErrorResponse(c, http.StatusInternalServerError, msg)
+	}
+}
+
+// relationshipResponse 操作后返回与目标用户的最新关系
+func relationshipResponse(c *gin.Context, status, viewer, target int) {
+	relationship, err := social.GetRelationship(viewer, target)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "查询关系失败")
+		return
+	}
+	util.SuccessResponse(c, status, relationship)
+}
+
+// FollowUser 关注用户；被对方拉黑时与查看主页一样按用户不存在处理
+func FollowUser(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	targetID, ok := targetUserID(c)
+	if !ok {
+		return
+	}
+
+	created, err := social.Follow(userID, targetID)
+	if errors.Is(err, social.ErrBlocked) && !checkNotBlockedBy(c, targetID) {
+		// 检查之后才被对方拉黑
+		return
+	}
+	if err != nil {
+		socialErrorResponse(c, err, "关注失败")
+		return
+	}
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+		notify.Emit(notify.NewFollower(targetID, userID))
+	}
+	relationshipResponse(c, status, userID, targetID)
+}
+
+// UnfollowUser 取消关注
+func UnfollowUser(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	targetID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "无效的用户ID")
+		return
+	}
+	if err := social.Unfollow(userID, targetID); err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "取消关注失败")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// BlockUser 拉黑用户：解除双方关注，互相看不到对方的内容，对方不能再关注你或报名你发起的约球
+func BlockUser(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	targetID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "无效的用户ID")
+		return
+	}
+	if err := social.Block(userID, targetID); err != nil {
+		socialErrorResponse(c, err, "拉黑失败")
+		return
+	}
+	relationshipResponse(c, http.StatusOK, userID, targetID)
+}
+
+// UnblockUser 取消拉黑
+func UnblockUser(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	targetID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "无效的用户ID")
+		return
+	}
+	if err := social.Unblock(userID, targetID); err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "取消拉黑失败")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetPrivacySettings 我的主页隐私设置
+func GetPrivacySettings(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	privacy, err := social.GetPrivacy(userID)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "查询隐私设置失败")
+		return
+	}
+	util.SuccessResponse(c, http.StatusOK, privacy)
+}
+
+// UpdatePrivacySettingsRequest 更新隐私设置请求，不传的字段保持不变
+type UpdatePrivacySettingsRequest struct {
+	ShowTeam         *bool `json:"show_team"`
+	ShowJoinDate     *bool `json:"show_join_date"`
+	ShowCounts       *bool `json:"show_counts"`
+	ShowAchievements *bool `json:"show_achievements"`
+}
+
+// UpdatePrivacySettings 更新主页隐私设置
+func UpdatePrivacySettings(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req UpdatePrivacySettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.ErrorResponse(c, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+
+	privacy, err := social.GetPrivacy(userID)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "更新隐私设置失败")
+		return
+	}
+	if req.ShowTeam != nil {
+		privacy.ShowTeam = *req.ShowTeam
+	}
+	if req.ShowJoinDate != nil {
+		privacy.ShowJoinDate = *req.ShowJoinDate
+	}
+	if req.ShowCounts != nil {
+		privacy.ShowCounts = *req.ShowCounts
+	}
+	if req.ShowAchievements != nil {
+		privacy.ShowAchievements = *req.ShowAchievements
+	}
+	if err := social.SavePrivacy(userID, privacy); err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "更新隐私设置失败")
+		return
+	}
+	util.SuccessResponse(c, http.StatusOK, privacy)
+}
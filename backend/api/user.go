@@ -3,6 +3,7 @@ package api
 import (
 	"buzzerbeater/db"
 	"buzzerbeater/model"
+	"buzzerbeater/social"
 	"buzzerbeater/util"
 	"database/sql"
 	"net/http"
@@ -151,6 +152,15 @@ func GetCurrentUser(c *gin.Context) {
 	}
 
 	user.Team = &team
+
+	// 关注数据
+	counts, err := social.Counts(user.ID)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "查询用户失败")
+		return
+	}
+	user.Counts = &counts
+
 	util.SuccessResponse(c, http.StatusOK, user)
 }
 
@@ -248,3 +258,14 @@ func UpdateTeam(c *gin.Context) {
 	// 返回新的球队信息
 	util.SuccessResponse(c, http.StatusOK, team)
 }
+
+// currentUserID 取认证中间件写入的用户 ID，未登录时写入 401 响应
+func currentUserID(c *gin.Context) (int, bool) {
+	userID, exists := c.Get("user_id")
+	id, ok := userID.(int)
+	if !exists || !ok {
+		util.ErrorResponse(c, http.StatusUnauthorized, "未授权")
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"buzzerbeater/db"
+	"buzzerbeater/ledger"
+	"buzzerbeater/util"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// walletTransactionLimit 钱包接口返回的流水条数
+const walletTransactionLimit = 50
+
+// GetWallet 当前用户的金币余额和最近流水（第一次访问时发放初始金币）
+func GetWallet(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	wallet, err := ledger.Wallet(userID, walletTransactionLimit)
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "查询钱包失败")
+		return
+	}
+	util.SuccessResponse(c, http.StatusOK, wallet)
+}
+
+// DailyBonusResponse 领取每日奖励响应
+type DailyBonusResponse struct {
+	Claimed bool `json:"claimed"` // 今天已经领取过时为 false
+	Amount  int  `json:"amount"`
+	Balance int  `json:"balance"`
+}
+
+// ClaimDailyBonus 领取每日登录奖励（每天一次，按北京时间零点重置）
+func ClaimDailyBonus(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	claimed, err := ledger.ClaimDailyBonus(userID, time.Now())
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "领取奖励失败")
+		return
+	}
+	balance, err := ledger.Balance(db.GetDB(), ledger.UserAccount(userID))
+	if err != nil {
+		util.ErrorResponse(c, http.StatusInternalServerError, "查询钱包失败")
+		return
+	}
+
+	response := DailyBonusResponse{Claimed: claimed, Balance: balance}
+	status := http.StatusOK
+	if claimed {
+		response.Amount = ledger.DailyBonus
+		status = http.StatusCreated
+	}
+	util.SuccessResponse(c, status, response)
+}
+
+// claimLoginBonus 登录时自动领取每日奖励，失败不影响登录
+func claimLoginBonus(userID int) {
+	if _, err := ledger.ClaimDailyBonus(userID, time.Now()); err != nil {
+		log.Printf("Daily bonus for user %d failed: %v", userID, err)
+	}
+}
@@ -0,0 +1,136 @@
+// ledger 金币账本运维工具（在服务的工作目录下运行，读写同一个 buzzerbeater.db）
+//
+// 用法：
+//
+//	go run ./cmd/ledger reconcile
+//	go run ./cmd/ledger balance -user 42
+//	go run ./cmd/ledger adjust -user 42 -amount 500 -memo "活动补偿" -key event-2026-10
+//
+// reconcile 校验每笔凭证借贷平衡、账户余额从未为负、全部账户合计为 0，以及竞猜盘口的托管余额与下注一致，
+// 发现问题时逐条打印并以状态码 1 退出。
+// adjust 为管理员调整（amount 为负时扣减），-key 为幂等键，同一个键重复执行只记一次账。
+package main
+
+import (
+	"buzzerbeater/db"
+	"buzzerbeater/ledger"
+	"buzzerbeater/prediction"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	db.Init()
+	defer db.Close()
+
+	var err error
+	switch os.Args[1] {
+	case "reconcile":
+		err = runReconcile(os.Args[2:])
+	case "balance":
+		err = runBalance(os.Args[2:])
+	case "adjust":
+		err = runAdjust(os.Args[2:])
+	default:
+		usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ledger:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: ledger <reconcile|balance|adjust> [flags]")
+}
+
+// runReconcile 对账
+func runReconcile(args []string) error {
+	fs := flag.NewFlagSet("reconcile", flag.ExitOnError)
+	fs.Parse(args)
+
+	report, err := ledger.Reconcile()
+	if err != nil {
+		return err
+	}
+	escrowProblems, err := prediction.ReconcileEscrow()
+	if err != nil {
+		return err
+	}
+	problems := append(report.Problems, escrowProblems...)
+
+	fmt.Printf("accounts:     %d\n", report.Accounts)
+	fmt.Printf("transactions: %d\n", report.Transactions)
+	fmt.Printf("issued:       %d\n", report.Issued)
+	fmt.Printf("wallets:      %d\n", report.UserBalance)
+	fmt.Printf("escrow:       %d\n", report.EscrowBalance)
+	for _, p := range problems {
+		fmt.Println("PROBLEM", p)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%d problems found", len(problems))
+	}
+	fmt.Println("ok")
+	return nil
+}
+
+// runBalance 查询用户余额
+func runBalance(args []string) error {
+	fs := flag.NewFlagSet("balance", flag.ExitOnError)
+	userID := fs.Int("user", 0, "用户 ID")
+	fs.Parse(args)
+	if *userID <= 0 {
+		return errors.New("-user is required")
+	}
+
+	balance, err := ledger.Balance(db.GetDB(), ledger.UserAccount(*userID))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s %d\n", ledger.UserAccount(*userID), balance)
+	return nil
+}
+
+// runAdjust 管理员调整余额
+func runAdjust(args []string) error {
+	fs := flag.NewFlagSet("adjust", flag.ExitOnError)
+	userID := fs.Int("user", 0, "用户 ID")
+	amount := fs.Int("amount", 0, "调整金额（负数为扣减）")
+	memo := fs.String("memo", "", "调整原因")
+	key := fs.String("key", "", "幂等键（同一个键只记一次账）")
+	fs.Parse(args)
+	if *userID <= 0 || *amount == 0 || *memo == "" || *key == "" {
+		return errors.New("-user, -amount, -memo and -key are required")
+	}
+
+	var exists bool
+	if err := db.GetDB().QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE id = ?)", *userID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("user %d not found", *userID)
+	}
+
+	id, err := ledger.Adjust(*userID, *amount, *memo, *key)
+	if errors.Is(err, ledger.ErrDuplicate) {
+		fmt.Printf("already posted as transaction %d\n", id)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	balance, err := ledger.Balance(db.GetDB(), ledger.UserAccount(*userID))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("posted transaction %d, balance %d\n", id, balance)
+	return nil
+}
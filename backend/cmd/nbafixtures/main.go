@@ -0,0 +1,171 @@
+// nbafixtures 维护 NBA 离线回放用的录制数据
+//
+// 用法：
+//
+//	go run ./cmd/nbafixtures list
+//	go run ./cmd/nbafixtures refresh [-older-than 168h] [endpoint ...]
+//	go run ./cmd/nbafixtures prune -older-than 720h [-dry-run]
+//
+// refresh 不带 endpoint 时重新请求目录中已录制的所有请求；带 endpoint（如 /nba/v1/teams）时录制指定请求。
+// prune 删除超过指定时长的录制和无法解析的损坏文件。
+package main
+
+import (
+	"buzzerbeater/config"
+	"buzzerbeater/external"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+)
+
+func main() {
+	config.Init()
+
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "list":
+		err = runList(os.Args[2:])
+	case "refresh":
+		err = runRefresh(os.Args[2:])
+	case "prune":
+		err = runPrune(os.Args[2:])
+	default:
+		usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "nbafixtures:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: nbafixtures <list|refresh|prune> [flags]")
+}
+
+// newFlagSet 每个子命令共用的 -dir 参数
+func newFlagSet(name string) (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	dir := fs.String("dir", config.AppConfig.NBAFixturesDir, "录制数据目录")
+	return fs, dir
+}
+
+// runList 列出录制数据
+func runList(args []string) error {
+	fs, dir := newFlagSet("list")
+	fs.Parse(args)
+
+	files, err := external.NewFixtureStore(*dir).List()
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if f.Fixture == nil {
+			fmt.Printf("%-20s %-4s %s (%v)\n", "-", "ERR", f.Path, f.Err)
+			continue
+		}
+		fmt.Printf("%-20s %-4d %s\n", f.Fixture.RecordedAt.Format(time.DateTime), f.Fixture.StatusCode, f.Fixture.Endpoint)
+	}
+	fmt.Printf("%d fixtures in %s\n", len(files), *dir)
+	return nil
+}
+
+// runRefresh 重新录制
+func runRefresh(args []string) error {
+	fs, dir := newFlagSet("refresh")
+	olderThan := fs.Duration("older-than", 0, "只刷新录制时间早于该时长的数据（0 表示全部）")
+	fs.Parse(args)
+
+	store := external.NewFixtureStore(*dir)
+	endpoints := fs.Args()
+	if len(endpoints) == 0 {
+		files, err := store.List()
+		if err != nil {
+			return err
+		}
+		cutoff := time.Now().Add(-*olderThan)
+		for _, f := range files {
+			if f.Fixture == nil {
+				continue
+			}
+			if *olderThan > 0 && f.Fixture.RecordedAt.After(cutoff) {
+				continue
+			}
+			endpoints = append(endpoints, f.Fixture.Endpoint)
+		}
+	}
+
+	config.AppConfig.NBAMode = external.ModeRecord
+	config.AppConfig.NBAFixturesDir = *dir
+	client := external.NewNBAClient()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	failed := 0
+	for i, endpoint := range endpoints {
+		if err := client.RefreshFixture(ctx, endpoint); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			failed++
+			fmt.Printf("[%d/%d] FAIL %s: %v\n", i+1, len(endpoints), endpoint, err)
+			continue
+		}
+		fmt.Printf("[%d/%d] ok   %s\n", i+1, len(endpoints), endpoint)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d fixtures failed to refresh", failed, len(endpoints))
+	}
+	return nil
+}
+
+// runPrune 删除过期和损坏的录制数据
+func runPrune(args []string) error {
+	fs, dir := newFlagSet("prune")
+	olderThan := fs.Duration("older-than", 0, "删除录制时间早于该时长的数据（0 表示只删除损坏文件）")
+	dryRun := fs.Bool("dry-run", false, "只列出将被删除的文件")
+	fs.Parse(args)
+
+	files, err := external.NewFixtureStore(*dir).List()
+	if err != nil {
+		return err
+	}
+
+	cutoff := time.Now().Add(-*olderThan)
+	removed := 0
+	for _, f := range files {
+		reason := ""
+		switch {
+		case f.Fixture == nil:
+			reason = fmt.Sprintf("invalid: %v", f.Err)
+		case *olderThan > 0 && f.Fixture.RecordedAt.Before(cutoff):
+			reason = "recorded " + f.Fixture.RecordedAt.Format(time.DateTime)
+		default:
+			continue
+		}
+
+		if !*dryRun {
+			if err := os.Remove(f.Path); err != nil {
+				return err
+			}
+		}
+		removed++
+		fmt.Printf("remove %s (%s)\n", f.Path, reason)
+	}
+
+	if *dryRun {
+		fmt.Printf("%d of %d fixtures would be removed\n", removed, len(files))
+	} else {
+		fmt.Printf("removed %d of %d fixtures\n", removed, len(files))
+	}
+	return nil
+}
@@ -2,11 +2,40 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Config 应用配置
 type Config struct {
-	BallDontLieAPIKey string
+	BallDontLieAPIKey    string
+	BallDontLieRateLimit int // 每分钟请求上限（ALL-STAR 套餐为 60）
+
+	NBAMode        string // 上游访问模式：live / record / replay
+	NBAFixturesDir string // record/replay 模式的录制数据目录
+
+	NBASyncEnabled  bool          // 是否启动本地数据仓库定时同步
+	NBASyncInterval time.Duration // 同步间隔
+	NBASyncSeason   int           // 同步的赛季，0 表示按日期跟随当前赛季
+
+	FantasyScoringEnabled  bool          // 是否启动 Fantasy 联赛定时计分
+	FantasyScoringInterval time.Duration // 计分间隔
+
+	PredictionSettleEnabled  bool          // 是否启动竞猜盘口定时开盘/封盘/结算
+	PredictionSettleInterval time.Duration // 结算间隔
+
+	FeedRankingEnabled  bool          // 是否定时为活跃用户重算推荐流
+	FeedRankingInterval time.Duration // 重算间隔
+
+	GameReminderEnabled  bool          // 是否定时提醒主队比赛即将开始
+	GameReminderInterval time.Duration // 检查间隔
+	NotificationChannels []string      // 站内信之外的通知投递渠道（目前支持 log）
+
+	InjuryProvider    string // 伤病数据来源：balldontlie / fixture
+	InjuryFixtureFile string // fixture 模式下的伤病数据文件
+
+	ModeratorIDs []int // 管理员用户 ID（可下架评价等）
 }
 
 var AppConfig *Config
@@ -14,7 +43,25 @@ var AppConfig *Config
 // Init 初始化配置
 func Init() {
 	AppConfig = &Config{
-		BallDontLieAPIKey: getEnv("BALLDONTLIE_API_KEY", "3b8fe95f-2b5b-4e57-984d-d6e76c7e7606"),
+		BallDontLieAPIKey:        getEnv("BALLDONTLIE_API_KEY", "3b8fe95f-2b5b-4e57-984d-d6e76c7e7606"),
+		BallDontLieRateLimit:     getEnvInt("BALLDONTLIE_RATE_LIMIT", 60),
+		NBAMode:                  getEnv("NBA_MODE", "live"),
+		NBAFixturesDir:           getEnv("NBA_FIXTURES_DIR", "./fixtures/nba"),
+		NBASyncEnabled:           getEnv("NBA_SYNC_ENABLED", "true") == "true",
+		NBASyncInterval:          getEnvDuration("NBA_SYNC_INTERVAL", 30*time.Minute),
+		NBASyncSeason:            getEnvInt("NBA_SYNC_SEASON", 0),
+		FantasyScoringEnabled:    getEnv("FANTASY_SCORING_ENABLED", "true") == "true",
+		FantasyScoringInterval:   getEnvDuration("FANTASY_SCORING_INTERVAL", 15*time.Minute),
+		PredictionSettleEnabled:  getEnv("PREDICTION_SETTLE_ENABLED", "true") == "true",
+		PredictionSettleInterval: getEnvDuration("PREDICTION_SETTLE_INTERVAL", 5*time.Minute),
+		FeedRankingEnabled:       getEnv("FEED_RANKING_ENABLED", "true") == "true",
+		FeedRankingInterval:      getEnvDuration("FEED_RANKING_INTERVAL", 10*time.Minute),
+		GameReminderEnabled:      getEnv("GAME_REMINDER_ENABLED", "true") == "true",
+		GameReminderInterval:     getEnvDuration("GAME_REMINDER_INTERVAL", 5*time.Minute),
+		NotificationChannels:     getEnvList("NOTIFICATION_CHANNELS"),
+		InjuryProvider:           getEnv("INJURY_PROVIDER", "balldontlie"),
+		InjuryFixtureFile:        getEnv("INJURY_FIXTURE_FILE", "./fixtures/injuries.json"),
+		ModeratorIDs:             getEnvIntList("MODERATOR_USER_IDS"),
 	}
 }
 
@@ -24,3 +71,43 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+	}
+	return defaultValue
+}
+
+// getEnvIntList 解析逗号分隔的整数列表，忽略无效项
+func getEnvIntList(key string) []int {
+	var list []int
+	for _, part := range strings.Split(os.Getenv(key), ",") {
+		if n, err := strconv.Atoi(strings.TrimSpace(part)); err == nil {
+			list = append(list, n)
+		}
+	}
+	return list
+}
+
+// getEnvList 解析逗号分隔的字符串列表，忽略空项
+func getEnvList(key string) []string {
+	var list []string
+	for _, part := range strings.Split(os.Getenv(key), ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			list = append(list, part)
+		}
+	}
+	return list
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
@@ -32,3 +32,653 @@ INSERT OR IGNORE INTO teams (id, name, code, color, accent) VALUES
 (5, '马刺', 'SAS', '#C4CED4', '#000000'),
 (6, '雷霆', 'OKC', '#007AC1', '#EF3B24');
 
+
+-- ========== NBA 本地数据仓库（由 warehouse 定时同步） ==========
+
+-- NBA 球队
+CREATE TABLE IF NOT EXISTS nba_teams (
+    id INTEGER PRIMARY KEY,
+    conference TEXT NOT NULL,
+    division TEXT NOT NULL,
+    city TEXT NOT NULL,
+    name TEXT NOT NULL,
+    full_name TEXT NOT NULL,
+    full_name_zh TEXT NOT NULL DEFAULT '',
+    abbreviation TEXT NOT NULL,
+    logo_url TEXT NOT NULL DEFAULT '',
+    bg_color TEXT NOT NULL DEFAULT '',
+    synced_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- NBA 球员
+CREATE TABLE IF NOT EXISTS nba_players (
+    id INTEGER PRIMARY KEY,
+    first_name TEXT NOT NULL,
+    last_name TEXT NOT NULL,
+    position TEXT NOT NULL DEFAULT '',
+    height TEXT NOT NULL DEFAULT '',
+    weight TEXT NOT NULL DEFAULT '',
+    jersey_number TEXT NOT NULL DEFAULT '',
+    college TEXT NOT NULL DEFAULT '',
+    country TEXT NOT NULL DEFAULT '',
+    draft_year INTEGER NOT NULL DEFAULT 0,
+    draft_round INTEGER NOT NULL DEFAULT 0,
+    draft_number INTEGER NOT NULL DEFAULT 0,
+    team_id INTEGER NOT NULL DEFAULT 0,
+    synced_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_nba_players_team ON nba_players(team_id);
+
+-- NBA 比赛
+CREATE TABLE IF NOT EXISTS nba_games (
+    id INTEGER PRIMARY KEY,
+    date TEXT NOT NULL,
+    datetime TEXT NOT NULL DEFAULT '',
+    season INTEGER NOT NULL,
+    status TEXT NOT NULL,
+    period INTEGER NOT NULL DEFAULT 0,
+    time TEXT NOT NULL DEFAULT '',
+    postseason INTEGER NOT NULL DEFAULT 0,
+    home_team_id INTEGER NOT NULL,
+    home_team_score INTEGER NOT NULL DEFAULT 0,
+    visitor_team_id INTEGER NOT NULL,
+    visitor_team_score INTEGER NOT NULL DEFAULT 0,
+    synced_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_nba_games_season_date ON nba_games(season, date);
+CREATE INDEX IF NOT EXISTS idx_nba_games_home ON nba_games(home_team_id, season);
+CREATE INDEX IF NOT EXISTS idx_nba_games_visitor ON nba_games(visitor_team_id, season);
+
+-- NBA 球员单场数据
+CREATE TABLE IF NOT EXISTS nba_player_stats (
+    id INTEGER PRIMARY KEY,
+    game_id INTEGER NOT NULL,
+    player_id INTEGER NOT NULL,
+    team_id INTEGER NOT NULL,
+    season INTEGER NOT NULL,
+    min TEXT NOT NULL DEFAULT '',
+    fgm INTEGER NOT NULL DEFAULT 0,
+    fga INTEGER NOT NULL DEFAULT 0,
+    fg3m INTEGER NOT NULL DEFAULT 0,
+    fg3a INTEGER NOT NULL DEFAULT 0,
+    ftm INTEGER NOT NULL DEFAULT 0,
+    fta INTEGER NOT NULL DEFAULT 0,
+    oreb INTEGER NOT NULL DEFAULT 0,
+    dreb INTEGER NOT NULL DEFAULT 0,
+    reb INTEGER NOT NULL DEFAULT 0,
+    ast INTEGER NOT NULL DEFAULT 0,
+    stl INTEGER NOT NULL DEFAULT 0,
+    blk INTEGER NOT NULL DEFAULT 0,
+    turnover INTEGER NOT NULL DEFAULT 0,
+    pf INTEGER NOT NULL DEFAULT 0,
+    pts INTEGER NOT NULL DEFAULT 0,
+    synced_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_nba_player_stats_player ON nba_player_stats(player_id, season);
+CREATE INDEX IF NOT EXISTS idx_nba_player_stats_game ON nba_player_stats(game_id);
+
+-- 同步检查点（resource 如 teams / players / games:2024 / stats:2024）
+CREATE TABLE IF NOT EXISTS nba_sync_checkpoints (
+    resource TEXT PRIMARY KEY,
+    cursor INTEGER NOT NULL DEFAULT 0,     -- 分页同步进行中的游标，0 表示从头开始
+    last_date TEXT NOT NULL DEFAULT '',    -- 增量同步已覆盖到的日期
+    last_synced_at DATETIME,               -- 最近一次完整同步完成时间
+    last_error TEXT NOT NULL DEFAULT '',
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 日历订阅 Token（每个用户一个，可重置）
+CREATE TABLE IF NOT EXISTS calendar_tokens (
+    user_id INTEGER PRIMARY KEY,
+    token TEXT UNIQUE NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+-- 关注的 NBA 球员/球队（target_id 为 balldontlie ID）
+CREATE TABLE IF NOT EXISTS nba_follows (
+    user_id INTEGER NOT NULL,
+    target_type TEXT NOT NULL CHECK (target_type IN ('player', 'team')),
+    target_id INTEGER NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, target_type, target_id),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_nba_follows_target ON nba_follows(target_type, target_id);
+
+
+-- ========== Fantasy 篮球 ==========
+
+-- 联赛（scoring / roster_slots 为 JSON 配置）
+CREATE TABLE IF NOT EXISTS fantasy_leagues (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    owner_id INTEGER NOT NULL,
+    season INTEGER NOT NULL,
+    scoring_type TEXT NOT NULL CHECK (scoring_type IN ('points', 'category')),
+    scoring TEXT NOT NULL,
+    roster_slots TEXT NOT NULL,
+    max_teams INTEGER NOT NULL,
+    start_date TEXT NOT NULL,              -- 第一周的周一
+    weeks INTEGER NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'active', 'finished')),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (owner_id) REFERENCES users(id)
+);
+
+-- 联赛中的球队（每个用户在一个联赛中一支），战绩由计分任务根据已结束的对阵汇总
+CREATE TABLE IF NOT EXISTS fantasy_teams (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    league_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    wins INTEGER NOT NULL DEFAULT 0,
+    losses INTEGER NOT NULL DEFAULT 0,
+    ties INTEGER NOT NULL DEFAULT 0,
+    points_for REAL NOT NULL DEFAULT 0,
+    points_against REAL NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (league_id, user_id),
+    FOREIGN KEY (league_id) REFERENCES fantasy_leagues(id),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+-- 联赛邀请
+CREATE TABLE IF NOT EXISTS fantasy_invitations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    league_id INTEGER NOT NULL,
+    inviter_id INTEGER NOT NULL,
+    invitee_id INTEGER NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'declined')),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    responded_at DATETIME,
+    FOREIGN KEY (league_id) REFERENCES fantasy_leagues(id),
+    FOREIGN KEY (inviter_id) REFERENCES users(id),
+    FOREIGN KEY (invitee_id) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_fantasy_invitations_invitee ON fantasy_invitations(invitee_id, status);
+
+-- 阵容（player_id 为 balldontlie ID，同一联赛中一名球员只能属于一支球队）
+CREATE TABLE IF NOT EXISTS fantasy_rosters (
+    league_id INTEGER NOT NULL,
+    team_id INTEGER NOT NULL,
+    player_id INTEGER NOT NULL,
+    slot TEXT NOT NULL,
+    acquired_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (league_id, player_id),
+    FOREIGN KEY (league_id) REFERENCES fantasy_leagues(id),
+    FOREIGN KEY (team_id) REFERENCES fantasy_teams(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_fantasy_rosters_team ON fantasy_rosters(team_id);
+
+-- 阵容位置记录（球员在某队某位置的一段时间，ended_at 为空表示仍在该位置；计分按开赛时所在位置判断）
+CREATE TABLE IF NOT EXISTS fantasy_lineup_stints (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    league_id INTEGER NOT NULL,
+    team_id INTEGER NOT NULL,
+    player_id INTEGER NOT NULL,
+    slot TEXT NOT NULL,
+    started_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ended_at DATETIME,
+    FOREIGN KEY (league_id) REFERENCES fantasy_leagues(id),
+    FOREIGN KEY (team_id) REFERENCES fantasy_teams(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_fantasy_lineup_stints_team ON fantasy_lineup_stints(team_id, ended_at);
+
+-- 为建表前已在阵容中的球员补一段从签入时开始的记录
+INSERT INTO fantasy_lineup_stints (league_id, team_id, player_id, slot, started_at)
+SELECT r.league_id, r.team_id, r.player_id, r.slot, COALESCE(r.acquired_at, CURRENT_TIMESTAMP)
+FROM fantasy_rosters r
+WHERE NOT EXISTS (
+    SELECT 1 FROM fantasy_lineup_stints s
+    WHERE s.team_id = r.team_id AND s.player_id = r.player_id AND s.ended_at IS NULL
+);
+
+-- 每周对阵（details 为各类别/球员得分明细 JSON）
+CREATE TABLE IF NOT EXISTS fantasy_matchups (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    league_id INTEGER NOT NULL,
+    week INTEGER NOT NULL,
+    start_date TEXT NOT NULL,
+    end_date TEXT NOT NULL,
+    home_team_id INTEGER NOT NULL,
+    away_team_id INTEGER NOT NULL,
+    home_score REAL NOT NULL DEFAULT 0,
+    away_score REAL NOT NULL DEFAULT 0,
+    winner_team_id INTEGER,                -- 平局或未结束时为 NULL
+    status TEXT NOT NULL DEFAULT 'scheduled' CHECK (status IN ('scheduled', 'live', 'final')),
+    details TEXT NOT NULL DEFAULT '{}',
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (league_id) REFERENCES fantasy_leagues(id),
+    FOREIGN KEY (home_team_id) REFERENCES fantasy_teams(id),
+    FOREIGN KEY (away_team_id) REFERENCES fantasy_teams(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_fantasy_matchups_league ON fantasy_matchups(league_id, week);
+
+
+-- ========== 竞猜 ==========
+
+-- 竞猜盘口（挂在一场 NBA 比赛上，下注托管在 ledger 的 market:{id} 账户，开赛时封盘；winner 盘口 player_id 为 0）
+CREATE TABLE IF NOT EXISTS prediction_markets (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    game_id INTEGER NOT NULL,              -- balldontlie 比赛 ID
+    game_date TEXT NOT NULL,
+    type TEXT NOT NULL CHECK (type IN ('winner', 'player_points')),
+    player_id INTEGER NOT NULL DEFAULT 0,
+    title TEXT NOT NULL,
+    lock_at DATETIME NOT NULL,             -- 开赛时间，之后不能再下注
+    status TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'locked', 'settled', 'cancelled')),
+    winning_option_id INTEGER,
+    created_by INTEGER,                    -- 自动生成的盘口为 NULL
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    settled_at DATETIME,
+    UNIQUE (game_id, type, player_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_prediction_markets_status ON prediction_markets(status, lock_at);
+CREATE INDEX IF NOT EXISTS idx_prediction_markets_date ON prediction_markets(game_date);
+
+-- 盘口选项（winner 为两支球队，player_points 为得分区间，max_points 为 NULL 表示不封顶）
+CREATE TABLE IF NOT EXISTS prediction_options (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    market_id INTEGER NOT NULL,
+    label TEXT NOT NULL,
+    team_id INTEGER,
+    min_points INTEGER,
+    max_points INTEGER,
+    pool INTEGER NOT NULL DEFAULT 0,       -- 该选项的下注总额
+    FOREIGN KEY (market_id) REFERENCES prediction_markets(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_prediction_options_market ON prediction_options(market_id);
+
+-- 下注记录（payout 在结算后写入，退款时等于下注额）
+CREATE TABLE IF NOT EXISTS prediction_stakes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    market_id INTEGER NOT NULL,
+    option_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    amount INTEGER NOT NULL CHECK (amount > 0),
+    payout INTEGER,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (market_id) REFERENCES prediction_markets(id),
+    FOREIGN KEY (option_id) REFERENCES prediction_options(id),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_prediction_stakes_market ON prediction_stakes(market_id);
+CREATE INDEX IF NOT EXISTS idx_prediction_stakes_user ON prediction_stakes(user_id, id);
+
+-- 带 Idempotency-Key 的下注，用于识别客户端重试和键被复用于不同的下注
+CREATE TABLE IF NOT EXISTS prediction_stake_keys (
+    user_id INTEGER NOT NULL,
+    idempotency_key TEXT NOT NULL,
+    stake_id INTEGER NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, idempotency_key),
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    FOREIGN KEY (stake_id) REFERENCES prediction_stakes(id)
+);
+
+
+-- ========== 金币账本（复式记账，只追加不修改） ==========
+
+-- 账户（user:{id} 用户钱包，market:{id} 竞猜盘口托管，system:issuance 发行账户）
+-- 只有发行账户允许为负，其余额的相反数即流通中的金币总量
+CREATE TABLE IF NOT EXISTS ledger_accounts (
+    code TEXT PRIMARY KEY,
+    type TEXT NOT NULL CHECK (type IN ('user', 'escrow', 'system')),
+    user_id INTEGER,
+    allow_negative INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+-- 记账凭证（idempotency_key 保证同一业务操作只记一次）
+CREATE TABLE IF NOT EXISTS ledger_transactions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    idempotency_key TEXT UNIQUE NOT NULL,
+    kind TEXT NOT NULL CHECK (kind IN ('grant', 'daily_bonus', 'wager', 'payout', 'refund', 'adjustment')),
+    memo TEXT NOT NULL DEFAULT '',
+    market_id INTEGER,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 分录（同一凭证的分录金额之和为 0；余额 = 账户所有分录之和）
+CREATE TABLE IF NOT EXISTS ledger_entries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    transaction_id INTEGER NOT NULL,
+    account TEXT NOT NULL,
+    amount INTEGER NOT NULL CHECK (amount != 0),
+    FOREIGN KEY (transaction_id) REFERENCES ledger_transactions(id),
+    FOREIGN KEY (account) REFERENCES ledger_accounts(code)
+);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_account ON ledger_entries(account, id);
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_transaction ON ledger_entries(transaction_id);
+
+-- 凭证和分录一经写入不能修改或删除，更正需要另记一笔调整
+CREATE TRIGGER IF NOT EXISTS ledger_transactions_no_update BEFORE UPDATE ON ledger_transactions
+BEGIN
+    SELECT RAISE(ABORT, 'ledger transactions are immutable');
+END;
+
+CREATE TRIGGER IF NOT EXISTS ledger_transactions_no_delete BEFORE DELETE ON ledger_transactions
+BEGIN
+    SELECT RAISE(ABORT, 'ledger transactions are immutable');
+END;
+
+CREATE TRIGGER IF NOT EXISTS ledger_entries_no_update BEFORE UPDATE ON ledger_entries
+BEGIN
+    SELECT RAISE(ABORT, 'ledger entries are immutable');
+END;
+
+CREATE TRIGGER IF NOT EXISTS ledger_entries_no_delete BEFORE DELETE ON ledger_entries
+BEGIN
+    SELECT RAISE(ABORT, 'ledger entries are immutable');
+END;
+
+
+-- ========== 约球：球场 ==========
+
+-- 球场（geohash 为 9 位编码，附近搜索按 geohash 前缀范围扫描索引后再精确过滤）
+CREATE TABLE IF NOT EXISTS courts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    address TEXT NOT NULL DEFAULT '',
+    latitude REAL NOT NULL,
+    longitude REAL NOT NULL,
+    geohash TEXT NOT NULL,
+    surface TEXT NOT NULL CHECK (surface IN ('wood', 'acrylic', 'rubber', 'concrete', 'asphalt', 'other')),
+    indoor INTEGER NOT NULL DEFAULT 0,
+    free INTEGER NOT NULL DEFAULT 1,
+    price TEXT NOT NULL DEFAULT '',        -- 收费说明，如 "80元/小时"
+    opening_hours TEXT,                    -- JSON：{"mon": [{"open": "06:00", "close": "22:00"}], ...}，NULL 表示未知
+    created_by INTEGER NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (created_by) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_courts_geohash ON courts(geohash);
+
+-- 球场照片（按 position 排序）
+CREATE TABLE IF NOT EXISTS court_photos (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    court_id INTEGER NOT NULL,
+    url TEXT NOT NULL,
+    position INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (court_id) REFERENCES courts(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_court_photos_court ON court_photos(court_id, position);
+
+
+-- ========== 约球：活动 ==========
+
+-- 约球活动（时间均为 UTC）
+CREATE TABLE IF NOT EXISTS pickup_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    court_id INTEGER NOT NULL,
+    organizer_id INTEGER NOT NULL,
+    title TEXT NOT NULL,
+    starts_at DATETIME NOT NULL,
+    ends_at DATETIME NOT NULL,
+    skill_level TEXT NOT NULL CHECK (skill_level IN ('any', 'beginner', 'intermediate', 'advanced')),
+    format TEXT NOT NULL CHECK (format IN ('3v3', '5v5')),
+    capacity INTEGER NOT NULL,
+    note TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'scheduled' CHECK (status IN ('scheduled', 'cancelled')),
+    cancel_reason TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (court_id) REFERENCES courts(id),
+    FOREIGN KEY (organizer_id) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_pickup_events_court ON pickup_events(court_id, starts_at);
+CREATE INDEX IF NOT EXISTS idx_pickup_events_starts ON pickup_events(starts_at);
+
+-- 报名（退出即删除记录；候补按 id 顺序递补）
+CREATE TABLE IF NOT EXISTS pickup_rsvps (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('going', 'waitlist')),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (event_id, user_id),
+    FOREIGN KEY (event_id) REFERENCES pickup_events(id),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_pickup_rsvps_user ON pickup_rsvps(user_id);
+
+
+-- ========== 约球：球场评价 ==========
+
+-- 球场评价（每人每个球场一条未下架的评价；被管理员下架后不再计入评分，作者可以重新评价）
+CREATE TABLE IF NOT EXISTS court_reviews (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    court_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    rating INTEGER NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    content TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'visible' CHECK (status IN ('visible', 'removed')),
+    helpful_count INTEGER NOT NULL DEFAULT 0,
+    removed_by INTEGER,
+    removed_reason TEXT NOT NULL DEFAULT '',
+    removed_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (court_id) REFERENCES courts(id),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_court_reviews_court ON court_reviews(court_id, status);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_court_reviews_visible_author ON court_reviews(court_id, user_id) WHERE status = 'visible';
+
+-- 评价照片
+CREATE TABLE IF NOT EXISTS court_review_photos (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    review_id INTEGER NOT NULL,
+    url TEXT NOT NULL,
+    position INTEGER NOT NULL DEFAULT 0,
+    FOREIGN KEY (review_id) REFERENCES court_reviews(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_court_review_photos_review ON court_review_photos(review_id, position);
+
+-- “有用”投票
+CREATE TABLE IF NOT EXISTS court_review_votes (
+    review_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (review_id, user_id),
+    FOREIGN KEY (review_id) REFERENCES court_reviews(id),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+-- 球场评分汇总（随评价增删改增量维护，平均分 = rating_total / review_count）
+CREATE TABLE IF NOT EXISTS court_rating_stats (
+    court_id INTEGER PRIMARY KEY,
+    review_count INTEGER NOT NULL DEFAULT 0,
+    rating_total INTEGER NOT NULL DEFAULT 0,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (court_id) REFERENCES courts(id)
+);
+
+
+-- ========== 社交：关注和拉黑 ==========
+
+-- 用户之间的关注（与 nba_follows 的球员/球队关注无关）
+CREATE TABLE IF NOT EXISTS user_follows (
+    follower_id INTEGER NOT NULL,
+    followee_id INTEGER NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (follower_id, followee_id),
+    CHECK (follower_id != followee_id),
+    FOREIGN KEY (follower_id) REFERENCES users(id),
+    FOREIGN KEY (followee_id) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_follows_followee ON user_follows(followee_id, created_at);
+
+-- 拉黑（拉黑时双方的关注关系一并解除）
+CREATE TABLE IF NOT EXISTS user_blocks (
+    blocker_id INTEGER NOT NULL,
+    blocked_id INTEGER NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (blocker_id, blocked_id),
+    CHECK (blocker_id != blocked_id),
+    FOREIGN KEY (blocker_id) REFERENCES users(id),
+    FOREIGN KEY (blocked_id) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_blocks_blocked ON user_blocks(blocked_id);
+
+
+-- ========== 社交：主页隐私设置 ==========
+
+-- 主页隐私设置（没有记录时全部公开）
+CREATE TABLE IF NOT EXISTS user_privacy (
+    user_id INTEGER PRIMARY KEY,
+    show_team INTEGER NOT NULL DEFAULT 1,
+    show_join_date INTEGER NOT NULL DEFAULT 1,
+    show_counts INTEGER NOT NULL DEFAULT 1,        -- 同时控制粉丝/关注列表是否公开
+    show_achievements INTEGER NOT NULL DEFAULT 1,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+
+-- ========== 社区：帖子和评论 ==========
+
+-- 帖子（deleted_at 非空为已删除，保留记录以便复核）
+CREATE TABLE IF NOT EXISTS posts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    content TEXT NOT NULL DEFAULT '',
+    like_count INTEGER NOT NULL DEFAULT 0,
+    comment_count INTEGER NOT NULL DEFAULT 0,
+    deleted_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_posts_user ON posts(user_id, id);
+
+-- 帖子图片
+CREATE TABLE IF NOT EXISTS post_images (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    post_id INTEGER NOT NULL,
+    url TEXT NOT NULL,
+    position INTEGER NOT NULL DEFAULT 0,
+    FOREIGN KEY (post_id) REFERENCES posts(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_post_images_post ON post_images(post_id, position);
+
+-- 帖子标签（target_id 为 balldontlie 的球队/球员/比赛 ID）
+CREATE TABLE IF NOT EXISTS post_tags (
+    post_id INTEGER NOT NULL,
+    tag_type TEXT NOT NULL CHECK (tag_type IN ('team', 'player', 'game')),
+    target_id INTEGER NOT NULL,
+    PRIMARY KEY (post_id, tag_type, target_id),
+    FOREIGN KEY (post_id) REFERENCES posts(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_post_tags_target ON post_tags(tag_type, target_id, post_id);
+
+-- 评论（root_id 为所属的一级评论，一级评论的 parent_id / root_id 为空）
+CREATE TABLE IF NOT EXISTS post_comments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    post_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    parent_id INTEGER,
+    root_id INTEGER,
+    content TEXT NOT NULL,
+    deleted_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (post_id) REFERENCES posts(id),
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    FOREIGN KEY (parent_id) REFERENCES post_comments(id),
+    FOREIGN KEY (root_id) REFERENCES post_comments(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_post_comments_post ON post_comments(post_id, root_id, id);
+
+-- 点赞
+CREATE TABLE IF NOT EXISTS post_likes (
+    post_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (post_id, user_id),
+    FOREIGN KEY (post_id) REFERENCES posts(id),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+
+-- ========== 社区：个性化推荐流 ==========
+
+-- 预计算的推荐流（每次重算整体替换；帖子展示时实时读取，payload 为比赛结果、球员表现的快照 JSON）
+CREATE TABLE IF NOT EXISTS feed_timelines (
+    user_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    kind TEXT NOT NULL CHECK (kind IN ('post', 'game_result', 'player_performance')),
+    ref_id INTEGER NOT NULL,
+    score REAL NOT NULL,
+    occurred_at DATETIME NOT NULL,
+    payload TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (user_id, position),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+-- 推荐流状态（requested_at 用于判断活跃用户，后台只为活跃用户定时重算）
+CREATE TABLE IF NOT EXISTS feed_timeline_state (
+    user_id INTEGER PRIMARY KEY,
+    computed_at DATETIME,
+    requested_at DATETIME,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+
+-- ========== 消息通知 ==========
+
+-- 站内通知（dedupe_key 非空时同一用户相同 key 只通知一次，如开赛提醒）
+CREATE TABLE IF NOT EXISTS notifications (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    type TEXT NOT NULL,
+    actor_id INTEGER,
+    title TEXT NOT NULL,
+    body TEXT NOT NULL DEFAULT '',
+    data TEXT NOT NULL DEFAULT '{}',
+    dedupe_key TEXT,
+    read_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, dedupe_key),
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    FOREIGN KEY (actor_id) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_user ON notifications(user_id, id);
+CREATE INDEX IF NOT EXISTS idx_notifications_unread ON notifications(user_id, read_at);
+
+-- 通知偏好（没有记录的类型默认开启）
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id INTEGER NOT NULL,
+    type TEXT NOT NULL,
+    enabled INTEGER NOT NULL,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, type),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
@@ -0,0 +1,90 @@
+package external
+
+import (
+	"sync"
+	"time"
+)
+
+// breakerState 熔断器状态
+type breakerState int
+
+const (
+	breakerClosed   breakerState = iota // 正常放行
+	breakerOpen                         // 熔断中，直接拒绝
+	breakerHalfOpen                     // 冷却结束，放行一个探测请求
+)
+
+// circuitBreaker 连续失败达到阈值后熔断，冷却后通过单个探测请求决定是否恢复
+type circuitBreaker struct {
+	mu        sync.Mutex
+	state     breakerState
+	failures  int
+	threshold int
+	cooldown  time.Duration
+	openedAt  time.Time
+	probing   bool
+}
+
+// newCircuitBreaker 创建熔断器
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+	}
+}
+
+// Allow 当前是否允许发起请求
+func (b *circuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = breakerHalfOpen
+		b.probing = true
+		return true
+	case breakerHalfOpen:
+		// 探测请求未返回前，其余请求继续拒绝
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// Success 记录一次成功
+func (b *circuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = breakerClosed
+	b.failures = 0
+	b.probing = false
+}
+
+// Failure 记录一次失败
+func (b *circuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= b.threshold {
+		b.state = breakerOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// Release 请求被调用方取消，不计入成败，只释放探测名额
+func (b *circuitBreaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
@@ -0,0 +1,25 @@
+package external
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrCircuitOpen 熔断器打开，暂停访问上游
+var ErrCircuitOpen = errors.New("balldontlie circuit breaker is open")
+
+// APIError 上游返回的非 200 响应
+type APIError struct {
+	StatusCode int
+	RetryAfter time.Duration // 仅 429 时有值
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API returned status code: %d", e.StatusCode)
+}
+
+// retryable 是否值得重试（5xx 和 429）
+func (e *APIError) retryable() bool {
+	return e.StatusCode >= 500 || e.StatusCode == 429
+}
@@ -0,0 +1,176 @@
+package external
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 上游访问模式
+const (
+	ModeLive   = "live"   // 直接请求 balldontlie
+	ModeRecord = "record" // 请求 balldontlie 并把响应录制到 fixtures 目录
+	ModeReplay = "replay" // 完全从 fixtures 目录回放，不访问网络
+)
+
+// ErrFixtureNotFound 回放模式下没有该请求的录制数据
+var ErrFixtureNotFound = errors.New("no recorded fixture for request")
+
+// ValidMode 是否为支持的访问模式
+func ValidMode(mode string) bool {
+	return mode == ModeLive || mode == ModeRecord || mode == ModeReplay
+}
+
+// Fixture 一条录制的上游响应
+type Fixture struct {
+	Endpoint   string          `json:"endpoint"`
+	StatusCode int             `json:"status_code"`
+	RecordedAt time.Time       `json:"recorded_at"`
+	Body       json.RawMessage `json:"body,omitempty"`
+}
+
+// FixtureStore fixtures 目录，每个 endpoint 一个 JSON 文件
+type FixtureStore struct {
+	Dir string
+}
+
+// NewFixtureStore 创建 fixtures 目录
+func NewFixtureStore(dir string) *FixtureStore {
+	return &FixtureStore{Dir: dir}
+}
+
+// path endpoint 对应的文件路径
+// 文件名取 URL 路径（便于人工查找），带查询参数时追加参数的短哈希
+func (s *FixtureStore) path(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		u = &url.URL{Path: endpoint}
+	}
+	name := strings.ReplaceAll(strings.Trim(u.Path, "/"), "/", "_")
+	if u.RawQuery != "" {
+		sum := sha1.Sum([]byte(u.RawQuery))
+		name += "__" + hex.EncodeToString(sum[:])[:12]
+	}
+	return filepath.Join(s.Dir, name+".json")
+}
+
+// Load 读取录制的响应；录制时上游返回非 200 的，回放时返回同样的 APIError
+func (s *FixtureStore) Load(endpoint string) ([]byte, error) {
+	fixture, err := readFixture(s.path(endpoint))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", ErrFixtureNotFound, endpoint)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if fixture.StatusCode != http.StatusOK {
+		return nil, &APIError{StatusCode: fixture.StatusCode}
+	}
+	return fixture.Body, nil
+}
+
+// Save 录制一条响应（先写临时文件再重命名，避免并发读到半个文件）
+func (s *FixtureStore) Save(endpoint string, statusCode int, body []byte) error {
+	fixture := Fixture{
+		Endpoint:   endpoint,
+		StatusCode: statusCode,
+		RecordedAt: time.Now().UTC(),
+	}
+	if statusCode == http.StatusOK {
+		if !json.Valid(body) {
+			return fmt.Errorf("response for %s is not valid JSON", endpoint)
+		}
+		fixture.Body = body
+	}
+
+	data, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.Dir, 0755); err != nil {
+		return err
+	}
+
+	path := s.path(endpoint)
+	tmp, err := os.CreateTemp(s.Dir, ".fixture-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// FixtureFile 目录中的一个录制文件
+type FixtureFile struct {
+	Path    string
+	Fixture *Fixture // 文件损坏时为 nil
+	Err     error
+}
+
+// List 列出目录中的所有录制文件（按 endpoint 排序），目录不存在时返回空
+func (s *FixtureStore) List() ([]FixtureFile, error) {
+	paths, err := filepath.Glob(filepath.Join(s.Dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]FixtureFile, 0, len(paths))
+	for _, path := range paths {
+		fixture, err := readFixture(path)
+		if err == nil && fixture.Endpoint == "" {
+			err = errors.New("missing endpoint")
+		}
+		if err != nil {
+			files = append(files, FixtureFile{Path: path, Err: err})
+			continue
+		}
+		files = append(files, FixtureFile{Path: path, Fixture: fixture})
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return fixtureSortKey(files[i]) < fixtureSortKey(files[j])
+	})
+	return files, nil
+}
+
+func fixtureSortKey(f FixtureFile) string {
+	if f.Fixture != nil {
+		return f.Fixture.Endpoint
+	}
+	return f.Path
+}
+
+func readFixture(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, err
+	}
+	return &fixture, nil
+}
+
+// recordable 是否录制该状态码的响应
+// 404 也录制，回放时"球员不存在"之类的结果与线上一致；5xx/429 是临时状态，不录制
+func recordable(statusCode int) bool {
+	return statusCode == http.StatusOK || statusCode == http.StatusNotFound
+}
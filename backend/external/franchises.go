@@ -0,0 +1,154 @@
+package external
+
+// FranchiseEra 球队在某一段时间内使用的城市和队名
+type FranchiseEra struct {
+	City         string `json:"city"`
+	Name         string `json:"name"`
+	FullName     string `json:"full_name"`
+	Abbreviation string `json:"abbreviation"`
+	FromSeason   int    `json:"from_season"`
+	ToSeason     int    `json:"to_season,omitempty"` // 0 表示沿用至今
+}
+
+func era(city, name, abbreviation string, from, to int) FranchiseEra {
+	return FranchiseEra{
+		City:         city,
+		Name:         name,
+		FullName:     city + " " + name,
+		Abbreviation: abbreviation,
+		FromSeason:   from,
+		ToSeason:     to,
+	}
+}
+
+// franchiseHistory 现役球队（按当前缩写）的搬迁和改名历史，只记录加入 NBA/BAA 之后的赛季
+// balldontlie 把搬迁前的比赛也记在现役球队名下，查询历史赛季时用这里的名称展示
+// 夏洛特黄蜂 1988-2001 的队史按 NBA 官方口径归属现在的黄蜂，而不是鹈鹕
+var franchiseHistory = map[string][]FranchiseEra{
+	"ATL": {
+		era("Tri-Cities", "Blackhawks", "TRI", 1949, 1950),
+		era("Milwaukee", "Hawks", "MLH", 1951, 1954),
+		era("St. Louis", "Hawks", "STL", 1955, 1967),
+		era("Atlanta", "Hawks", "ATL", 1968, 0),
+	},
+	"BOS": {era("Boston", "Celtics", "BOS", 1946, 0)},
+	"BKN": {
+		era("New York", "Nets", "NYN", 1976, 1976),
+		era("New Jersey", "Nets", "NJN", 1977, 2011),
+		era("Brooklyn", "Nets", "BKN", 2012, 0),
+	},
+	"CHA": {
+		era("Charlotte", "Hornets", "CHH", 1988, 2001),
+		era("Charlotte", "Bobcats", "CHA", 2004, 2013),
+		era("Charlotte", "Hornets", "CHA", 2014, 0),
+	},
+	"CHI": {era("Chicago", "Bulls", "CHI", 1966, 0)},
+	"CLE": {era("Cleveland", "Cavaliers", "CLE", 1970, 0)},
+	"DAL": {era("Dallas", "Mavericks", "DAL", 1980, 0)},
+	"DEN": {era("Denver", "Nuggets", "DEN", 1976, 0)},
+	"DET": {
+		era("Fort Wayne", "Pistons", "FTW", 1948, 1956),
+		era("Detroit", "Pistons", "DET", 1957, 0),
+	},
+	"GSW": {
+		era("Philadelphia", "Warriors", "PHW", 1946, 1961),
+		era("San Francisco", "Warriors", "SFW", 1962, 1970),
+		era("Golden State", "Warriors", "GSW", 1971, 0),
+	},
+	"HOU": {
+		era("San Diego", "Rockets", "SDR", 1967, 1970),
+		era("Houston", "Rockets", "HOU", 1971, 0),
+	},
+	"IND": {era("Indiana", "Pacers", "IND", 1976, 0)},
+	"LAC": {
+		era("Buffalo", "Braves", "BUF", 1970, 1977),
+		era("San Diego", "Clippers", "SDC", 1978, 1983),
+		era("Los Angeles", "Clippers", "LAC", 1984, 2014),
+		era("LA", "Clippers", "LAC", 2015, 0),
+	},
+	"LAL": {
+		era("Minneapolis", "Lakers", "MNL", 1948, 1959),
+		era("Los Angeles", "Lakers", "LAL", 1960, 0),
+	},
+	"MEM": {
+		era("Vancouver", "Grizzlies", "VAN", 1995, 2000),
+		era("Memphis", "Grizzlies", "MEM", 2001, 0),
+	},
+	"MIA": {era("Miami", "Heat", "MIA", 1988, 0)},
+	"MIL": {era("Milwaukee", "Bucks", "MIL", 1968, 0)},
+	"MIN": {era("Minnesota", "Timberwolves", "MIN", 1989, 0)},
+	"NOP": {
+		era("New Orleans", "Hornets", "NOH", 2002, 2004),
+		era("New Orleans/Oklahoma City", "Hornets", "NOK", 2005, 2006),
+		era("New Orleans", "Hornets", "NOH", 2007, 2012),
+		era("New Orleans", "Pelicans", "NOP", 2013, 0),
+	},
+	"NYK": {era("New York", "Knicks", "NYK", 1946, 0)},
+	"OKC": {
+		era("Seattle", "SuperSonics", "SEA", 1967, 2007),
+		era("Oklahoma City", "Thunder", "OKC", 2008, 0),
+	},
+	"ORL": {era("Orlando", "Magic", "ORL", 1989, 0)},
+	"PHI": {
+		era("Syracuse", "Nationals", "SYR", 1949, 1962),
+		era("Philadelphia", "76ers", "PHI", 1963, 0),
+	},
+	"PHX": {era("Phoenix", "Suns", "PHX", 1968, 0)},
+	"POR": {era("Portland", "Trail Blazers", "POR", 1970, 0)},
+	"SAC": {
+		era("Rochester", "Royals", "ROC", 1948, 1956),
+		era("Cincinnati", "Royals", "CIN", 1957, 1971),
+		era("Kansas City-Omaha", "Kings", "KCO", 1972, 1974),
+		era("Kansas City", "Kings", "KCK", 1975, 1984),
+		era("Sacramento", "Kings", "SAC", 1985, 0),
+	},
+	"SAS": {era("San Antonio", "Spurs", "SAS", 1976, 0)},
+	"TOR": {era("Toronto", "Raptors", "TOR", 1995, 0)},
+	"UTA": {
+		era("New Orleans", "Jazz", "NOJ", 1974, 1978),
+		era("Utah", "Jazz", "UTA", 1979, 0),
+	},
+	"WAS": {
+		era("Chicago", "Packers", "CHP", 1961, 1961),
+		era("Chicago", "Zephyrs", "CHZ", 1962, 1962),
+		era("Baltimore", "Bullets", "BAL", 1963, 1972),
+		era("Capital", "Bullets", "CAP", 1973, 1973),
+		era("Washington", "Bullets", "WSB", 1974, 1996),
+		era("Washington", "Wizards", "WAS", 1997, 0),
+	},
+}
+
+// FranchiseHistory 现役球队的历史名称（按时间先后），未知球队返回 nil
+func FranchiseHistory(abbreviation string) []FranchiseEra {
+	eras := franchiseHistory[abbreviation]
+	if eras == nil {
+		return nil
+	}
+	return append([]FranchiseEra(nil), eras...)
+}
+
+// TeamInSeason 返回球队在某赛季使用的城市和队名（中文名、队标、配色保持现役数据）
+// 第二个返回值表示该赛季球队是否存在（尚未加入联盟或当季停赛，如 2002-03 赛季的夏洛特）
+// 历史球队和没有收录历史的球队原样返回
+func TeamInSeason(team NBATeam, season int) (NBATeam, bool) {
+	if team.Historical {
+		return team, true
+	}
+	eras := franchiseHistory[team.Abbreviation]
+	if eras == nil {
+		return team, true
+	}
+	for _, e := range eras {
+		if season < e.FromSeason || (e.ToSeason != 0 && season > e.ToSeason) {
+			continue
+		}
+		if e.ToSeason != 0 {
+			team.City = e.City
+			team.Name = e.Name
+			team.FullName = e.FullName
+			team.Abbreviation = e.Abbreviation
+		}
+		return team, true
+	}
+	return team, false
+}
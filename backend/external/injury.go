@@ -0,0 +1,105 @@
+package external
+
+import (
+	"buzzerbeater/config"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// InjuryReport 球员伤病/出场状态
+type InjuryReport struct {
+	PlayerID    int    `json:"player_id"`
+	Status      string `json:"status"`      // Out / Day-To-Day / Questionable 等
+	ReturnDate  string `json:"return_date"` // 预计复出时间（上游为自由文本，如 "Nov 17"）
+	Description string `json:"description"`
+}
+
+// InjuryProvider 伤病数据来源
+type InjuryProvider interface {
+	// Injuries 获取某队当前的伤病名单
+	Injuries(ctx context.Context, teamID int) ([]InjuryReport, error)
+}
+
+// NewInjuryProvider 按配置创建伤病数据来源（balldontlie 或 fixture）
+func NewInjuryProvider(client *NBAClient) InjuryProvider {
+	if config.AppConfig.InjuryProvider == "fixture" {
+		return &FixtureInjuryProvider{Path: config.AppConfig.InjuryFixtureFile}
+	}
+	return &BallDontLieInjuryProvider{client: client}
+}
+
+// BallDontLieInjuryProvider 从 balldontlie player_injuries 接口获取伤病
+type BallDontLieInjuryProvider struct {
+	client *NBAClient
+}
+
+// Injuries 获取某队伤病名单（自动翻页）
+func (p *BallDontLieInjuryProvider) Injuries(ctx context.Context, teamID int) ([]InjuryReport, error) {
+	var reports []InjuryReport
+	cursor := 0
+	for {
+		endpoint := fmt.Sprintf("/nba/v1/player_injuries?per_page=100&team_ids[]=%d", teamID)
+		if cursor > 0 {
+			endpoint += fmt.Sprintf("&cursor=%d", cursor)
+		}
+
+		body, _, err := p.client.doRequest(ctx, endpoint)
+		if err != nil {
+			return nil, err
+		}
+
+		var response struct {
+			Data []struct {
+				Player      NBAPlayer `json:"player"`
+				Status      string    `json:"status"`
+				ReturnDate  string    `json:"return_date"`
+				Description string    `json:"description"`
+			} `json:"data"`
+			Meta struct {
+				NextCursor int `json:"next_cursor"`
+			} `json:"meta"`
+		}
+		if err := json.Unmarshal(body, &response); err != nil {
+			return nil, err
+		}
+
+		for _, d := range response.Data {
+			reports = append(reports, InjuryReport{
+				PlayerID:    d.Player.ID,
+				Status:      d.Status,
+				ReturnDate:  d.ReturnDate,
+				Description: d.Description,
+			})
+		}
+		cursor = response.Meta.NextCursor
+		if cursor == 0 {
+			break
+		}
+	}
+	return reports, nil
+}
+
+// FixtureInjuryProvider 从本地 JSON 文件读取伤病（开发和测试用）
+// 文件格式：{"<球队ID>": [InjuryReport, ...]}
+type FixtureInjuryProvider struct {
+	Path string
+}
+
+// Injuries 读取某队伤病名单，文件不存在时视为没有伤病
+func (p *FixtureInjuryProvider) Injuries(ctx context.Context, teamID int) ([]InjuryReport, error) {
+	data, err := os.ReadFile(p.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var byTeam map[string][]InjuryReport
+	if err := json.Unmarshal(data, &byTeam); err != nil {
+		return nil, err
+	}
+	return byTeam[fmt.Sprint(teamID)], nil
+}
@@ -2,28 +2,61 @@ package external
 
 import (
 	"buzzerbeater/config"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
+	"math/rand/v2"
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 )
 
 const ballDontLieBaseURL = "https://api.balldontlie.io"
 
+// staleWhileRevalidate 缓存过期后仍可直接返回旧数据的时间窗口（期间后台刷新）
+const staleWhileRevalidate = 10 * time.Minute
+
+// 重试与熔断参数
+const (
+	maxRetries       = 3                      // 最多重试次数（不含首次请求）
+	retryBaseDelay   = 200 * time.Millisecond // 退避基准时间
+	retryMaxDelay    = 5 * time.Second        // 单次退避上限
+	maxRetryAfter    = 10 * time.Second       // 超过该时长的 Retry-After 不在服务端等待，直接返回 429
+	breakerThreshold = 5                      // 连续失败多少次后熔断
+	breakerCooldown  = 30 * time.Second       // 熔断冷却时间
+)
+
 // cacheEntry 缓存条目
 type cacheEntry struct {
 	data      []byte
 	expiresAt time.Time
 }
 
+// inflightCall 正在进行的上游请求（相同 endpoint 的并发请求共享结果）
+type inflightCall struct {
+	done    chan struct{}
+	data    []byte
+	err     error
+	waiters int                // 仍在等待结果的调用方数量，归零时取消上游请求
+	cancel  context.CancelFunc // 取消上游请求
+}
+
 // NBAClient NBA API 客户端
 type NBAClient struct {
 	httpClient *http.Client
 	apiKey     string
 	cache      map[string]*cacheEntry
 	cacheMu    sync.RWMutex
+	inflight   map[string]*inflightCall
+	inflightMu sync.Mutex
+	limiter    *rateLimiter
+	breaker    *circuitBreaker
+	mode       string        // live / record / replay
+	fixtures   *FixtureStore // record 和 replay 模式使用
 }
 
 // NewNBAClient 创建 NBA API 客户端
@@ -32,26 +65,198 @@ func NewNBAClient() *NBAClient {
 		httpClient: &http.Client{
 			Timeout: 10 * time.Second,
 		},
-		apiKey: config.AppConfig.BallDontLieAPIKey,
-		cache:  make(map[string]*cacheEntry),
+		apiKey:   config.AppConfig.BallDontLieAPIKey,
+		cache:    make(map[string]*cacheEntry),
+		inflight: make(map[string]*inflightCall),
+		limiter:  newRateLimiter(config.AppConfig.BallDontLieRateLimit),
+		breaker:  newCircuitBreaker(breakerThreshold, breakerCooldown),
+		mode:     config.AppConfig.NBAMode,
+		fixtures: NewFixtureStore(config.AppConfig.NBAFixturesDir),
 	}
 }
 
+var (
+	defaultClient     *NBAClient
+	defaultClientOnce sync.Once
+)
+
+// Default 获取全局共享的客户端（API 处理函数与数据同步共用同一份缓存、限流和熔断状态）
+func Default() *NBAClient {
+	defaultClientOnce.Do(func() {
+		defaultClient = NewNBAClient()
+	})
+	return defaultClient
+}
+
+// noCacheKey 跳过缓存的 context 标记
+type noCacheKey struct{}
+
+// WithoutCache 返回跳过内存缓存的 ctx（批量同步的分页数据只用一次，没必要常驻内存）
+func WithoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noCacheKey{}, true)
+}
+
 // doRequest 执行 HTTP 请求（带缓存）
-func (c *NBAClient) doRequest(endpoint string) ([]byte, error) {
-	// 检查缓存
+// 第二个返回值表示数据是否为过期的旧数据：
+//   - 缓存未过期：直接返回
+//   - 缓存已过期但仍在 staleWhileRevalidate 窗口内：返回旧数据并在后台刷新
+//   - 其余情况同步请求上游，上游失败时退回到旧数据（如果有）
+func (c *NBAClient) doRequest(ctx context.Context, endpoint string) ([]byte, bool, error) {
+	if ctx.Value(noCacheKey{}) != nil {
+		body, err := c.fetch(ctx, endpoint)
+		return body, false, err
+	}
+
 	c.cacheMu.RLock()
-	if entry, exists := c.cache[endpoint]; exists {
-		if time.Now().Before(entry.expiresAt) {
-			c.cacheMu.RUnlock()
-			return entry.data, nil
+	entry, exists := c.cache[endpoint]
+	c.cacheMu.RUnlock()
+
+	now := time.Now()
+	if exists {
+		if now.Before(entry.expiresAt) {
+			return entry.data, false, nil
+		}
+		if now.Before(entry.expiresAt.Add(staleWhileRevalidate)) {
+			go c.fetchShared(context.Background(), endpoint)
+			return entry.data, true, nil
 		}
 	}
-	c.cacheMu.RUnlock()
 
-	// 发起 API 请求
+	body, err := c.fetchShared(ctx, endpoint)
+	if err != nil {
+		// 调用方已取消时直接返回；否则上游异常时宁可返回旧数据也不直接报错
+		if exists && ctx.Err() == nil {
+			return entry.data, true, nil
+		}
+		return nil, false, err
+	}
+
+	return body, false, nil
+}
+
+// fetchShared 请求上游并写入缓存，同一 endpoint 同时只会有一个请求在进行
+// 上游请求不绑定某个调用方的 ctx，只有所有等待者都取消后才会被取消
+func (c *NBAClient) fetchShared(ctx context.Context, endpoint string) ([]byte, error) {
+	c.inflightMu.Lock()
+	call, ok := c.inflight[endpoint]
+	if !ok {
+		fetchCtx, cancel := context.WithCancel(context.Background())
+		call = &inflightCall{done: make(chan struct{}), cancel: cancel}
+		c.inflight[endpoint] = call
+		go c.runFetch(fetchCtx, endpoint, call)
+	}
+	call.waiters++
+	c.inflightMu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.data, call.err
+	case <-ctx.Done():
+		c.inflightMu.Lock()
+		call.waiters--
+		if call.waiters == 0 {
+			// 已取消的请求不能再被后来者复用，让下一个调用方重新发起
+			call.cancel()
+			if c.inflight[endpoint] == call {
+				delete(c.inflight, endpoint)
+			}
+		}
+		c.inflightMu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// runFetch 执行共享的上游请求并广播结果
+func (c *NBAClient) runFetch(ctx context.Context, endpoint string, call *inflightCall) {
+	call.data, call.err = c.fetch(ctx, endpoint)
+	if call.err == nil {
+		c.cacheMu.Lock()
+		c.cache[endpoint] = &cacheEntry{
+			data:      call.data,
+			expiresAt: time.Now().Add(cacheDuration(endpoint)),
+		}
+		c.cacheMu.Unlock()
+	}
+
+	c.inflightMu.Lock()
+	if c.inflight[endpoint] == call {
+		delete(c.inflight, endpoint)
+	}
+	c.inflightMu.Unlock()
+
+	call.cancel()
+	close(call.done)
+}
+
+// fetch 发起 API 请求：经过熔断和限流，5xx/超时按抖动退避重试，429 遵循 Retry-After
+func (c *NBAClient) fetch(ctx context.Context, endpoint string) ([]byte, error) {
+	// 回放模式不访问网络，也就不需要限流和熔断
+	if c.mode == ModeReplay {
+		return c.fixtures.Load(endpoint)
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if !c.breaker.Allow() {
+			return nil, ErrCircuitOpen
+		}
+		if err := c.limiter.Wait(ctx); err != nil {
+			c.breaker.Release()
+			return nil, err
+		}
+
+		body, err := c.fetchOnce(ctx, endpoint)
+		if err == nil {
+			c.breaker.Success()
+			return body, nil
+		}
+		if ctx.Err() != nil {
+			c.breaker.Release()
+			return nil, ctx.Err()
+		}
+		lastErr = err
+
+		var apiErr *APIError
+		isAPIErr := errors.As(err, &apiErr)
+		switch {
+		case isAPIErr && apiErr.StatusCode == http.StatusTooManyRequests:
+			// 限流不代表上游故障，不计入熔断
+			c.breaker.Release()
+		case isAPIErr && !apiErr.retryable():
+			// 4xx 说明上游是健康的，请求本身有问题，不重试
+			c.breaker.Success()
+			return nil, err
+		default:
+			c.breaker.Failure()
+		}
+
+		if attempt == maxRetries {
+			break
+		}
+
+		delay := backoff(attempt)
+		if isAPIErr && apiErr.StatusCode == http.StatusTooManyRequests && apiErr.RetryAfter > 0 {
+			if apiErr.RetryAfter > maxRetryAfter {
+				return nil, err
+			}
+			delay = apiErr.RetryAfter
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+	return nil, lastErr
+}
+
+// fetchOnce 发起一次实际的 API 请求
+func (c *NBAClient) fetchOnce(ctx context.Context, endpoint string) ([]byte, error) {
 	url := ballDontLieBaseURL + endpoint
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -66,29 +271,77 @@ func (c *NBAClient) doRequest(endpoint string) ([]byte, error) {
 	}
 	defer resp.Body.Close()
 
+	var body []byte
+	if resp.StatusCode == http.StatusOK {
+		if body, err = io.ReadAll(resp.Body); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.mode == ModeRecord && recordable(resp.StatusCode) {
+		// 录制失败只影响离线数据，不影响本次请求
+		if err := c.fixtures.Save(endpoint, resp.StatusCode, body); err != nil {
+			log.Printf("NBA fixture %s not recorded: %v", endpoint, err)
+		}
+	}
+
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status code: %d", resp.StatusCode)
+		return nil, &APIError{
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		}
 	}
+	return body, nil
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+// Mode 当前的上游访问模式
+func (c *NBAClient) Mode() string {
+	return c.mode
+}
+
+// RefreshFixture 重新请求上游并覆盖录制数据（仅 record 模式可用，供 fixtures 维护命令使用）
+func (c *NBAClient) RefreshFixture(ctx context.Context, endpoint string) error {
+	if c.mode != ModeRecord {
+		return fmt.Errorf("refreshing fixtures requires %s mode, client is in %s mode", ModeRecord, c.mode)
 	}
+	_, err := c.fetch(ctx, endpoint)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && recordable(apiErr.StatusCode) {
+		// 404 已被录制，不算刷新失败
+		return nil
+	}
+	return err
+}
 
-	// 保存到缓存（球队数据缓存1小时，球员数据缓存5分钟）
-	cacheDuration := 5 * time.Minute
-	if endpoint == "/nba/v1/teams" {
-		cacheDuration = 1 * time.Hour // 球队数据变化少，缓存时间长
+// backoff 第 attempt 次重试前的等待时间（指数退避 + 全抖动）
+func backoff(attempt int) time.Duration {
+	d := retryBaseDelay << attempt
+	if d > retryMaxDelay {
+		d = retryMaxDelay
 	}
+	return time.Duration(rand.Int64N(int64(d)) + 1)
+}
 
-	c.cacheMu.Lock()
-	c.cache[endpoint] = &cacheEntry{
-		data:      body,
-		expiresAt: time.Now().Add(cacheDuration),
+// parseRetryAfter 解析 Retry-After（秒数或 HTTP 日期）
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
 	}
-	c.cacheMu.Unlock()
+	if t, err := http.ParseTime(value); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
 
-	return body, nil
+// cacheDuration 缓存时长（球队数据缓存1小时，球员数据缓存5分钟）
+func cacheDuration(endpoint string) time.Duration {
+	if endpoint == "/nba/v1/teams" {
+		return 1 * time.Hour // 球队数据变化少，缓存时间长
+	}
+	return 5 * time.Minute
 }
 
 // NBATeamResponse 球队响应
@@ -110,29 +363,44 @@ type NBATeam struct {
 	FullName     string `json:"full_name"`
 	FullNameZh   string `json:"full_name_zh"` // 中文名称
 	Abbreviation string `json:"abbreviation"`
-	LogoURL      string `json:"logo_url"` // 队标 URL (SVG格式)
-	BgColor      string `json:"bg_color"` // 背景色 (与队标配色搭配)
+	LogoURL      string `json:"logo_url"`             // 队标 URL (SVG格式)
+	BgColor      string `json:"bg_color"`             // 背景色 (与队标配色搭配)
+	Historical   bool   `json:"historical,omitempty"` // 已解散的历史球队
 }
 
 // GetTeams 获取所有 NBA 球队（只返回现役30支球队）
-func (c *NBAClient) GetTeams() ([]NBATeam, error) {
-	body, err := c.doRequest("/nba/v1/teams")
+// 第二个返回值表示数据是否为过期的旧数据（下同）
+func (c *NBAClient) GetTeams(ctx context.Context) ([]NBATeam, bool, error) {
+	teams, stale, err := c.GetAllTeams(ctx)
 	if err != nil {
-		return nil, err
+		return nil, false, err
+	}
+
+	var active []NBATeam
+	for _, team := range teams {
+		if !team.Historical {
+			active = append(active, team)
+		}
+	}
+	return active, stale, nil
+}
+
+// GetAllTeams 获取包括已解散历史球队在内的所有球队，历史球队标记为 Historical
+func (c *NBAClient) GetAllTeams(ctx context.Context) ([]NBATeam, bool, error) {
+	body, stale, err := c.doRequest(ctx, "/nba/v1/teams")
+	if err != nil {
+		return nil, false, err
 	}
 
 	var response NBATeamResponse
 	if err := json.Unmarshal(body, &response); err != nil {
-		return nil, err
+		return nil, false, err
 	}
-
-	// 过滤掉历史球队，只保留现役的30支球队
-	activeTeams := filterActiveTeams(response.Data)
-	return activeTeams, nil
+	return annotateTeams(response.Data), stale, nil
 }
 
-// filterActiveTeams 过滤出现役的30支NBA球队
-func filterActiveTeams(teams []NBATeam) []NBATeam {
+// annotateTeams 为现役30支球队补充中文名、队标和背景色，其余球队标记为历史球队
+func annotateTeams(teams []NBATeam) []NBATeam {
 	// 现役30支球队的全名白名单（2024-25赛季）
 	// 使用全名更准确，避免历史球队混入
 	activeTeamNames := map[string]bool{
@@ -206,7 +474,7 @@ func filterActiveTeams(teams []NBATeam) []NBATeam {
 		"Washington Wizards":     {"华盛顿奇才", "https://a.espncdn.com/i/teamlogos/nba/500/wsh.png", "#002B5C"},
 	}
 
-	var active []NBATeam
+	annotated := make([]NBATeam, 0, len(teams))
 	for _, team := range teams {
 		// 白名单检查 + conference非空检查（历史球队没有conference）
 		if activeTeamNames[team.FullName] && team.Conference != "" {
@@ -216,10 +484,12 @@ func filterActiveTeams(teams []NBATeam) []NBATeam {
 				team.LogoURL = info.logoURL
 				team.BgColor = info.bgColor
 			}
-			active = append(active, team)
+		} else {
+			team.Historical = true
 		}
+		annotated = append(annotated, team)
 	}
-	return active
+	return annotated
 }
 
 // NBAPlayerResponse 球员响应
@@ -249,23 +519,94 @@ type NBAPlayer struct {
 }
 
 // GetPlayers 获取球员列表（可按球队筛选）
-func (c *NBAClient) GetPlayers(teamID int) ([]NBAPlayer, error) {
+func (c *NBAClient) GetPlayers(ctx context.Context, teamID int) ([]NBAPlayer, bool, error) {
 	endpoint := "/nba/v1/players?per_page=25"
 	if teamID > 0 {
 		endpoint += fmt.Sprintf("&team_ids[]=%d", teamID)
 	}
 
-	body, err := c.doRequest(endpoint)
+	body, stale, err := c.doRequest(ctx, endpoint)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
 	var response NBAPlayerResponse
 	if err := json.Unmarshal(body, &response); err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
-	return response.Data, nil
+	return response.Data, stale, nil
+}
+
+// GetPlayer 获取单个球员
+func (c *NBAClient) GetPlayer(ctx context.Context, playerID int) (*NBAPlayer, bool, error) {
+	body, stale, err := c.doRequest(ctx, fmt.Sprintf("/nba/v1/players/%d", playerID))
+	if err != nil {
+		return nil, false, err
+	}
+
+	var response struct {
+		Data NBAPlayer `json:"data"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, false, err
+	}
+
+	return &response.Data, stale, nil
+}
+
+// GetActivePlayers 获取现役球员（teamID 为 0 时获取全联盟，自动翻页）
+func (c *NBAClient) GetActivePlayers(ctx context.Context, teamID int) ([]NBAPlayer, bool, error) {
+	var players []NBAPlayer
+	anyStale := false
+	cursor := 0
+	for {
+		endpoint := "/nba/v1/players/active?per_page=100"
+		if teamID > 0 {
+			endpoint += fmt.Sprintf("&team_ids[]=%d", teamID)
+		}
+		if cursor > 0 {
+			endpoint += fmt.Sprintf("&cursor=%d", cursor)
+		}
+
+		body, stale, err := c.doRequest(ctx, endpoint)
+		if err != nil {
+			return nil, false, err
+		}
+
+		var response NBAPlayerResponse
+		if err := json.Unmarshal(body, &response); err != nil {
+			return nil, false, err
+		}
+
+		players = append(players, response.Data...)
+		anyStale = anyStale || stale
+		cursor = response.Meta.NextCursor
+		if cursor == 0 {
+			break
+		}
+	}
+	return players, anyStale, nil
+}
+
+// GetPlayersPage 按游标分页获取全部球员（用于数据同步）
+func (c *NBAClient) GetPlayersPage(ctx context.Context, cursor int, perPage int) (*NBAPlayerResponse, bool, error) {
+	endpoint := fmt.Sprintf("/nba/v1/players?per_page=%d", perPage)
+	if cursor > 0 {
+		endpoint += fmt.Sprintf("&cursor=%d", cursor)
+	}
+
+	body, stale, err := c.doRequest(ctx, endpoint)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var response NBAPlayerResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, false, err
+	}
+
+	return &response, stale, nil
 }
 
 // NBASeasonAveragesResponse 赛季数据响应
@@ -303,22 +644,42 @@ type NBASeasonAverage struct {
 }
 
 // GetPlayerSeasonAverages 获取球员赛季平均数据
-func (c *NBAClient) GetPlayerSeasonAverages(playerID int, season int) (*NBASeasonAverage, error) {
+func (c *NBAClient) GetPlayerSeasonAverages(ctx context.Context, playerID int, season int) (*NBASeasonAverage, bool, error) {
 	endpoint := fmt.Sprintf("/nba/v1/season_averages?season=%d&player_ids[]=%d", season, playerID)
 
-	body, err := c.doRequest(endpoint)
+	body, stale, err := c.doRequest(ctx, endpoint)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
 	var response NBASeasonAveragesResponse
 	if err := json.Unmarshal(body, &response); err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
 	if len(response.Data) == 0 {
-		return nil, fmt.Errorf("no season averages found")
+		return nil, false, fmt.Errorf("no season averages found")
+	}
+
+	return &response.Data[0], stale, nil
+}
+
+// GetSeasonAveragesBatch 一次请求获取多名球员的赛季平均数据（没有数据的球员不会出现在结果中）
+func (c *NBAClient) GetSeasonAveragesBatch(ctx context.Context, season int, playerIDs []int) ([]NBASeasonAverage, bool, error) {
+	endpoint := fmt.Sprintf("/nba/v1/season_averages?season=%d", season)
+	for _, id := range playerIDs {
+		endpoint += fmt.Sprintf("&player_ids[]=%d", id)
+	}
+
+	body, stale, err := c.doRequest(ctx, endpoint)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var response NBASeasonAveragesResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, false, err
 	}
 
-	return &response.Data[0], nil
+	return response.Data, stale, nil
 }
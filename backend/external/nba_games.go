@@ -0,0 +1,232 @@
+package external
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// NBAGameResponse 比赛响应
+type NBAGameResponse struct {
+	Data []NBAGame `json:"data"`
+	Meta struct {
+		NextCursor int `json:"next_cursor"`
+		PerPage    int `json:"per_page"`
+	} `json:"meta"`
+}
+
+// NBAGame 比赛信息
+type NBAGame struct {
+	ID               int     `json:"id"`
+	Date             string  `json:"date"`     // 比赛日期（美国当地），如 2024-10-22
+	Datetime         string  `json:"datetime"` // 开赛时间（UTC），如 2024-10-22T23:30:00.000Z
+	Season           int     `json:"season"`
+	Status           string  `json:"status"` // Final / 1st Qtr / 开赛时间
+	Period           int     `json:"period"`
+	Time             string  `json:"time"`
+	Postseason       bool    `json:"postseason"`
+	HomeTeamScore    int     `json:"home_team_score"`
+	VisitorTeamScore int     `json:"visitor_team_score"`
+	HomeTeam         NBATeam `json:"home_team"`
+	VisitorTeam      NBATeam `json:"visitor_team"`
+}
+
+// IsFinal 比赛是否已结束
+func (g *NBAGame) IsFinal() bool {
+	return g.Status == "Final"
+}
+
+// IsCancelled 比赛是否已取消或延期（上游不会再更新比分）
+func (g *NBAGame) IsCancelled() bool {
+	status := strings.ToLower(g.Status)
+	return strings.Contains(status, "postpone") || strings.Contains(status, "cancel")
+}
+
+// TipOff 开赛时间；上游还没排定具体时间时取比赛日期当天 0 点（UTC），宁早勿晚
+func (g *NBAGame) TipOff() (time.Time, error) {
+	if g.Datetime != "" {
+		if t, err := time.Parse(time.RFC3339, g.Datetime); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Parse("2006-01-02", g.Date)
+}
+
+// GameQuery 比赛/数据统计查询条件
+type GameQuery struct {
+	Seasons   []int
+	TeamIDs   []int
+	PlayerIDs []int // 仅用于 stats
+	GameIDs   []int // 仅用于 stats
+	StartDate string
+	EndDate   string
+	Cursor    int
+	PerPage   int
+}
+
+// encode 生成查询字符串
+func (q GameQuery) encode() string {
+	perPage := q.PerPage
+	if perPage <= 0 {
+		perPage = 100
+	}
+	parts := []string{fmt.Sprintf("per_page=%d", perPage)}
+	for _, s := range q.Seasons {
+		parts = append(parts, fmt.Sprintf("seasons[]=%d", s))
+	}
+	for _, id := range q.TeamIDs {
+		parts = append(parts, fmt.Sprintf("team_ids[]=%d", id))
+	}
+	for _, id := range q.PlayerIDs {
+		parts = append(parts, fmt.Sprintf("player_ids[]=%d", id))
+	}
+	for _, id := range q.GameIDs {
+		parts = append(parts, fmt.Sprintf("game_ids[]=%d", id))
+	}
+	if q.StartDate != "" {
+		parts = append(parts, "start_date="+q.StartDate)
+	}
+	if q.EndDate != "" {
+		parts = append(parts, "end_date="+q.EndDate)
+	}
+	if q.Cursor > 0 {
+		parts = append(parts, fmt.Sprintf("cursor=%d", q.Cursor))
+	}
+	return strings.Join(parts, "&")
+}
+
+// GetGamesPage 获取一页比赛
+func (c *NBAClient) GetGamesPage(ctx context.Context, query GameQuery) (*NBAGameResponse, bool, error) {
+	body, stale, err := c.doRequest(ctx, "/nba/v1/games?"+query.encode())
+	if err != nil {
+		return nil, false, err
+	}
+
+	var response NBAGameResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, false, err
+	}
+
+	return &response, stale, nil
+}
+
+// GetGame 获取单场比赛
+func (c *NBAClient) GetGame(ctx context.Context, gameID int) (*NBAGame, bool, error) {
+	body, stale, err := c.doRequest(ctx, fmt.Sprintf("/nba/v1/games/%d", gameID))
+	if err != nil {
+		return nil, false, err
+	}
+
+	var response struct {
+		Data NBAGame `json:"data"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, false, err
+	}
+
+	return &response.Data, stale, nil
+}
+
+// GetGames 获取满足条件的全部比赛（自动翻页）
+func (c *NBAClient) GetGames(ctx context.Context, query GameQuery) ([]NBAGame, bool, error) {
+	var games []NBAGame
+	anyStale := false
+	for {
+		page, stale, err := c.GetGamesPage(ctx, query)
+		if err != nil {
+			return nil, false, err
+		}
+		games = append(games, page.Data...)
+		anyStale = anyStale || stale
+		if page.Meta.NextCursor == 0 {
+			break
+		}
+		query.Cursor = page.Meta.NextCursor
+	}
+	return games, anyStale, nil
+}
+
+// NBAStatResponse 单场数据统计响应
+type NBAStatResponse struct {
+	Data []NBAStat `json:"data"`
+	Meta struct {
+		NextCursor int `json:"next_cursor"`
+		PerPage    int `json:"per_page"`
+	} `json:"meta"`
+}
+
+// NBAStat 球员单场数据统计（box score）
+type NBAStat struct {
+	ID       int         `json:"id"`
+	Min      string      `json:"min"`
+	Fgm      int         `json:"fgm"`
+	Fga      int         `json:"fga"`
+	FgPct    float64     `json:"fg_pct"`
+	Fg3m     int         `json:"fg3m"`
+	Fg3a     int         `json:"fg3a"`
+	Fg3Pct   float64     `json:"fg3_pct"`
+	Ftm      int         `json:"ftm"`
+	Fta      int         `json:"fta"`
+	FtPct    float64     `json:"ft_pct"`
+	Oreb     int         `json:"oreb"`
+	Dreb     int         `json:"dreb"`
+	Reb      int         `json:"reb"`
+	Ast      int         `json:"ast"`
+	Stl      int         `json:"stl"`
+	Blk      int         `json:"blk"`
+	Turnover int         `json:"turnover"`
+	Pf       int         `json:"pf"`
+	Pts      int         `json:"pts"`
+	Player   NBAPlayer   `json:"player"`
+	Team     NBATeam     `json:"team"`
+	Game     NBAStatGame `json:"game"`
+}
+
+// NBAStatGame 数据统计中内嵌的比赛信息（只有球队 ID，没有完整球队对象）
+type NBAStatGame struct {
+	ID               int    `json:"id"`
+	Date             string `json:"date"`
+	Season           int    `json:"season"`
+	Status           string `json:"status"`
+	Postseason       bool   `json:"postseason"`
+	HomeTeamID       int    `json:"home_team_id"`
+	VisitorTeamID    int    `json:"visitor_team_id"`
+	HomeTeamScore    int    `json:"home_team_score"`
+	VisitorTeamScore int    `json:"visitor_team_score"`
+}
+
+// GetStatsPage 获取一页单场数据统计
+func (c *NBAClient) GetStatsPage(ctx context.Context, query GameQuery) (*NBAStatResponse, bool, error) {
+	body, stale, err := c.doRequest(ctx, "/nba/v1/stats?"+query.encode())
+	if err != nil {
+		return nil, false, err
+	}
+
+	var response NBAStatResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, false, err
+	}
+
+	return &response, stale, nil
+}
+
+// GetStats 获取满足条件的全部单场数据统计（自动翻页）
+func (c *NBAClient) GetStats(ctx context.Context, query GameQuery) ([]NBAStat, bool, error) {
+	var stats []NBAStat
+	anyStale := false
+	for {
+		page, stale, err := c.GetStatsPage(ctx, query)
+		if err != nil {
+			return nil, false, err
+		}
+		stats = append(stats, page.Data...)
+		anyStale = anyStale || stale
+		if page.Meta.NextCursor == 0 {
+			break
+		}
+		query.Cursor = page.Meta.NextCursor
+	}
+	return stats, anyStale, nil
+}
@@ -0,0 +1,58 @@
+package external
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// rateLimiter 令牌桶限流器，保证调用频率不超过 API 套餐额度
+type rateLimiter struct {
+	mu       sync.Mutex
+	tokens   float64
+	capacity float64
+	interval time.Duration // 生成一个令牌所需时间
+	last     time.Time
+}
+
+// newRateLimiter 创建每分钟 perMinute 次的限流器
+func newRateLimiter(perMinute int) *rateLimiter {
+	if perMinute <= 0 {
+		perMinute = 1
+	}
+	return &rateLimiter{
+		tokens:   float64(perMinute),
+		capacity: float64(perMinute),
+		interval: time.Minute / time.Duration(perMinute),
+		last:     time.Now(),
+	}
+}
+
+// Wait 阻塞直到获得令牌或 ctx 结束
+func (l *rateLimiter) Wait(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		l.tokens += float64(now.Sub(l.last)) / float64(l.interval)
+		if l.tokens > l.capacity {
+			l.tokens = l.capacity
+		}
+		l.last = now
+
+		if l.tokens >= 1 {
+			l.tokens--
+			l.mu.Unlock()
+			return nil
+		}
+		wait := time.Duration((1 - l.tokens) * float64(l.interval))
+		l.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package external
+
+import "time"
+
+// seasonStartMonth 新赛季开始的月份（常规赛 10 月中下旬开打，季前赛在 10 月初）
+const seasonStartMonth = time.October
+
+// CurrentSeason 按日期推算当前赛季（balldontlie 以赛季开始的年份表示赛季，如 2024-25 赛季为 2024）
+// 10 月之前属于上一年开始的赛季；休赛期（7-9 月）返回刚结束的赛季，此时它仍是最近有数据的赛季
+func CurrentSeason(now time.Time) int {
+	if now.Month() >= seasonStartMonth {
+		return now.Year()
+	}
+	return now.Year() - 1
+}
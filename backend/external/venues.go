@@ -0,0 +1,40 @@
+package external
+
+// arenas 现役球队主场（按球队缩写，2024-25赛季）
+var arenas = map[string]string{
+	"ATL": "State Farm Arena, Atlanta, GA",
+	"BOS": "TD Garden, Boston, MA",
+	"BKN": "Barclays Center, Brooklyn, NY",
+	"CHA": "Spectrum Center, Charlotte, NC",
+	"CHI": "United Center, Chicago, IL",
+	"CLE": "Rocket Mortgage FieldHouse, Cleveland, OH",
+	"DAL": "American Airlines Center, Dallas, TX",
+	"DEN": "Ball Arena, Denver, CO",
+	"DET": "Little Caesars Arena, Detroit, MI",
+	"GSW": "Chase Center, San Francisco, CA",
+	"HOU": "Toyota Center, Houston, TX",
+	"IND": "Gainbridge Fieldhouse, Indianapolis, IN",
+	"LAC": "Intuit Dome, Inglewood, CA",
+	"LAL": "Crypto.com Arena, Los Angeles, CA",
+	"MEM": "FedExForum, Memphis, TN",
+	"MIA": "Kaseya Center, Miami, FL",
+	"MIL": "Fiserv Forum, Milwaukee, WI",
+	"MIN": "Target Center, Minneapolis, MN",
+	"NOP": "Smoothie King Center, New Orleans, LA",
+	"NYK": "Madison Square Garden, New York, NY",
+	"OKC": "Paycom Center, Oklahoma City, OK",
+	"ORL": "Kia Center, Orlando, FL",
+	"PHI": "Wells Fargo Center, Philadelphia, PA",
+	"PHX": "Footprint Center, Phoenix, AZ",
+	"POR": "Moda Center, Portland, OR",
+	"SAC": "Golden 1 Center, Sacramento, CA",
+	"SAS": "Frost Bank Center, San Antonio, TX",
+	"TOR": "Scotiabank Arena, Toronto, ON",
+	"UTA": "Delta Center, Salt Lake City, UT",
+	"WAS": "Capital One Arena, Washington, DC",
+}
+
+// Arena 获取球队主场名称（未知球队返回空字符串）
+func Arena(abbreviation string) string {
+	return arenas[abbreviation]
+}
@@ -0,0 +1,94 @@
+package fantasy
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 阵容位置
+const (
+	SlotGuard   = "G"
+	SlotForward = "F"
+	SlotCenter  = "C"
+	SlotUtil    = "UTIL" // 任意位置
+	SlotBench   = "BN"   // 替补，不计分
+)
+
+// slotOrder 位置的展示顺序（balldontlie 只区分 G/F/C，不细分 PG/SG 等）
+var slotOrder = []string{SlotGuard, SlotForward, SlotCenter, SlotUtil, SlotBench}
+
+// 阵容规模限制
+const (
+	maxSlotCount  = 10
+	maxRosterSize = 20
+)
+
+// DefaultRosterSlots 默认阵容配置：8 名首发 + 3 名替补
+var DefaultRosterSlots = map[string]int{
+	SlotGuard:   2,
+	SlotForward: 2,
+	SlotCenter:  1,
+	SlotUtil:    3,
+	SlotBench:   3,
+}
+
+// NormalizeRosterSlots 校验阵容配置，未配置时使用默认值
+func NormalizeRosterSlots(slots map[string]int) (map[string]int, error) {
+	if len(slots) == 0 {
+		return DefaultRosterSlots, nil
+	}
+
+	known := map[string]bool{}
+	for _, s := range slotOrder {
+		known[s] = true
+	}
+	active, total := 0, 0
+	for slot, n := range slots {
+		if !known[slot] {
+			return nil, fmt.Errorf("不支持的阵容位置: %s", slot)
+		}
+		if n < 0 || n > maxSlotCount {
+			return nil, fmt.Errorf("位置 %s 的数量必须在 0-%d 之间", slot, maxSlotCount)
+		}
+		total += n
+		if slot != SlotBench {
+			active += n
+		}
+	}
+	if active == 0 {
+		return nil, fmt.Errorf("至少需要一个首发位置")
+	}
+	if total > maxRosterSize {
+		return nil, fmt.Errorf("阵容总人数不能超过 %d", maxRosterSize)
+	}
+	return slots, nil
+}
+
+// RosterSize 阵容总人数
+func RosterSize(slots map[string]int) int {
+	total := 0
+	for _, n := range slots {
+		total += n
+	}
+	return total
+}
+
+// Eligible 球员（balldontlie 位置，如 G、F-C）能否放在该位置
+func Eligible(slot, position string) bool {
+	switch slot {
+	case SlotUtil, SlotBench:
+		return true
+	case SlotGuard, SlotForward, SlotCenter:
+		for _, p := range strings.Split(position, "-") {
+			if strings.TrimSpace(p) == slot {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Scores 该位置的球员是否计分
+func Scores(slot string) bool {
+	return slot != SlotBench
+}
@@ -0,0 +1,71 @@
+package fantasy
+
+import "time"
+
+// dateLayout balldontlie 的比赛日期格式
+const dateLayout = "2006-01-02"
+
+// Pairing 一场对阵的主客队（fantasy_teams.id）
+type Pairing struct {
+	Home int
+	Away int
+}
+
+// RoundRobin 生成 weeks 周的循环赛程（圆桌法）
+// 每轮所有球队各打一场，奇数支球队时每周有一队轮空；轮次用完后从头循环，并交换主客
+func RoundRobin(teamIDs []int, weeks int) [][]Pairing {
+	ids := append([]int(nil), teamIDs...)
+	if len(ids)%2 == 1 {
+		ids = append(ids, 0) // 0 表示轮空
+	}
+	n := len(ids)
+	rounds := n - 1
+
+	schedule := make([][]Pairing, weeks)
+	if n < 2 {
+		return schedule
+	}
+	for week := 0; week < weeks; week++ {
+		round := week % rounds
+		swap := (week/rounds)%2 == 1
+
+		// 第一个球队固定，其余按轮次旋转
+		rotated := make([]int, n)
+		rotated[0] = ids[0]
+		for i := 1; i < n; i++ {
+			rotated[i] = ids[1+(i-1+round)%(n-1)]
+		}
+
+		for i := 0; i < n/2; i++ {
+			home, away := rotated[i], rotated[n-1-i]
+			if home == 0 || away == 0 {
+				continue
+			}
+			// 同一轮中按场次和轮次交替主客，避免固定球队永远是主队
+			if (i+round)%2 == 1 {
+				home, away = away, home
+			}
+			if swap {
+				home, away = away, home
+			}
+			schedule[week] = append(schedule[week], Pairing{Home: home, Away: away})
+		}
+	}
+	return schedule
+}
+
+// WeekStart 日期所在周的周一
+func WeekStart(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, time.UTC)
+}
+
+// WeekRange 第 week 周（从 1 开始）的起止日期（周一到周日，含首尾）
+func WeekRange(startDate string, week int) (string, string, error) {
+	start, err := time.Parse(dateLayout, startDate)
+	if err != nil {
+		return "", "", err
+	}
+	first := start.AddDate(0, 0, 7*(week-1))
+	return first.Format(dateLayout), first.AddDate(0, 0, 6).Format(dateLayout), nil
+}
@@ -0,0 +1,207 @@
+package fantasy
+
+import (
+	"buzzerbeater/external"
+	"buzzerbeater/model"
+	"buzzerbeater/warehouse"
+	"context"
+	"log"
+	"time"
+)
+
+// finalizeAfter 一周结束后多久定为最终比分（留出时间给周日晚场的数据统计同步）
+const finalizeAfter = 48 * time.Hour
+
+// Scorer 定时根据 NBA 单场数据计算进行中联赛的每周对阵比分和战绩
+type Scorer struct {
+	client   *external.NBAClient
+	interval time.Duration
+	now      func() time.Time
+}
+
+// NewScorer 创建计分任务
+func NewScorer(client *external.NBAClient, interval time.Duration) *Scorer {
+	return &Scorer{client: client, interval: interval, now: time.Now}
+}
+
+// Start 启动后台计分（立即执行一次，之后按间隔执行，ctx 结束时退出）
+func (s *Scorer) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			s.RunOnce(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// RunOnce 对所有进行中的联赛计分一次，某个联赛失败不影响其余联赛
+func (s *Scorer) RunOnce(ctx context.Context) {
+	leagues, err := ActiveLeagues()
+	if err != nil {
+		log.Printf("Fantasy scoring: list leagues failed: %v", err)
+		return
+	}
+	for i := range leagues {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := s.ScoreLeague(ctx, &leagues[i]); err != nil {
+			log.Printf("Fantasy scoring: league %d failed: %v", leagues[i].ID, err)
+		}
+	}
+}
+
+// ScoreLeague 计算联赛中已开始且未结束的对阵，然后汇总战绩
+func (s *Scorer) ScoreLeague(ctx context.Context, league *model.FantasyLeague) error {
+	matchups, err := Matchups(league.ID, 0)
+	if err != nil {
+		return err
+	}
+
+	now := s.now().UTC()
+	today := now.Format(dateLayout)
+	for _, m := range matchups {
+		if m.Status == model.FantasyMatchupFinal || m.StartDate > today {
+			continue
+		}
+		end, err := time.Parse(dateLayout, m.EndDate)
+		if err != nil {
+			return err
+		}
+
+		score, err := s.scoreMatchup(ctx, league, m)
+		if err != nil {
+			return err
+		}
+		status := model.FantasyMatchupLive
+		if !now.Before(end.Add(finalizeAfter)) {
+			status = model.FantasyMatchupFinal
+		}
+		if err := saveMatchupScore(m, score, status); err != nil {
+			return err
+		}
+	}
+
+	if err := updateStandings(league.ID); err != nil {
+		return err
+	}
+	return finishLeagueIfDone(league.ID)
+}
+
+// scoreMatchup 取双方球员在本周内的单场数据并计分
+// 一场比赛只在球员开赛时位于计分位置（非替补）时计入，开赛后再调整阵容不会改变已开赛比赛的归属
+func (s *Scorer) scoreMatchup(ctx context.Context, league *model.FantasyLeague, m model.FantasyMatchup) (MatchupScore, error) {
+	weekStart, err := time.Parse(dateLayout, m.StartDate)
+	if err != nil {
+		return MatchupScore{}, err
+	}
+	homeStints, err := scoringStints(m.HomeTeamID, weekStart)
+	if err != nil {
+		return MatchupScore{}, err
+	}
+	awayStints, err := scoringStints(m.AwayTeamID, weekStart)
+	if err != nil {
+		return MatchupScore{}, err
+	}
+
+	seen := map[int]bool{}
+	var playerIDs []int
+	for _, st := range append(append([]lineupStint(nil), homeStints...), awayStints...) {
+		if !seen[st.PlayerID] {
+			seen[st.PlayerID] = true
+			playerIDs = append(playerIDs, st.PlayerID)
+		}
+	}
+
+	lines, err := s.weekStats(ctx, league.Season, playerIDs, m.StartDate, m.EndDate)
+	if err != nil {
+		return MatchupScore{}, err
+	}
+	tipOffs, err := s.weekTipOffs(ctx, league.Season, m.StartDate, m.EndDate)
+	if err != nil {
+		return MatchupScore{}, err
+	}
+
+	var home, away []external.NBAStat
+	for _, line := range lines {
+		tipOff, ok := tipOffs[line.Game.ID]
+		if !ok {
+			// 没有比赛信息时按比赛日期 0 点（UTC）算，宁早勿晚
+			if tipOff, err = (&external.NBAGame{Date: line.Game.Date}).TipOff(); err != nil {
+				continue
+			}
+		}
+		switch {
+		case onCourt(homeStints, line.Player.ID, tipOff):
+			home = append(home, line)
+		case onCourt(awayStints, line.Player.ID, tipOff):
+			away = append(away, line)
+		}
+	}
+	return Score(league.ScoringType, league.Scoring, home, away), nil
+}
+
+// onCourt 开赛时球员是否在球队的计分位置上
+func onCourt(stints []lineupStint, playerID int, tipOff time.Time) bool {
+	for _, st := range stints {
+		if st.PlayerID == playerID && st.covers(tipOff) {
+			return true
+		}
+	}
+	return false
+}
+
+// weekTipOffs 日期范围内各场比赛的开赛时间，本地仓库已同步时优先使用本地数据
+func (s *Scorer) weekTipOffs(ctx context.Context, season int, start, end string) (map[int]time.Time, error) {
+	if warehouse.Ready(warehouse.GamesResource(season)) {
+		if games, err := warehouse.Games(warehouse.GameFilter{Season: season, StartDate: start, EndDate: end}); err == nil {
+			return tipOffsByGame(games), nil
+		}
+	}
+	games, _, err := s.client.GetGames(ctx, external.GameQuery{
+		Seasons:   []int{season},
+		StartDate: start,
+		EndDate:   end,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tipOffsByGame(games), nil
+}
+
+func tipOffsByGame(games []external.NBAGame) map[int]time.Time {
+	tipOffs := make(map[int]time.Time, len(games))
+	for i := range games {
+		if t, err := games[i].TipOff(); err == nil {
+			tipOffs[games[i].ID] = t
+		}
+	}
+	return tipOffs
+}
+
+// weekStats 球员在日期范围内的单场数据，本地仓库已同步时优先使用本地数据
+func (s *Scorer) weekStats(ctx context.Context, season int, playerIDs []int, start, end string) ([]external.NBAStat, error) {
+	if len(playerIDs) == 0 {
+		return nil, nil
+	}
+	if warehouse.Ready(warehouse.StatsResource(season)) {
+		if lines, err := warehouse.StatsBetween(playerIDs, start, end); err == nil {
+			return lines, nil
+		}
+	}
+	lines, _, err := s.client.GetStats(ctx, external.GameQuery{
+		Seasons:   []int{season},
+		PlayerIDs: playerIDs,
+		StartDate: start,
+		EndDate:   end,
+	})
+	return lines, err
+}
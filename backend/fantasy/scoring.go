@@ -0,0 +1,209 @@
+package fantasy
+
+import (
+	"buzzerbeater/external"
+	"buzzerbeater/model"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// statKeys 可用于计分的数据项（百分比只能用于类别制）
+var statKeys = map[string]bool{
+	"pts": true, "reb": true, "oreb": true, "dreb": true, "ast": true, "stl": true, "blk": true,
+	"turnover": true, "fgm": true, "fga": true, "fg3m": true, "fg3a": true, "ftm": true, "fta": true, "pf": true,
+}
+
+// percentageKeys 类别制可用的命中率类别（由整周的命中数/出手数计算，不是单场命中率的平均）
+var percentageKeys = map[string]bool{"fg_pct": true, "fg3_pct": true, "ft_pct": true}
+
+// lowerIsBetter 越低越好的类别
+var lowerIsBetter = map[string]bool{"turnover": true, "pf": true}
+
+// DefaultPointWeights 积分制默认权重
+var DefaultPointWeights = map[string]float64{
+	"pts":      1,
+	"reb":      1.2,
+	"ast":      1.5,
+	"stl":      3,
+	"blk":      3,
+	"turnover": -1,
+}
+
+// DefaultCategories 类别制默认类别（常见的 9 类）
+var DefaultCategories = []string{"fg_pct", "ft_pct", "fg3m", "pts", "reb", "ast", "stl", "blk", "turnover"}
+
+// NormalizeScoring 校验计分配置，未配置时使用默认值
+func NormalizeScoring(scoringType string, scoring model.FantasyScoring) (model.FantasyScoring, error) {
+	switch scoringType {
+	case model.FantasyScoringPoints:
+		if len(scoring.Weights) == 0 {
+			scoring.Weights = DefaultPointWeights
+		}
+		for key := range scoring.Weights {
+			if !statKeys[key] {
+				return scoring, fmt.Errorf("不支持的计分项: %s", key)
+			}
+		}
+		scoring.Categories = nil
+	case model.FantasyScoringCategory:
+		if len(scoring.Categories) == 0 {
+			scoring.Categories = DefaultCategories
+		}
+		seen := map[string]bool{}
+		for _, key := range scoring.Categories {
+			if !statKeys[key] && !percentageKeys[key] {
+				return scoring, fmt.Errorf("不支持的计分类别: %s", key)
+			}
+			if seen[key] {
+				return scoring, fmt.Errorf("重复的计分类别: %s", key)
+			}
+			seen[key] = true
+		}
+		scoring.Weights = nil
+	default:
+		return scoring, errors.New("计分方式只能是 points 或 category")
+	}
+	return scoring, nil
+}
+
+// totals 一支球队一周的数据合计
+type totals struct {
+	values map[string]float64
+}
+
+// newTotals 合计若干单场数据
+func newTotals(lines []external.NBAStat) totals {
+	t := totals{values: map[string]float64{}}
+	for _, s := range lines {
+		for key, v := range lineValues(s) {
+			t.values[key] += v
+		}
+	}
+	return t
+}
+
+// Value 某项数据的合计，命中率由命中数/出手数计算
+func (t totals) Value(key string) float64 {
+	switch key {
+	case "fg_pct":
+		return ratio(t.values["fgm"], t.values["fga"])
+	case "fg3_pct":
+		return ratio(t.values["fg3m"], t.values["fg3a"])
+	case "ft_pct":
+		return ratio(t.values["ftm"], t.values["fta"])
+	}
+	return t.values[key]
+}
+
+func lineValues(s external.NBAStat) map[string]float64 {
+	return map[string]float64{
+		"pts": float64(s.Pts), "reb": float64(s.Reb), "oreb": float64(s.Oreb), "dreb": float64(s.Dreb),
+		"ast": float64(s.Ast), "stl": float64(s.Stl), "blk": float64(s.Blk), "turnover": float64(s.Turnover),
+		"fgm": float64(s.Fgm), "fga": float64(s.Fga), "fg3m": float64(s.Fg3m), "fg3a": float64(s.Fg3a),
+		"ftm": float64(s.Ftm), "fta": float64(s.Fta), "pf": float64(s.Pf),
+	}
+}
+
+// LinePoints 单场数据按权重折算的积分
+func LinePoints(s external.NBAStat, weights map[string]float64) float64 {
+	values := lineValues(s)
+	var points float64
+	for key, w := range weights {
+		points += values[key] * w
+	}
+	return round(points)
+}
+
+// CategoryResult 类别制中一个类别的比较结果
+type CategoryResult struct {
+	Key    string  `json:"key"`
+	Home   float64 `json:"home"`
+	Away   float64 `json:"away"`
+	Winner string  `json:"winner"` // home / away / tie
+}
+
+// MatchupDetails 对阵明细（存入 fantasy_matchups.details）
+type MatchupDetails struct {
+	Categories  []CategoryResult `json:"categories,omitempty"`
+	HomePlayers map[int]float64  `json:"home_players,omitempty"` // 球员ID -> 积分（积分制）
+	AwayPlayers map[int]float64  `json:"away_players,omitempty"`
+}
+
+// MatchupScore 对阵比分：积分制为双方总积分，类别制为双方赢下的类别数
+type MatchupScore struct {
+	Home    float64
+	Away    float64
+	Details MatchupDetails
+}
+
+// Winner 胜者：1 主队、-1 客队、0 平局
+func (m MatchupScore) Winner() int {
+	switch {
+	case m.Home > m.Away:
+		return 1
+	case m.Away > m.Home:
+		return -1
+	}
+	return 0
+}
+
+// Score 按联赛计分配置计算一场对阵
+func Score(scoringType string, scoring model.FantasyScoring, home, away []external.NBAStat) MatchupScore {
+	if scoringType == model.FantasyScoringCategory {
+		return scoreCategories(scoring.Categories, home, away)
+	}
+	return scorePoints(scoring.Weights, home, away)
+}
+
+func scorePoints(weights map[string]float64, home, away []external.NBAStat) MatchupScore {
+	result := MatchupScore{Details: MatchupDetails{HomePlayers: map[int]float64{}, AwayPlayers: map[int]float64{}}}
+	for _, s := range home {
+		p := LinePoints(s, weights)
+		result.Home += p
+		result.Details.HomePlayers[s.Player.ID] = round(result.Details.HomePlayers[s.Player.ID] + p)
+	}
+	for _, s := range away {
+		p := LinePoints(s, weights)
+		result.Away += p
+		result.Details.AwayPlayers[s.Player.ID] = round(result.Details.AwayPlayers[s.Player.ID] + p)
+	}
+	result.Home, result.Away = round(result.Home), round(result.Away)
+	return result
+}
+
+func scoreCategories(categories []string, home, away []external.NBAStat) MatchupScore {
+	homeTotals, awayTotals := newTotals(home), newTotals(away)
+	result := MatchupScore{}
+	for _, key := range categories {
+		h, a := round(homeTotals.Value(key)), round(awayTotals.Value(key))
+		r := CategoryResult{Key: key, Home: h, Away: a, Winner: "tie"}
+		if h != a {
+			homeBetter := h > a
+			if lowerIsBetter[key] {
+				homeBetter = !homeBetter
+			}
+			if homeBetter {
+				r.Winner = "home"
+				result.Home++
+			} else {
+				r.Winner = "away"
+				result.Away++
+			}
+		}
+		result.Details.Categories = append(result.Details.Categories, r)
+	}
+	return result
+}
+
+func ratio(a, b float64) float64 {
+	if b == 0 {
+		return 0
+	}
+	return a / b
+}
+
+// round 保留 3 位小数（命中率需要 3 位，积分 1-2 位）
+func round(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
@@ -0,0 +1,407 @@
+package fantasy
+
+import (
+	"buzzerbeater/db"
+	"buzzerbeater/model"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ErrNotFound 联赛/球队/对阵不存在
+var ErrNotFound = errors.New("fantasy record not found")
+
+const leagueColumns = "id, name, owner_id, season, scoring_type, scoring, roster_slots, max_teams, start_date, weeks, status, created_at"
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanLeague(row scanner) (*model.FantasyLeague, error) {
+	var l model.FantasyLeague
+	var scoring, slots string
+	if err := row.Scan(&l.ID, &l.Name, &l.OwnerID, &l.Season, &l.ScoringType, &scoring, &slots,
+		&l.MaxTeams, &l.StartDate, &l.Weeks, &l.Status, &l.CreatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(scoring), &l.Scoring); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(slots), &l.RosterSlots); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// GetLeague 获取联赛
+func GetLeague(id int) (*model.FantasyLeague, error) {
+	l, err := scanLeague(db.GetDB().QueryRow("SELECT "+leagueColumns+" FROM fantasy_leagues WHERE id = ?", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return l, err
+}
+
+// UserLeagues 用户参加的联赛（最新创建的在前）
+func UserLeagues(userID int) ([]model.FantasyLeague, error) {
+	return queryLeagues(`
+		SELECT `+leagueColumns+` FROM fantasy_leagues
+		WHERE id IN (SELECT league_id FROM fantasy_teams WHERE user_id = ?)
+		ORDER BY created_at DESC, id DESC
+	`, userID)
+}
+
+// ActiveLeagues 进行中的联赛
+func ActiveLeagues() ([]model.FantasyLeague, error) {
+	return queryLeagues("SELECT "+leagueColumns+" FROM fantasy_leagues WHERE status = ? ORDER BY id", model.FantasyLeagueActive)
+}
+
+func queryLeagues(query string, args ...interface{}) ([]model.FantasyLeague, error) {
+	rows, err := db.GetDB().Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	leagues := []model.FantasyLeague{}
+	for rows.Next() {
+		l, err := scanLeague(rows)
+		if err != nil {
+			return nil, err
+		}
+		leagues = append(leagues, *l)
+	}
+	return leagues, rows.Err()
+}
+
+const teamColumns = "id, league_id, user_id, name, wins, losses, ties, points_for, points_against, created_at"
+
+func scanTeam(row scanner) (*model.FantasyTeam, error) {
+	var t model.FantasyTeam
+	if err := row.Scan(&t.ID, &t.LeagueID, &t.UserID, &t.Name, &t.Wins, &t.Losses, &t.Ties,
+		&t.PointsFor, &t.PointsAgainst, &t.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// GetTeam 获取球队
+func GetTeam(id int) (*model.FantasyTeam, error) {
+	t, err := scanTeam(db.GetDB().QueryRow("SELECT "+teamColumns+" FROM fantasy_teams WHERE id = ?", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return t, err
+}
+
+// UserTeam 用户在联赛中的球队，不是联赛成员时返回 ErrNotFound
+func UserTeam(leagueID, userID int) (*model.FantasyTeam, error) {
+	t, err := scanTeam(db.GetDB().QueryRow(
+		"SELECT "+teamColumns+" FROM fantasy_teams WHERE league_id = ? AND user_id = ?", leagueID, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return t, err
+}
+
+// LeagueTeams 联赛中的球队（按战绩排名：胜率、总得分，平局算半场胜利）
+func LeagueTeams(leagueID int) ([]model.FantasyTeam, error) {
+	rows, err := db.GetDB().Query(`
+		SELECT `+teamColumns+` FROM fantasy_teams
+		WHERE league_id = ?
+		ORDER BY CAST(wins * 2 + ties AS REAL) / MAX(wins + losses + ties, 1) DESC, points_for DESC, id
+	`, leagueID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	teams := []model.FantasyTeam{}
+	for rows.Next() {
+		t, err := scanTeam(rows)
+		if err != nil {
+			return nil, err
+		}
+		teams = append(teams, *t)
+	}
+	return teams, rows.Err()
+}
+
+// Roster 球队阵容
+func Roster(teamID int) ([]model.FantasyRosterSpot, error) {
+	rows, err := db.GetDB().Query(
+		"SELECT team_id, player_id, slot, acquired_at FROM fantasy_rosters WHERE team_id = ? ORDER BY acquired_at, player_id",
+		teamID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	spots := []model.FantasyRosterSpot{}
+	for rows.Next() {
+		var s model.FantasyRosterSpot
+		if err := rows.Scan(&s.TeamID, &s.PlayerID, &s.Slot, &s.AcquiredAt); err != nil {
+			return nil, err
+		}
+		spots = append(spots, s)
+	}
+	return spots, rows.Err()
+}
+
+// ErrPlayerTaken 球员已被联赛中其他球队签下
+var ErrPlayerTaken = errors.New("fantasy player already rostered")
+
+// AddPlayer 签入球员并开始一段位置记录
+func AddPlayer(leagueID, teamID, playerID int, slot string) error {
+	tx, err := db.GetDB().Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var taken bool
+	if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM fantasy_rosters WHERE league_id = ? AND player_id = ?)",
+		leagueID, playerID).Scan(&taken); err != nil {
+		return err
+	}
+	if taken {
+		return ErrPlayerTaken
+	}
+	if _, err := tx.Exec("INSERT INTO fantasy_rosters (league_id, team_id, player_id, slot) VALUES (?, ?, ?, ?)",
+		leagueID, teamID, playerID, slot); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("INSERT INTO fantasy_lineup_stints (league_id, team_id, player_id, slot) VALUES (?, ?, ?, ?)",
+		leagueID, teamID, playerID, slot); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// MovePlayer 调整球员位置：结束当前位置记录并开始新位置的记录
+func MovePlayer(leagueID, teamID, playerID int, slot string) error {
+	tx, err := db.GetDB().Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("UPDATE fantasy_rosters SET slot = ? WHERE team_id = ? AND player_id = ?",
+		slot, teamID, playerID); err != nil {
+		return err
+	}
+	if err := endStint(tx, teamID, playerID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("INSERT INTO fantasy_lineup_stints (league_id, team_id, player_id, slot) VALUES (?, ?, ?, ?)",
+		leagueID, teamID, playerID, slot); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DropPlayer 裁掉球员并结束其位置记录
+func DropPlayer(teamID, playerID int) error {
+	tx, err := db.GetDB().Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM fantasy_rosters WHERE team_id = ? AND player_id = ?", teamID, playerID); err != nil {
+		return err
+	}
+	if err := endStint(tx, teamID, playerID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func endStint(tx *sql.Tx, teamID, playerID int) error {
+	_, err := tx.Exec("UPDATE fantasy_lineup_stints SET ended_at = CURRENT_TIMESTAMP WHERE team_id = ? AND player_id = ? AND ended_at IS NULL",
+		teamID, playerID)
+	return err
+}
+
+// lineupStint 球员在计分位置上的一段时间，EndedAt 为零值表示仍在该位置
+type lineupStint struct {
+	PlayerID  int
+	StartedAt time.Time
+	EndedAt   time.Time
+}
+
+// covers 开赛时球员是否在该位置上
+func (s lineupStint) covers(tipOff time.Time) bool {
+	return !tipOff.Before(s.StartedAt) && (s.EndedAt.IsZero() || tipOff.Before(s.EndedAt))
+}
+
+// scoringStints 球队在 since 之后仍有效的计分（非替补）位置记录
+func scoringStints(teamID int, since time.Time) ([]lineupStint, error) {
+	rows, err := db.GetDB().Query(`
+		SELECT player_id, slot, started_at, ended_at FROM fantasy_lineup_stints
+		WHERE team_id = ? AND (ended_at IS NULL OR ended_at > ?)
+		ORDER BY started_at, id
+	`, teamID, since.UTC().Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stints []lineupStint
+	for rows.Next() {
+		var st lineupStint
+		var slot string
+		var ended sql.NullTime
+		if err := rows.Scan(&st.PlayerID, &slot, &st.StartedAt, &ended); err != nil {
+			return nil, err
+		}
+		if !Scores(slot) {
+			continue
+		}
+		if ended.Valid {
+			st.EndedAt = ended.Time
+		}
+		stints = append(stints, st)
+	}
+	return stints, rows.Err()
+}
+
+const matchupColumns = "id, league_id, week, start_date, end_date, home_team_id, away_team_id, home_score, away_score, winner_team_id, status, details, updated_at"
+
+// Matchups 联赛对阵，week 为 0 时返回全部
+func Matchups(leagueID int, week int) ([]model.FantasyMatchup, error) {
+	query := "SELECT " + matchupColumns + " FROM fantasy_matchups WHERE league_id = ?"
+	args := []interface{}{leagueID}
+	if week > 0 {
+		query += " AND week = ?"
+		args = append(args, week)
+	}
+	rows, err := db.GetDB().Query(query+" ORDER BY week, id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	matchups := []model.FantasyMatchup{}
+	for rows.Next() {
+		var m model.FantasyMatchup
+		var winner sql.NullInt64
+		var details string
+		if err := rows.Scan(&m.ID, &m.LeagueID, &m.Week, &m.StartDate, &m.EndDate, &m.HomeTeamID, &m.AwayTeamID,
+			&m.HomeScore, &m.AwayScore, &winner, &m.Status, &details, &m.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if winner.Valid {
+			id := int(winner.Int64)
+			m.WinnerTeamID = &id
+		}
+		m.Details = json.RawMessage(details)
+		matchups = append(matchups, m)
+	}
+	return matchups, rows.Err()
+}
+
+// StartLeague 生成整个赛季的对阵并把联赛置为进行中
+func StartLeague(league *model.FantasyLeague, teams []model.FantasyTeam) error {
+	ids := make([]int, len(teams))
+	for i, t := range teams {
+		ids[i] = t.ID
+	}
+
+	tx, err := db.GetDB().Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// 只有招募中的联赛可以开始（防止重复生成赛程）
+	result, err := tx.Exec("UPDATE fantasy_leagues SET status = ? WHERE id = ? AND status = ?",
+		model.FantasyLeagueActive, league.ID, model.FantasyLeaguePending)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO fantasy_matchups (league_id, week, start_date, end_date, home_team_id, away_team_id)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for i, pairings := range RoundRobin(ids, league.Weeks) {
+		week := i + 1
+		start, end, err := WeekRange(league.StartDate, week)
+		if err != nil {
+			return err
+		}
+		for _, p := range pairings {
+			if _, err := stmt.Exec(league.ID, week, start, end, p.Home, p.Away); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// saveMatchupScore 保存对阵比分，final 时写入胜者
+func saveMatchupScore(m model.FantasyMatchup, score MatchupScore, status string) error {
+	details, err := json.Marshal(score.Details)
+	if err != nil {
+		return err
+	}
+	var winner interface{}
+	if status == model.FantasyMatchupFinal {
+		switch score.Winner() {
+		case 1:
+			winner = m.HomeTeamID
+		case -1:
+			winner = m.AwayTeamID
+		}
+	}
+	_, err = db.GetDB().Exec(`
+		UPDATE fantasy_matchups
+		SET home_score = ?, away_score = ?, winner_team_id = ?, status = ?, details = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, score.Home, score.Away, winner, status, string(details), m.ID)
+	return err
+}
+
+// updateStandings 由已结束的对阵重新汇总联赛中每支球队的战绩
+func updateStandings(leagueID int) error {
+	_, err := db.GetDB().Exec(`
+		UPDATE fantasy_teams SET
+			wins = (SELECT COUNT(*) FROM fantasy_matchups m
+				WHERE m.status = 'final' AND m.winner_team_id = fantasy_teams.id),
+			losses = (SELECT COUNT(*) FROM fantasy_matchups m
+				WHERE m.status = 'final' AND m.winner_team_id IS NOT NULL AND m.winner_team_id != fantasy_teams.id
+				AND (m.home_team_id = fantasy_teams.id OR m.away_team_id = fantasy_teams.id)),
+			ties = (SELECT COUNT(*) FROM fantasy_matchups m
+				WHERE m.status = 'final' AND m.winner_team_id IS NULL
+				AND (m.home_team_id = fantasy_teams.id OR m.away_team_id = fantasy_teams.id)),
+			points_for = (SELECT COALESCE(SUM(CASE WHEN m.home_team_id = fantasy_teams.id THEN m.home_score ELSE m.away_score END), 0)
+				FROM fantasy_matchups m
+				WHERE m.status = 'final' AND (m.home_team_id = fantasy_teams.id OR m.away_team_id = fantasy_teams.id)),
+			points_against = (SELECT COALESCE(SUM(CASE WHEN m.home_team_id = fantasy_teams.id THEN m.away_score ELSE m.home_score END), 0)
+				FROM fantasy_matchups m
+				WHERE m.status = 'final' AND (m.home_team_id = fantasy_teams.id OR m.away_team_id = fantasy_teams.id))
+		WHERE league_id = ?
+	`, leagueID)
+	return err
+}
+
+// finishLeagueIfDone 所有对阵都已结束时把联赛置为已结束
+func finishLeagueIfDone(leagueID int) error {
+	_, err := db.GetDB().Exec(`
+		UPDATE fantasy_leagues SET status = ?
+		WHERE id = ? AND status = ?
+		AND NOT EXISTS (SELECT 1 FROM fantasy_matchups WHERE league_id = ? AND status != 'final')
+	`, model.FantasyLeagueFinished, leagueID, model.FantasyLeagueActive, leagueID)
+	return err
+}
@@ -0,0 +1,54 @@
+package feed
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Builder 定时为活跃用户重算推荐流
+type Builder struct {
+	interval time.Duration
+	now      func() time.Time
+}
+
+// NewBuilder 创建推荐流重算任务
+func NewBuilder(interval time.Duration) *Builder {
+	return &Builder{interval: interval, now: time.Now}
+}
+
+// Start 启动后台任务（立即执行一次，之后按间隔执行，ctx 结束时退出）
+func (b *Builder) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(b.interval)
+		defer ticker.Stop()
+
+		for {
+			b.RunOnce(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// RunOnce 为最近请求过推荐流的用户逐个重算，某个用户失败不影响其他用户
+func (b *Builder) RunOnce(ctx context.Context) {
+	now := b.now().UTC()
+	users, err := activeUsers(now)
+	if err != nil {
+		log.Printf("Feed: list active users failed: %v", err)
+		return
+	}
+	for _, userID := range users {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := Rebuild(userID, now); err != nil {
+			log.Printf("Feed: rebuild timeline for user %d failed: %v", userID, err)
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package feed
+
+import (
+	"buzzerbeater/db"
+	"buzzerbeater/external"
+	"buzzerbeater/model"
+	"buzzerbeater/social"
+	"buzzerbeater/stats"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// 候选内容范围
+const (
+	candidateWindow   = 72 * time.Hour // 只考虑最近 3 天的内容
+	maxPostCandidates = 500
+	trendingPosts     = 50 // 额外纳入互动量最高的帖子，让推荐流不局限于关注
+)
+
+// LoadAffinity 读取用户的主队（通过本地数据仓库换算为 balldontlie ID）、关注的用户和 NBA 球队/球员
+func LoadAffinity(userID int) (Affinity, error) {
+	a := Affinity{UserID: userID, Users: map[int]bool{}, Teams: map[int]bool{}, Players: map[int]bool{}}
+	err := db.GetDB().QueryRow(`
+		SELECT n.id FROM users u
+		JOIN teams t ON t.id = u.team_id
+		JOIN nba_teams n ON n.abbreviation = t.code
+		WHERE u.id = ?
+	`, userID).Scan(&a.HomeTeamID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return a, err
+	}
+
+	rows, err := db.GetDB().Query(`
+		SELECT 'user', followee_id FROM user_follows WHERE follower_id = ?
+		UNION ALL
+		SELECT target_type, target_id FROM nba_follows WHERE user_id = ?
+	`, userID, userID)
+	if err != nil {
+		return a, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var kind string
+		var id int
+		if err := rows.Scan(&kind, &id); err != nil {
+			return a, err
+		}
+		switch kind {
+		case "user":
+			a.Users[id] = true
+		case model.FollowTargetTeam:
+			a.Teams[id] = true
+		case model.FollowTargetPlayer:
+			a.Players[id] = true
+		}
+	}
+	return a, rows.Err()
+}
+
+// Candidates 为用户收集候选内容：帖子、主队和关注球队的比赛结果、关注球员的单场表现
+func Candidates(a Affinity, now time.Time) ([]Candidate, error) {
+	since := now.Add(-candidateWindow).UTC()
+	posts, err := postCandidates(a, since)
+	if err != nil {
+		return nil, err
+	}
+	games, err := gameCandidates(a, since)
+	if err != nil {
+		return nil, err
+	}
+	performances, err := performanceCandidates(a, since)
+	if err != nil {
+		return nil, err
+	}
+	return append(append(posts, games...), performances...), nil
+}
+
+// postCandidates 自己和关注的用户发的帖子、关联主队或关注的球队/球员的帖子，以及近期互动量最高的帖子
+func postCandidates(a Affinity, since time.Time) ([]Candidate, error) {
+	rows, err := db.GetDB().Query(`
+		SELECT p.id, p.user_id, p.like_count + 2 * p.comment_count, p.created_at
+		FROM posts p
+		WHERE p.deleted_at IS NULL AND p.created_at >= ? AND `+social.BlockFilter("p.user_id")+`
+			AND (p.user_id = ?
+				OR p.user_id IN (SELECT followee_id FROM user_follows WHERE follower_id = ?)
+				OR EXISTS(SELECT 1 FROM post_tags t WHERE t.post_id = p.id AND (
+					(t.tag_type = 'team' AND (t.target_id = ? OR t.target_id IN
+						(SELECT target_id FROM nba_follows WHERE user_id = ? AND target_type = 'team')))
+					OR (t.tag_type = 'player' AND t.target_id IN
+						(SELECT target_id FROM nba_follows WHERE user_id = ? AND target_type = 'player'))))
+				OR p.id IN (SELECT id FROM posts WHERE deleted_at IS NULL AND created_at >= ?
+					ORDER BY like_count + 2 * comment_count DESC, id DESC LIMIT ?))
+		ORDER BY p.id DESC
+		LIMIT ?
+	`, since, a.UserID, a.UserID, a.UserID, a.UserID, a.HomeTeamID, a.UserID, a.UserID, since, trendingPosts, maxPostCandidates)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	candidates := []Candidate{}
+	index := map[int]int{}
+	var args []interface{}
+	for rows.Next() {
+		c := Candidate{Kind: model.FeedKindPost}
+		if err := rows.Scan(&c.RefID, &c.AuthorID, &c.Engagement, &c.OccurredAt); err != nil {
+			return nil, err
+		}
+		index[c.RefID] = len(candidates)
+		args = append(args, c.RefID)
+		candidates = append(candidates, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return candidates, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(args)), ",")
+	tagRows, err := db.GetDB().Query(
+		"SELECT post_id, tag_type, target_id FROM post_tags WHERE post_id IN ("+placeholders+") AND tag_type IN ('team', 'player')",
+		args...)
+	if err != nil {
+		return nil, err
+	}
+	defer tagRows.Close()
+	for tagRows.Next() {
+		var postID, targetID int
+		var tagType string
+		if err := tagRows.Scan(&postID, &tagType, &targetID); err != nil {
+			return nil, err
+		}
+		c := &candidates[index[postID]]
+		if tagType == model.PostTagTeam {
+			c.TeamIDs = append(c.TeamIDs, targetID)
+		} else {
+			c.PlayerIDs = append(c.PlayerIDs, targetID)
+		}
+	}
+	return candidates, tagRows.Err()
+}
+
+// teamIDs 主队和关注的球队
+func teamIDs(a Affinity) []interface{} {
+	ids := []interface{}{}
+	if a.HomeTeamID != 0 {
+		ids = append(ids, a.HomeTeamID)
+	}
+	for id := range a.Teams {
+		if id != a.HomeTeamID {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// gameCandidates 主队和关注球队已结束的比赛
+func gameCandidates(a Affinity, since time.Time) ([]Candidate, error) {
+	ids := teamIDs(a)
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	args := append([]interface{}{since.Format("2006-01-02")}, append(ids, ids...)...)
+	rows, err := db.GetDB().Query(`
+		SELECT g.id, g.date, g.datetime, g.postseason,
+			g.home_team_id, COALESCE(ht.abbreviation, ''), g.home_team_score,
+			g.visitor_team_id, COALESCE(vt.abbreviation, ''), g.visitor_team_score
+		FROM nba_games g
+		LEFT JOIN nba_teams ht ON ht.id = g.home_team_id
+		LEFT JOIN nba_teams vt ON vt.id = g.visitor_team_id
+		WHERE g.status = 'Final' AND g.date >= ?
+			AND (g.home_team_id IN (`+placeholders+`) OR g.visitor_team_id IN (`+placeholders+`))
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	candidates := []Candidate{}
+	for rows.Next() {
+		var g model.FeedGame
+		var datetime string
+		if err := rows.Scan(&g.GameID, &g.Date, &datetime, &g.Postseason, &g.HomeTeamID, &g.HomeTeam, &g.HomeScore,
+			&g.VisitorTeamID, &g.VisitorTeam, &g.VisitorScore); err != nil {
+			return nil, err
+		}
+		tipOff, err := (&external.NBAGame{Date: g.Date, Datetime: datetime}).TipOff()
+		if err != nil || tipOff.Before(since) {
+			continue
+		}
+		payload, err := json.Marshal(g)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, Candidate{
+			Kind:       model.FeedKindGameResult,
+			RefID:      g.GameID,
+			TeamIDs:    []int{g.HomeTeamID, g.VisitorTeamID},
+			OccurredAt: tipOff,
+			Payload:    string(payload),
+		})
+	}
+	return candidates, rows.Err()
+}
+
+// performanceCandidates 关注球员在已结束比赛中的单场数据（不含未出场）
+func performanceCandidates(a Affinity, since time.Time) ([]Candidate, error) {
+	if len(a.Players) == 0 {
+		return nil, nil
+	}
+	args := []interface{}{since.Format("2006-01-02")}
+	for id := range a.Players {
+		args = append(args, id)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(a.Players)), ",")
+	rows, err := db.GetDB().Query(`
+		SELECT s.id, s.player_id, COALESCE(p.first_name || ' ' || p.last_name, ''), s.team_id, s.game_id,
+			g.date, g.datetime,
+			COALESCE(CASE WHEN g.home_team_id = s.team_id THEN vt.abbreviation ELSE ht.abbreviation END, ''),
+			s.min, s.pts, s.reb, s.ast, s.stl, s.blk
+		FROM nba_player_stats s
+		JOIN nba_games g ON g.id = s.game_id
+		LEFT JOIN nba_players p ON p.id = s.player_id
+		LEFT JOIN nba_teams ht ON ht.id = g.home_team_id
+		LEFT JOIN nba_teams vt ON vt.id = g.visitor_team_id
+		WHERE g.status = 'Final' AND g.date >= ?
+			AND s.player_id IN (`+placeholders+`)
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	candidates := []Candidate{}
+	for rows.Next() {
+		var statID int
+		var datetime string
+		var p model.FeedPerformance
+		if err := rows.Scan(&statID, &p.PlayerID, &p.Name, &p.TeamID, &p.GameID, &p.Date, &datetime, &p.Opponent,
+			&p.Min, &p.Pts, &p.Reb, &p.Ast, &p.Stl, &p.Blk); err != nil {
+			return nil, err
+		}
+		if stats.ParseMinutes(p.Min) <= 0 {
+			continue
+		}
+		tipOff, err := (&external.NBAGame{Date: p.Date, Datetime: datetime}).TipOff()
+		if err != nil || tipOff.Before(since) {
+			continue
+		}
+		payload, err := json.Marshal(p)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, Candidate{
+			Kind:       model.FeedKindPerformance,
+			RefID:      statID,
+			TeamIDs:    []int{p.TeamID},
+			PlayerIDs:  []int{p.PlayerID},
+			Engagement: p.Pts + p.Reb + p.Ast,
+			OccurredAt: tipOff,
+			Payload:    string(payload),
+		})
+	}
+	return candidates, rows.Err()
+}
@@ -0,0 +1,118 @@
+package feed
+
+import (
+	"buzzerbeater/model"
+	"math"
+	"sort"
+	"time"
+)
+
+// 排序参数
+const (
+	halfLife         = 12 * time.Hour // 新鲜度每 12 小时减半
+	engagementWeight = 0.5            // 互动量取对数后的权重
+	followedUser     = 1.0            // 关注的用户发的帖子
+	ownPost          = 0.5            // 自己发的帖子
+	homeTeam         = 1.0            // 关联主队
+	followedTeam     = 0.75           // 关联关注的球队
+	followedPlayer   = 0.75           // 关联关注的球员
+)
+
+// kindWeights 各类内容的基础权重
+var kindWeights = map[string]float64{
+	model.FeedKindPost:        1.0,
+	model.FeedKindGameResult:  1.2,
+	model.FeedKindPerformance: 1.0,
+}
+
+// Candidate 候选内容
+type Candidate struct {
+	Kind       string
+	RefID      int // 帖子 ID / 比赛 ID / 球员单场数据 ID
+	AuthorID   int // 帖子作者，其他类型为 0
+	TeamIDs    []int
+	PlayerIDs  []int
+	Engagement int // 帖子为点赞数 + 2 × 评论数，球员表现为得分 + 篮板 + 助攻
+	OccurredAt time.Time
+	Payload    string // 比赛结果、球员表现的快照 JSON
+}
+
+// Affinity 用户的偏好：主队和关注（球队、球员为 balldontlie ID）
+type Affinity struct {
+	UserID     int
+	HomeTeamID int
+	Users      map[int]bool
+	Teams      map[int]bool
+	Players    map[int]bool
+}
+
+// Ranked 排序后的内容
+type Ranked struct {
+	Candidate
+	Score float64
+}
+
+// Score 内容得分 = 类型权重 × 新鲜度 × 互动加成 × 偏好加成
+// 新鲜度按半衰期指数衰减（未来时间按刚发生处理），互动加成为 1 + 0.5 × ln(1 + 互动量)，
+// 偏好加成为 1 加上命中的各项偏好（同一类偏好只计一次）
+func Score(c Candidate, a Affinity, now time.Time) float64 {
+	age := now.Sub(c.OccurredAt)
+	if age < 0 {
+		age = 0
+	}
+	recency := math.Pow(0.5, float64(age)/float64(halfLife))
+	engagement := 1 + engagementWeight*math.Log1p(float64(max(c.Engagement, 0)))
+	return kindWeights[c.Kind] * recency * engagement * affinityBoost(c, a)
+}
+
+func affinityBoost(c Candidate, a Affinity) float64 {
+	boost := 1.0
+	switch {
+	case c.AuthorID == 0:
+	case c.AuthorID == a.UserID:
+		boost += ownPost
+	case a.Users[c.AuthorID]:
+		boost += followedUser
+	}
+
+	var home, team bool
+	for _, id := range c.TeamIDs {
+		home = home || (a.HomeTeamID != 0 && id == a.HomeTeamID)
+		team = team || a.Teams[id]
+	}
+	if home {
+		boost += homeTeam
+	}
+	if team {
+		boost += followedTeam
+	}
+	for _, id := range c.PlayerIDs {
+		if a.Players[id] {
+			boost += followedPlayer
+			break
+		}
+	}
+	return boost
+}
+
+// Rank 按得分从高到低排序；得分相同时新的在前，再按类型和 ID 排序，保证同样的输入得到同样的结果
+func Rank(candidates []Candidate, a Affinity, now time.Time) []Ranked {
+	ranked := make([]Ranked, len(candidates))
+	for i, c := range candidates {
+		ranked[i] = Ranked{Candidate: c, Score: Score(c, a, now)}
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		x, y := ranked[i], ranked[j]
+		switch {
+		case x.Score != y.Score:
+			return x.Score > y.Score
+		case !x.OccurredAt.Equal(y.OccurredAt):
+			return x.OccurredAt.After(y.OccurredAt)
+		case x.Kind != y.Kind:
+			return x.Kind < y.Kind
+		default:
+			return x.RefID < y.RefID
+		}
+	})
+	return ranked
+}
@@ -0,0 +1,150 @@
+package feed
+
+import (
+	"buzzerbeater/model"
+	"math"
+	"strconv"
+	"testing"
+	"time"
+)
+
+var testNow = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func approx(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestScore(t *testing.T) {
+	viewer := Affinity{
+		UserID:     1,
+		HomeTeamID: 10,
+		Users:      map[int]bool{2: true},
+		Teams:      map[int]bool{20: true},
+		Players:    map[int]bool{300: true},
+	}
+	post := func(author int, age time.Duration) Candidate {
+		return Candidate{Kind: model.FeedKindPost, RefID: 1, AuthorID: author, OccurredAt: testNow.Add(-age)}
+	}
+
+	tests := []struct {
+		name string
+		c    Candidate
+		want float64
+	}{
+		{"fresh post from stranger", post(9, 0), 1},
+		{"one half-life old", post(9, halfLife), 0.5},
+		{"two half-lives old", post(9, 2*halfLife), 0.25},
+		{"future time counts as fresh", post(9, -time.Hour), 1},
+		{"engagement", Candidate{Kind: model.FeedKindPost, AuthorID: 9, Engagement: 10, OccurredAt: testNow},
+			1 + engagementWeight*math.Log1p(10)},
+		{"negative engagement ignored", Candidate{Kind: model.FeedKindPost, AuthorID: 9, Engagement: -5, OccurredAt: testNow}, 1},
+		{"own post", post(1, 0), 1 + ownPost},
+		{"followed user", post(2, 0), 1 + followedUser},
+		{"game kind weight", Candidate{Kind: model.FeedKindGameResult, TeamIDs: []int{98, 99}, OccurredAt: testNow},
+			kindWeights[model.FeedKindGameResult]},
+		{"home team", Candidate{Kind: model.FeedKindGameResult, TeamIDs: []int{10, 99}, OccurredAt: testNow},
+			kindWeights[model.FeedKindGameResult] * (1 + homeTeam)},
+		{"followed team", Candidate{Kind: model.FeedKindGameResult, TeamIDs: []int{99, 20}, OccurredAt: testNow},
+			kindWeights[model.FeedKindGameResult] * (1 + followedTeam)},
+		{"home and followed team", Candidate{Kind: model.FeedKindGameResult, TeamIDs: []int{10, 20}, OccurredAt: testNow},
+			kindWeights[model.FeedKindGameResult] * (1 + homeTeam + followedTeam)},
+		{"followed player", Candidate{Kind: model.FeedKindPerformance, TeamIDs: []int{99}, PlayerIDs: []int{300},
+			OccurredAt: testNow}, 1 + followedPlayer},
+		{"followed player counted once", Candidate{Kind: model.FeedKindPerformance, PlayerIDs: []int{300, 300},
+			OccurredAt: testNow}, 1 + followedPlayer},
+		{"all boosts with decay", Candidate{Kind: model.FeedKindPerformance, TeamIDs: []int{10, 20},
+			PlayerIDs: []int{300}, Engagement: 3, OccurredAt: testNow.Add(-halfLife)},
+			0.5 * (1 + engagementWeight*math.Log1p(3)) * (1 + homeTeam + followedTeam + followedPlayer)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Score(tt.c, viewer, testNow); !approx(got, tt.want) {
+				t.Errorf("Score = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScoreWithoutHomeTeam(t *testing.T) {
+	// 没有设置主队时，球队 ID 为 0 的内容不应被当作主队相关
+	c := Candidate{Kind: model.FeedKindGameResult, TeamIDs: []int{0}, OccurredAt: testNow}
+	if got, want := Score(c, Affinity{UserID: 1}, testNow), kindWeights[model.FeedKindGameResult]; !approx(got, want) {
+		t.Errorf("Score = %v, want %v", got, want)
+	}
+}
+
+func TestRank(t *testing.T) {
+	viewer := Affinity{UserID: 1, HomeTeamID: 10, Users: map[int]bool{2: true}}
+	hourAgo := testNow.Add(-time.Hour)
+
+	tests := []struct {
+		name       string
+		candidates []Candidate
+		want       []string // kind:refID
+	}{
+		{
+			name: "higher score first",
+			candidates: []Candidate{
+				{Kind: model.FeedKindPost, RefID: 1, AuthorID: 9, OccurredAt: testNow},
+				{Kind: model.FeedKindPost, RefID: 2, AuthorID: 2, OccurredAt: testNow},
+				{Kind: model.FeedKindGameResult, RefID: 3, TeamIDs: []int{10}, OccurredAt: testNow},
+			},
+			want: []string{"game_result:3", "post:2", "post:1"},
+		},
+		{
+			name: "recency beats small boost",
+			candidates: []Candidate{
+				{Kind: model.FeedKindPost, RefID: 1, AuthorID: 2, OccurredAt: testNow.Add(-3 * halfLife)},
+				{Kind: model.FeedKindPost, RefID: 2, AuthorID: 9, OccurredAt: testNow},
+			},
+			want: []string{"post:2", "post:1"},
+		},
+		{
+			// 未来时间按刚发生计分，得分相同时更晚的排在前面
+			name: "equal score newer first",
+			candidates: []Candidate{
+				{Kind: model.FeedKindPost, RefID: 1, AuthorID: 9, OccurredAt: testNow},
+				{Kind: model.FeedKindPost, RefID: 2, AuthorID: 9, OccurredAt: testNow.Add(time.Minute)},
+			},
+			want: []string{"post:2", "post:1"},
+		},
+		{
+			name: "equal score and time by kind then id",
+			candidates: []Candidate{
+				{Kind: model.FeedKindPost, RefID: 7, AuthorID: 9, OccurredAt: hourAgo},
+				{Kind: model.FeedKindPerformance, RefID: 5, OccurredAt: hourAgo},
+				{Kind: model.FeedKindPost, RefID: 3, AuthorID: 9, OccurredAt: hourAgo},
+				{Kind: model.FeedKindPerformance, RefID: 4, OccurredAt: hourAgo},
+			},
+			want: []string{"player_performance:4", "player_performance:5", "post:3", "post:7"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ranked := Rank(tt.candidates, viewer, testNow)
+			if len(ranked) != len(tt.want) {
+				t.Fatalf("got %d items, want %d", len(ranked), len(tt.want))
+			}
+			for i, r := range ranked {
+				if got := r.Kind + ":" + strconv.Itoa(r.RefID); got != tt.want[i] {
+					t.Errorf("ranked[%d] = %s, want %s", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRankIsDeterministic(t *testing.T) {
+	candidates := []Candidate{
+		{Kind: model.FeedKindPost, RefID: 2, AuthorID: 9, OccurredAt: testNow},
+		{Kind: model.FeedKindPost, RefID: 1, AuthorID: 9, OccurredAt: testNow},
+		{Kind: model.FeedKindPost, RefID: 3, AuthorID: 9, OccurredAt: testNow},
+	}
+	reversed := []Candidate{candidates[2], candidates[1], candidates[0]}
+	a, b := Rank(candidates, Affinity{UserID: 1}, testNow), Rank(reversed, Affinity{UserID: 1}, testNow)
+	for i := range a {
+		if a[i].RefID != b[i].RefID || a[i].RefID != i+1 {
+			t.Fatalf("ranked[%d] = %d / %d, want %d", i, a[i].RefID, b[i].RefID, i+1)
+		}
+	}
+}
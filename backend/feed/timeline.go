@@ -0,0 +1,228 @@
+package feed
+
+import (
+	"buzzerbeater/db"
+	"buzzerbeater/model"
+	"buzzerbeater/post"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// 预计算参数
+const (
+	timelineSize = 200              // 每个用户保留的条目数
+	StaleAfter   = 15 * time.Minute // 超过这个时间的推荐流在请求时重算
+	activeWindow = 24 * time.Hour   // 最近请求过推荐流的用户由后台定时重算
+)
+
+// Rebuild 重新计算用户的推荐流并整体替换
+func Rebuild(userID int, now time.Time) error {
+	affinity, err := LoadAffinity(userID)
+	if err != nil {
+		return err
+	}
+	candidates, err := Candidates(affinity, now)
+	if err != nil {
+		return err
+	}
+	ranked := Rank(candidates, affinity, now)
+	if len(ranked) > timelineSize {
+		ranked = ranked[:timelineSize]
+	}
+
+	tx, err := db.GetDB().Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM feed_timelines WHERE user_id = ?", userID); err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare(`
+		INSERT INTO feed_timelines (user_id, position, kind, ref_id, score, occurred_at, payload)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for i, r := range ranked {
+		if _, err := stmt.Exec(userID, i, r.Kind, r.RefID, r.Score, r.OccurredAt.UTC(), r.Payload); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO feed_timeline_state (user_id, computed_at) VALUES (?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET computed_at = excluded.computed_at
+	`, userID, now.UTC()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ComputedAt 用户推荐流的计算时间，从未计算过时返回零值
+func ComputedAt(userID int) (time.Time, error) {
+	var computedAt sql.NullTime
+	err := db.GetDB().QueryRow("SELECT computed_at FROM feed_timeline_state WHERE user_id = ?", userID).Scan(&computedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	return computedAt.Time, err
+}
+
+// Touch 记录用户请求推荐流的时间
+func Touch(userID int, now time.Time) error {
+	_, err := db.GetDB().Exec(`
+		INSERT INTO feed_timeline_state (user_id, requested_at) VALUES (?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET requested_at = excluded.requested_at
+	`, userID, now.UTC())
+	return err
+}
+
+// activeUsers 最近请求过推荐流的用户
+func activeUsers(now time.Time) ([]int, error) {
+	rows, err := db.GetDB().Query("SELECT user_id FROM feed_timeline_state WHERE requested_at >= ? ORDER BY user_id",
+		now.Add(-activeWindow).UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		users = append(users, id)
+	}
+	return users, rows.Err()
+}
+
+// ErrInvalidCursor 无法解析的翻页游标
+var ErrInvalidCursor = errors.New("invalid feed cursor")
+
+// Cursor 推荐流的翻页位置：所属推荐流的计算时间和下一页的起始位置
+type Cursor struct {
+	ComputedAt time.Time
+	Position   int
+}
+
+// Encode 编码为不透明的字符串
+func (c Cursor) Encode() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%d", c.ComputedAt.UnixNano(), c.Position)))
+}
+
+// DecodeCursor 解析 Encode 生成的游标，空字符串表示第一页
+func DecodeCursor(s string) (Cursor, error) {
+	if s == "" {
+		return Cursor{}, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	var nanos int64
+	var position int
+	if _, err := fmt.Sscanf(string(raw), "%d:%d", &nanos, &position); err != nil || position < 0 {
+		return Cursor{}, ErrInvalidCursor
+	}
+	return Cursor{ComputedAt: time.Unix(0, nanos).UTC(), Position: position}, nil
+}
+
+// Page 从游标位置读取预计算推荐流的一页；帖子实时读取，计算之后被删除或作者被拉黑的帖子会被跳过，
+// 因此一页可能少于 limit 条。游标之后推荐流被重算过时从第一页重新开始（Restarted 为 true），
+// 避免新旧两份推荐流拼在一起出现重复或遗漏
+func Page(userID int, cursor Cursor, limit int) (*model.FeedPage, error) {
+	// 计算时间和条目在同一事务中读取，避免读到一半时被重算
+	tx, err := db.GetDB().Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var computedAt time.Time
+	err = tx.QueryRow("SELECT computed_at FROM feed_timeline_state WHERE user_id = ? AND computed_at IS NOT NULL",
+		userID).Scan(&computedAt)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	page := &model.FeedPage{Items: []model.FeedItem{}, ComputedAt: computedAt}
+	if !cursor.ComputedAt.IsZero() && !cursor.ComputedAt.Equal(computedAt) {
+		cursor = Cursor{}
+		page.Restarted = true
+	}
+
+	rows, err := tx.Query(`
+		SELECT position, kind, ref_id, score, occurred_at, payload
+		FROM feed_timelines
+		WHERE user_id = ? AND position >= ?
+		ORDER BY position
+		LIMIT ?
+	`, userID, cursor.Position, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type entry struct {
+		item     model.FeedItem
+		position int
+		refID    int
+		payload  string
+	}
+	var entries []entry
+	var postIDs []int
+	for rows.Next() {
+		var e entry
+		if err := rows.Scan(&e.position, &e.item.Kind, &e.refID, &e.item.Score, &e.item.OccurredAt, &e.payload); err != nil {
+			return nil, err
+		}
+		if e.item.Kind == model.FeedKindPost {
+			postIDs = append(postIDs, e.refID)
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if len(entries) > limit {
+		page.NextCursor = Cursor{ComputedAt: computedAt, Position: entries[limit].position}.Encode()
+		entries = entries[:limit]
+	}
+	posts, err := post.GetMany(postIDs, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		item := e.item
+		switch item.Kind {
+		case model.FeedKindPost:
+			if item.Post = posts[e.refID]; item.Post == nil {
+				continue
+			}
+		case model.FeedKindGameResult:
+			item.Game = &model.FeedGame{}
+			if err := json.Unmarshal([]byte(e.payload), item.Game); err != nil {
+				return nil, err
+			}
+		case model.FeedKindPerformance:
+			item.Performance = &model.FeedPerformance{}
+			if err := json.Unmarshal([]byte(e.payload), item.Performance); err != nil {
+				return nil, err
+			}
+		}
+		page.Items = append(page.Items, item)
+	}
+	return page, nil
+}
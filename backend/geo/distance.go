@@ -0,0 +1,39 @@
+package geo
+
+import "math"
+
+// earthRadius 地球平均半径（米）
+const earthRadius = 6371000.0
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+// Distance 两点间的球面距离（米，haversine 公式）
+func Distance(lat1, lng1, lat2, lng2 float64) float64 {
+	dLat := radians(lat2 - lat1)
+	dLng := radians(lng2 - lng1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(radians(lat1))*math.Cos(radians(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// BoxAround 以某点为中心、包含半径 radius（米）圆的矩形，超出经纬度范围的部分被截断
+func BoxAround(lat, lng, radius float64) Box {
+	dLat := radius / earthRadius * 180 / math.Pi
+	dLng := 180.0
+	if c := math.Cos(radians(lat)); c > 1e-9 {
+		dLng = math.Min(180, dLat/c)
+	}
+	return Box{
+		MinLat: math.Max(-90, lat-dLat),
+		MaxLat: math.Min(90, lat+dLat),
+		MinLng: math.Max(-180, lng-dLng),
+		MaxLng: math.Min(180, lng+dLng),
+	}
+}
+
+// ValidCoordinate 坐标是否在合法范围内
+func ValidCoordinate(lat, lng float64) bool {
+	return lat >= -90 && lat <= 90 && lng >= -180 && lng <= 180 && !math.IsNaN(lat) && !math.IsNaN(lng)
+}
@@ -0,0 +1,136 @@
+package geo
+
+import (
+	"math"
+	"strings"
+)
+
+// base32 geohash 使用的字符表
+const base32 = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// Precision 存储坐标时的 geohash 长度（约 4.8m × 4.8m）
+const Precision = 9
+
+// Box 经纬度矩形（不跨越 180° 经线）
+type Box struct {
+	MinLat, MinLng, MaxLat, MaxLng float64
+}
+
+// Contains 点是否在矩形内（含边界）
+func (b Box) Contains(lat, lng float64) bool {
+	return lat >= b.MinLat && lat <= b.MaxLat && lng >= b.MinLng && lng <= b.MaxLng
+}
+
+// Center 矩形中心
+func (b Box) Center() (float64, float64) {
+	return (b.MinLat + b.MaxLat) / 2, (b.MinLng + b.MaxLng) / 2
+}
+
+// Encode 把坐标编码为指定长度的 geohash
+func Encode(lat, lng float64, precision int) string {
+	latLo, latHi := -90.0, 90.0
+	lngLo, lngHi := -180.0, 180.0
+
+	var sb strings.Builder
+	bit, ch, even := 0, 0, true
+	for sb.Len() < precision {
+		if even {
+			mid := (lngLo + lngHi) / 2
+			if lng >= mid {
+				ch = ch<<1 | 1
+				lngLo = mid
+			} else {
+				ch <<= 1
+				lngHi = mid
+			}
+		} else {
+			mid := (latLo + latHi) / 2
+			if lat >= mid {
+				ch = ch<<1 | 1
+				latLo = mid
+			} else {
+				ch <<= 1
+				latHi = mid
+			}
+		}
+		even = !even
+		if bit++; bit == 5 {
+			sb.WriteByte(base32[ch])
+			bit, ch = 0, 0
+		}
+	}
+	return sb.String()
+}
+
+// Bounds geohash 单元格的范围
+func Bounds(hash string) Box {
+	box := Box{MinLat: -90, MaxLat: 90, MinLng: -180, MaxLng: 180}
+	even := true
+	for i := 0; i < len(hash); i++ {
+		idx := strings.IndexByte(base32, hash[i])
+		for mask := 16; mask > 0; mask >>= 1 {
+			if even {
+				mid := (box.MinLng + box.MaxLng) / 2
+				if idx&mask != 0 {
+					box.MinLng = mid
+				} else {
+					box.MaxLng = mid
+				}
+			} else {
+				mid := (box.MinLat + box.MaxLat) / 2
+				if idx&mask != 0 {
+					box.MinLat = mid
+				} else {
+					box.MaxLat = mid
+				}
+			}
+			even = !even
+		}
+	}
+	return box
+}
+
+// cellSize 指定长度的 geohash 单元格的高和宽（度）
+func cellSize(precision int) (float64, float64) {
+	bits := precision * 5
+	lngBits := (bits + 1) / 2
+	latBits := bits / 2
+	return 180 / math.Exp2(float64(latBits)), 360 / math.Exp2(float64(lngBits))
+}
+
+// Cover 覆盖矩形的 geohash 前缀，数量不超过 maxCells
+// 在满足数量限制的前提下取尽量长的前缀，查询时按前缀范围扫描索引，再按精确范围过滤
+func Cover(box Box, maxCells int) []string {
+	precision := Precision
+	for ; precision > 1; precision-- {
+		h, w := cellSize(precision)
+		rows := math.Floor(box.MaxLat/h) - math.Floor(box.MinLat/h) + 1
+		cols := math.Floor(box.MaxLng/w) - math.Floor(box.MinLng/w) + 1
+		if rows*cols <= float64(maxCells) {
+			break
+		}
+	}
+
+	seen := map[string]bool{}
+	var cells []string
+	for lat := box.MinLat; ; {
+		row := Bounds(Encode(lat, box.MinLng, precision))
+		for lng := box.MinLng; ; {
+			hash := Encode(lat, lng, precision)
+			if !seen[hash] {
+				seen[hash] = true
+				cells = append(cells, hash)
+			}
+			cell := Bounds(hash)
+			if cell.MaxLng > box.MaxLng || cell.MaxLng >= 180 {
+				break
+			}
+			lng = cell.MaxLng
+		}
+		if row.MaxLat > box.MaxLat || row.MaxLat >= 90 {
+			break
+		}
+		lat = row.MaxLat
+	}
+	return cells
+}
@@ -0,0 +1,164 @@
+package ledger
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrInsufficientCoins 记账后非发行账户余额为负
+	ErrInsufficientCoins = errors.New("insufficient coins")
+	// ErrDuplicate 幂等键已使用过，该操作已经记过账
+	ErrDuplicate = errors.New("ledger transaction already posted")
+	// ErrUnbalanced 凭证分录金额之和不为 0
+	ErrUnbalanced = errors.New("ledger transaction is unbalanced")
+)
+
+// 账户类型
+const (
+	accountUser   = "user"
+	accountEscrow = "escrow"
+	accountSystem = "system"
+)
+
+// AccountIssuance 发行账户：初始金币、每日奖励和管理员调整的对手方，允许为负
+const AccountIssuance = "system:issuance"
+
+// UserAccount 用户钱包账户
+func UserAccount(userID int) string {
+	return fmt.Sprintf("user:%d", userID)
+}
+
+// MarketAccount 竞猜盘口的下注托管账户，结算或退款后应归零
+func MarketAccount(marketID int) string {
+	return fmt.Sprintf("market:%d", marketID)
+}
+
+// Entry 一条分录，Amount 为正表示记入，为负表示转出
+type Entry struct {
+	Account string
+	Amount  int
+}
+
+// Posting 一笔待记账的凭证
+type Posting struct {
+	Key      string // 幂等键，同一业务操作必须使用相同的键
+	Kind     string
+	Memo     string
+	MarketID int // 关联的竞猜盘口，0 表示无
+	Entries  []Entry
+}
+
+// Transfer 从 from 转 amount 到 to 的两条分录
+func Transfer(from, to string, amount int) []Entry {
+	return []Entry{{Account: from, Amount: -amount}, {Account: to, Amount: amount}}
+}
+
+// querier *sql.DB 和 *sql.Tx 共有的查询方法
+type querier interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// ensureAccount 按账户编码的前缀创建账户（已存在时忽略）
+func ensureAccount(tx *sql.Tx, code string) error {
+	kind, id, _ := strings.Cut(code, ":")
+	var userID interface{}
+	allowNegative := false
+	switch kind {
+	case accountUser:
+		userID = id
+	case "market":
+		kind = accountEscrow
+	case accountSystem:
+		allowNegative = code == AccountIssuance
+	default:
+		return fmt.Errorf("unknown ledger account %q", code)
+	}
+	_, err := tx.Exec(
+		"INSERT OR IGNORE INTO ledger_accounts (code, type, user_id, allow_negative) VALUES (?, ?, ?, ?)",
+		code, kind, userID, allowNegative,
+	)
+	return err
+}
+
+// Post 在调用方的事务中记一笔凭证，返回凭证 ID
+// 幂等键已存在时返回已有凭证的 ID 和 ErrDuplicate，调用方应把该操作视为已完成；
+// 记账后任一不允许为负的账户余额小于 0 时返回 ErrInsufficientCoins，调用方应回滚事务
+func Post(tx *sql.Tx, p Posting) (int, error) {
+	if p.Key == "" {
+		return 0, errors.New("ledger posting requires an idempotency key")
+	}
+	if len(p.Entries) < 2 {
+		return 0, ErrUnbalanced
+	}
+	sum := 0
+	for _, e := range p.Entries {
+		if e.Amount == 0 {
+			return 0, fmt.Errorf("ledger entry for %s has zero amount", e.Account)
+		}
+		sum += e.Amount
+	}
+	if sum != 0 {
+		return 0, ErrUnbalanced
+	}
+
+	var existing int
+	err := tx.QueryRow("SELECT id FROM ledger_transactions WHERE idempotency_key = ?", p.Key).Scan(&existing)
+	if err == nil {
+		return existing, ErrDuplicate
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, err
+	}
+
+	var marketID interface{}
+	if p.MarketID > 0 {
+		marketID = p.MarketID
+	}
+	result, err := tx.Exec(
+		"INSERT INTO ledger_transactions (idempotency_key, kind, memo, market_id) VALUES (?, ?, ?, ?)",
+		p.Key, p.Kind, p.Memo, marketID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	txID, _ := result.LastInsertId()
+
+	for _, e := range p.Entries {
+		if err := ensureAccount(tx, e.Account); err != nil {
+			return 0, err
+		}
+		if _, err := tx.Exec("INSERT INTO ledger_entries (transaction_id, account, amount) VALUES (?, ?, ?)",
+			txID, e.Account, e.Amount); err != nil {
+			return 0, err
+		}
+	}
+
+	// 写入后再检查余额：事务已持有写锁，并发记账不会在检查之后插入
+	for _, e := range p.Entries {
+		if e.Amount > 0 {
+			continue
+		}
+		var balance int
+		var allowNegative bool
+		if err := tx.QueryRow(`
+			SELECT COALESCE((SELECT SUM(amount) FROM ledger_entries WHERE account = a.code), 0), a.allow_negative
+			FROM ledger_accounts a WHERE a.code = ?
+		`, e.Account).Scan(&balance, &allowNegative); err != nil {
+			return 0, err
+		}
+		if balance < 0 && !allowNegative {
+			return 0, ErrInsufficientCoins
+		}
+	}
+	return int(txID), nil
+}
+
+// Balance 账户余额（所有分录之和），账户不存在时为 0
+func Balance(q querier, account string) (int, error) {
+	var balance int
+	err := q.QueryRow("SELECT COALESCE(SUM(amount), 0) FROM ledger_entries WHERE account = ?", account).Scan(&balance)
+	return balance, err
+}
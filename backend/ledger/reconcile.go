@@ -0,0 +1,116 @@
+package ledger
+
+import (
+	"buzzerbeater/db"
+	"fmt"
+)
+
+// Report 对账结果
+type Report struct {
+	Accounts      int      // 账户数
+	Transactions  int      // 凭证数
+	Issued        int      // 发行总量（发行账户余额的相反数）
+	UserBalance   int      // 用户钱包余额合计
+	EscrowBalance int      // 盘口托管余额合计
+	Problems      []string // 发现的问题，为空表示账目正确
+}
+
+// Reconcile 校验整个账本：每笔凭证借贷平衡、账户存在、不允许为负的账户在任何时点都不为负、全部账户合计为 0
+func Reconcile() (*Report, error) {
+	r := &Report{}
+	conn := db.GetDB()
+
+	if err := conn.QueryRow("SELECT COUNT(*) FROM ledger_accounts").Scan(&r.Accounts); err != nil {
+		return nil, err
+	}
+	if err := conn.QueryRow("SELECT COUNT(*) FROM ledger_transactions").Scan(&r.Transactions); err != nil {
+		return nil, err
+	}
+
+	// 借贷不平或分录不足两条的凭证
+	rows, err := conn.Query(`
+		SELECT t.id, t.idempotency_key, COUNT(e.id), COALESCE(SUM(e.amount), 0)
+		FROM ledger_transactions t
+		LEFT JOIN ledger_entries e ON e.transaction_id = t.id
+		GROUP BY t.id
+		HAVING COUNT(e.id) < 2 OR COALESCE(SUM(e.amount), 0) != 0
+	`)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var id, count, sum int
+		var key string
+		if err := rows.Scan(&id, &key, &count, &sum); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		r.Problems = append(r.Problems, fmt.Sprintf("transaction %d (%s): %d entries summing to %d", id, key, count, sum))
+	}
+	rows.Close()
+
+	// 引用了不存在的账户或凭证的分录
+	rows, err = conn.Query(`
+		SELECT e.id, e.account, e.transaction_id FROM ledger_entries e
+		WHERE NOT EXISTS (SELECT 1 FROM ledger_accounts a WHERE a.code = e.account)
+		   OR NOT EXISTS (SELECT 1 FROM ledger_transactions t WHERE t.id = e.transaction_id)
+	`)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var id, txID int
+		var account string
+		if err := rows.Scan(&id, &account, &txID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		r.Problems = append(r.Problems, fmt.Sprintf("entry %d: unknown account %s or transaction %d", id, account, txID))
+	}
+	rows.Close()
+
+	// 不允许为负的账户：按分录顺序累计，任何时点余额都不能小于 0
+	rows, err = conn.Query(`
+		SELECT account, MIN(running), MAX(CASE WHEN last THEN running END)
+		FROM (
+			SELECT e.account,
+			       SUM(e.amount) OVER (PARTITION BY e.account ORDER BY e.id) AS running,
+			       e.id = MAX(e.id) OVER (PARTITION BY e.account) AS last
+			FROM ledger_entries e
+			JOIN ledger_accounts a ON a.code = e.account AND a.allow_negative = 0
+		)
+		GROUP BY account
+		HAVING MIN(running) < 0
+	`)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var account string
+		var lowest, balance int
+		if err := rows.Scan(&account, &lowest, &balance); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		r.Problems = append(r.Problems, fmt.Sprintf("account %s went negative (lowest %d, balance %d)", account, lowest, balance))
+	}
+	rows.Close()
+
+	// 各类账户余额合计，全部相加应为 0
+	var system int
+	if err := conn.QueryRow(`
+		SELECT
+			COALESCE(SUM(CASE WHEN a.type = 'user' THEN e.amount END), 0),
+			COALESCE(SUM(CASE WHEN a.type = 'escrow' THEN e.amount END), 0),
+			COALESCE(SUM(CASE WHEN a.type = 'system' THEN e.amount END), 0)
+		FROM ledger_entries e
+		JOIN ledger_accounts a ON a.code = e.account
+	`).Scan(&r.UserBalance, &r.EscrowBalance, &system); err != nil {
+		return nil, err
+	}
+	r.Issued = -system
+	if total := r.UserBalance + r.EscrowBalance + system; total != 0 {
+		r.Problems = append(r.Problems, fmt.Sprintf("ledger does not sum to zero: %d", total))
+	}
+	return r, nil
+}
@@ -0,0 +1,135 @@
+package ledger
+
+import (
+	"buzzerbeater/db"
+	"buzzerbeater/model"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// 发放金额
+const (
+	StartingCoins = 1000 // 新钱包的初始金币
+	DailyBonus    = 100  // 每日登录奖励
+)
+
+// bonusZone 每日奖励按北京时间零点重置
+var bonusZone = time.FixedZone("CST", 8*60*60)
+
+// EnsureWallet 在调用方的事务中为用户发放初始金币（每个用户只发一次）
+func EnsureWallet(tx *sql.Tx, userID int) error {
+	_, err := Post(tx, Posting{
+		Key:     fmt.Sprintf("grant:%d", userID),
+		Kind:    model.LedgerGrant,
+		Entries: Transfer(AccountIssuance, UserAccount(userID), StartingCoins),
+	})
+	if errors.Is(err, ErrDuplicate) {
+		return nil
+	}
+	return err
+}
+
+// ClaimDailyBonus 领取当天的登录奖励，当天已领取过时 claimed 为 false
+func ClaimDailyBonus(userID int, now time.Time) (claimed bool, err error) {
+	tx, err := db.GetDB().Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if err := EnsureWallet(tx, userID); err != nil {
+		return false, err
+	}
+	day := now.In(bonusZone).Format("2006-01-02")
+	_, err = Post(tx, Posting{
+		Key:     fmt.Sprintf("daily_bonus:%d:%s", userID, day),
+		Kind:    model.LedgerDailyBonus,
+		Memo:    day,
+		Entries: Transfer(AccountIssuance, UserAccount(userID), DailyBonus),
+	})
+	if errors.Is(err, ErrDuplicate) {
+		return false, tx.Commit()
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// Adjust 管理员调整用户余额（amount 为负时扣减，扣减后不能为负）
+func Adjust(userID int, amount int, memo string, key string) (int, error) {
+	if amount == 0 {
+		return 0, errors.New("adjustment amount must not be zero")
+	}
+	tx, err := db.GetDB().Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if err := EnsureWallet(tx, userID); err != nil {
+		return 0, err
+	}
+	id, err := Post(tx, Posting{
+		Key:     "adjustment:" + key,
+		Kind:    model.LedgerAdjustment,
+		Memo:    memo,
+		Entries: Transfer(AccountIssuance, UserAccount(userID), amount),
+	})
+	if err != nil {
+		return id, err
+	}
+	return id, tx.Commit()
+}
+
+// Wallet 用户钱包及最近 limit 条流水（最新的在前），第一次访问时发放初始金币
+func Wallet(userID int, limit int) (*model.CoinWallet, error) {
+	tx, err := db.GetDB().Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	if err := EnsureWallet(tx, userID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	account := UserAccount(userID)
+	wallet := &model.CoinWallet{UserID: userID, Transactions: []model.CoinTransaction{}}
+	if wallet.Balance, err = Balance(db.GetDB(), account); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.GetDB().Query(`
+		SELECT t.id, t.kind, e.amount, e.balance_after, t.memo, t.market_id, t.created_at
+		FROM (
+			SELECT transaction_id, amount, SUM(amount) OVER (ORDER BY id) AS balance_after, id
+			FROM ledger_entries WHERE account = ?
+		) e
+		JOIN ledger_transactions t ON t.id = e.transaction_id
+		ORDER BY e.id DESC
+		LIMIT ?
+	`, account, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var t model.CoinTransaction
+		var marketID sql.NullInt64
+		if err := rows.Scan(&t.ID, &t.Kind, &t.Amount, &t.BalanceAfter, &t.Memo, &marketID, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		if marketID.Valid {
+			id := int(marketID.Int64)
+			t.MarketID = &id
+		}
+		wallet.Transactions = append(wallet.Transactions, t)
+	}
+	return wallet, rows.Err()
+}
@@ -4,7 +4,15 @@ import (
 	"buzzerbeater/api"
 	"buzzerbeater/config"
 	"buzzerbeater/db"
+	"buzzerbeater/external"
+	"buzzerbeater/fantasy"
+	"buzzerbeater/feed"
 	"buzzerbeater/middleware"
+	"buzzerbeater/notify"
+	"buzzerbeater/prediction"
+	"buzzerbeater/warehouse"
+	"context"
+	"flag"
 	"log"
 
 	"github.com/gin-gonic/gin"
@@ -14,10 +22,58 @@ func main() {
 	// 初始化配置
 	config.Init()
 
+	// 命令行参数优先于环境变量
+	flag.StringVar(&config.AppConfig.NBAMode, "nba-mode", config.AppConfig.NBAMode, "NBA 数据访问模式：live / record / replay")
+	flag.StringVar(&config.AppConfig.NBAFixturesDir, "nba-fixtures", config.AppConfig.NBAFixturesDir, "record/replay 模式的录制数据目录")
+	flag.Parse()
+	if !external.ValidMode(config.AppConfig.NBAMode) {
+		log.Fatalf("Invalid NBA data mode: %s", config.AppConfig.NBAMode)
+	}
+	if config.AppConfig.NBAMode != external.ModeLive {
+		log.Printf("NBA data mode: %s (fixtures: %s)", config.AppConfig.NBAMode, config.AppConfig.NBAFixturesDir)
+	}
+
 	// 初始化数据库
 	db.Init()
 	defer db.Close()
 
+	// 启动 NBA 数据定时同步
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if config.AppConfig.NBASyncEnabled {
+		warehouse.NewSyncer(external.Default(), config.AppConfig.NBASyncInterval, config.AppConfig.NBASyncSeason).Start(ctx)
+	}
+
+	// 启动 Fantasy 联赛定时计分
+	if config.AppConfig.FantasyScoringEnabled {
+		fantasy.NewScorer(external.Default(), config.AppConfig.FantasyScoringInterval).Start(ctx)
+	}
+
+	// 启动竞猜盘口定时开盘、封盘和结算
+	if config.AppConfig.PredictionSettleEnabled {
+		prediction.NewSettler(external.Default(), config.AppConfig.PredictionSettleInterval).Start(ctx)
+	}
+
+	// 通知投递渠道（站内信总是开启）
+	for _, name := range config.AppConfig.NotificationChannels {
+		switch name {
+		case "log":
+			notify.Register(notify.LogChannel{})
+		default:
+			log.Fatalf("Unknown notification channel: %s", name)
+		}
+	}
+
+	// 启动主队开赛提醒
+	if config.AppConfig.GameReminderEnabled {
+		notify.NewGameReminder(external.Default(), config.AppConfig.GameReminderInterval).Start(ctx)
+	}
+
+	// 启动推荐流定时重算
+	if config.AppConfig.FeedRankingEnabled {
+		feed.NewBuilder(config.AppConfig.FeedRankingInterval).Start(ctx)
+	}
+
 	// 创建 Gin 实例
 	r := gin.Default()
 
@@ -43,24 +99,132 @@ func main() {
 		apiGroup.POST("/session", api.CreateSession) // 登录
 		apiGroup.GET("/teams", api.GetTeams)         // 球队列表（本地数据）
 
+		// 日历订阅（通过 URL 中的 Token 鉴权，日历 App 无法携带 Authorization）
+		apiGroup.GET("/calendar/:token", api.GetCalendarFeed) // 主队赛程 .ics
+
 		// NBA 数据（公开）
-		apiGroup.GET("/nba/teams", api.GetNBATeams)     // NBA 球队列表
-		apiGroup.GET("/nba/players", api.GetNBAPlayers) // NBA 球员列表
+		apiGroup.GET("/nba/teams", api.GetNBATeams)                     // NBA 球队列表
+		apiGroup.GET("/nba/teams/:id/stats", api.GetNBATeamStats)       // 球队赛季数据
+		apiGroup.GET("/nba/teams/:id/history", api.GetNBATeamHistory)   // 球队搬迁和改名历史
+		apiGroup.GET("/nba/teams/:id/schedule", api.GetNBATeamSchedule) // 球队赛程
+		apiGroup.GET("/nba/teams/:id/roster", api.GetNBATeamRoster)     // 球队阵容
+		apiGroup.GET("/nba/standings", api.GetNBAStandings)             // 联盟排名
+		apiGroup.GET("/nba/leaders", api.GetNBALeaders)                 // 数据排行榜
+		apiGroup.GET("/nba/players", api.GetNBAPlayers)                 // NBA 球员列表
+		apiGroup.GET("/nba/sync", api.GetNBASyncStatus)                 // 本地数据同步状态
+
+		// 竞猜大厅（公开）
+		apiGroup.GET("/predictions/markets", api.GetPredictionMarkets)    // 盘口列表
+		apiGroup.GET("/predictions/markets/:id", api.GetPredictionMarket) // 盘口详情和赔率
+
+		// 约球：球场（公开，登录时过滤拉黑用户的内容）
+		apiGroup.GET("/courts", api.SearchCourts)                                           // 按半径或地图范围搜索球场
+		apiGroup.GET("/courts/:id", api.GetCourt)                                           // 球场详情
+		apiGroup.GET("/courts/:id/reviews", middleware.OptionalAuth(), api.GetCourtReviews) // 球场评价
+		apiGroup.GET("/pickups", middleware.OptionalAuth(), api.GetPickupEvents)            // 约球列表
+		apiGroup.GET("/pickups/:id", middleware.OptionalAuth(), api.GetPickupEvent)         // 约球详情和报名名单
+
+		// 用户主页（公开，登录时附带关系并过滤拉黑用户）
+		apiGroup.GET("/users/:id", middleware.OptionalAuth(), api.GetUserProfile)                             // 用户主页
+		apiGroup.GET("/users/by-nickname/:nickname", middleware.OptionalAuth(), api.GetUserProfileByNickname) // 按昵称查看用户主页
+		apiGroup.GET("/users/:id/followers", middleware.OptionalAuth(), api.GetUserFollowers)                 // 粉丝
+		apiGroup.GET("/users/:id/following", middleware.OptionalAuth(), api.GetUserFollowing)                 // 关注的人
+
+		// 社区（公开，登录时附带点赞状态并过滤拉黑用户的内容）
+		apiGroup.GET("/users/:id/posts", middleware.OptionalAuth(), api.GetUserPosts)       // 用户发的帖子
+		apiGroup.GET("/posts/:id", middleware.OptionalAuth(), api.GetPost)                  // 帖子详情
+		apiGroup.GET("/posts/:id/comments", middleware.OptionalAuth(), api.GetPostComments) // 帖子评论
 
 		// ========== 需要认证的接口 ==========
 		authGroup := apiGroup.Group("")
 		authGroup.Use(middleware.Auth())
 		{
 			// 用户资源
-			authGroup.GET("/users/me", api.GetCurrentUser)      // 获取当前用户
-			authGroup.PUT("/users/me/avatar", api.UpdateAvatar) // 更新头像
-			authGroup.PUT("/users/me/team", api.UpdateTeam)     // 更新主队
+			authGroup.GET("/users/me", api.GetCurrentUser)                     // 获取当前用户
+			authGroup.GET("/users/me/dashboard", api.GetDashboard)             // 主队个性化首页
+			authGroup.PUT("/users/me/avatar", api.UpdateAvatar)                // 更新头像
+			authGroup.PUT("/users/me/team", api.UpdateTeam)                    // 更新主队
+			authGroup.GET("/users/me/calendar", api.GetCalendarSubscription)   // 主队日历订阅地址
+			authGroup.POST("/users/me/calendar/token", api.ResetCalendarToken) // 重置订阅地址
+			authGroup.GET("/users/me/follows", api.GetFollows)                 // 关注的球员和球队
+			authGroup.POST("/users/me/follows", api.CreateFollow)              // 关注
+			authGroup.DELETE("/users/me/follows/:type/:id", api.DeleteFollow)  // 取消关注
+			authGroup.GET("/users/me/follows/feed", api.GetFollowFeed)         // 关注球员动态
+
+			// 社交
+			authGroup.POST("/users/:id/follow", api.FollowUser)           // 关注用户
+			authGroup.DELETE("/users/:id/follow", api.UnfollowUser)       // 取消关注
+			authGroup.POST("/users/:id/block", api.BlockUser)             // 拉黑
+			authGroup.DELETE("/users/:id/block", api.UnblockUser)         // 取消拉黑
+			authGroup.GET("/users/me/friends", api.GetMyFriends)          // 我的好友（互相关注）
+			authGroup.GET("/users/me/blocks", api.GetMyBlocks)            // 黑名单
+			authGroup.GET("/users/me/privacy", api.GetPrivacySettings)    // 主页隐私设置
+			authGroup.PUT("/users/me/privacy", api.UpdatePrivacySettings) // 更新主页隐私设置
+
+			// 消息通知
+			authGroup.GET("/users/me/notifications", api.GetNotifications)                         // 我的通知
+			authGroup.POST("/users/me/notifications/read", api.MarkAllNotificationsRead)           // 全部已读
+			authGroup.POST("/users/me/notifications/:id/read", api.MarkNotificationRead)           // 标记已读
+			authGroup.GET("/users/me/notification-preferences", api.GetNotificationPreferences)    // 通知设置
+			authGroup.PUT("/users/me/notification-preferences", api.UpdateNotificationPreferences) // 更新通知设置
 
 			// 会话资源
 			authGroup.DELETE("/session", api.DeleteSession) // 注销
 
 			// NBA 数据（需要认证）
+			authGroup.GET("/nba/players/compare", api.CompareNBAPlayers)   // 球员对比
+			authGroup.GET("/nba/players/:id", api.GetNBAPlayer)            // 球员详情
 			authGroup.GET("/nba/players/:id/stats", api.GetNBAPlayerStats) // 球员统计
+
+			// Fantasy 联赛
+			authGroup.POST("/fantasy/leagues", api.CreateFantasyLeague)                      // 创建联赛
+			authGroup.GET("/fantasy/leagues", api.GetFantasyLeagues)                         // 我的联赛
+			authGroup.GET("/fantasy/leagues/:id", api.GetFantasyLeague)                      // 联赛详情
+			authGroup.POST("/fantasy/leagues/:id/start", api.StartFantasyLeague)             // 开始联赛（生成赛程）
+			authGroup.POST("/fantasy/leagues/:id/invitations", api.CreateFantasyInvitation)  // 邀请成员
+			authGroup.GET("/fantasy/leagues/:id/matchups", api.GetFantasyMatchups)           // 每周对阵
+			authGroup.GET("/fantasy/leagues/:id/standings", api.GetFantasyStandings)         // 联赛排名
+			authGroup.GET("/users/me/fantasy/invitations", api.GetFantasyInvitations)        // 收到的邀请
+			authGroup.POST("/fantasy/invitations/:id/accept", api.AcceptFantasyInvitation)   // 接受邀请
+			authGroup.POST("/fantasy/invitations/:id/decline", api.DeclineFantasyInvitation) // 拒绝邀请
+			authGroup.GET("/fantasy/teams/:id", api.GetFantasyTeam)                          // 球队阵容
+			authGroup.POST("/fantasy/teams/:id/roster", api.AddFantasyPlayer)                // 签入球员
+			authGroup.PUT("/fantasy/teams/:id/roster/:player_id", api.MoveFantasyPlayer)     // 调整位置
+			authGroup.DELETE("/fantasy/teams/:id/roster/:player_id", api.DropFantasyPlayer)  // 裁掉球员
+
+			// 竞猜
+			authGroup.POST("/predictions/markets", api.CreatePredictionMarket)           // 开设盘口
+			authGroup.POST("/predictions/markets/:id/stakes", api.CreatePredictionStake) // 下注
+			authGroup.GET("/users/me/wallet", api.GetWallet)                             // 金币余额和流水
+			authGroup.POST("/users/me/wallet/daily-bonus", api.ClaimDailyBonus)          // 领取每日登录奖励
+			authGroup.GET("/users/me/predictions", api.GetMyPredictions)                 // 我的竞猜
+
+			// 约球：球场
+			authGroup.POST("/courts", api.CreateCourt)                                                     // 新增球场
+			authGroup.PUT("/courts/:id", api.UpdateCourt)                                                  // 修改球场（仅创建者）
+			authGroup.POST("/courts/:id/reviews", api.CreateCourtReview)                                   // 发表评价
+			authGroup.PUT("/court-reviews/:id", api.UpdateCourtReview)                                     // 修改评价
+			authGroup.DELETE("/court-reviews/:id", api.DeleteCourtReview)                                  // 删除评价
+			authGroup.POST("/court-reviews/:id/helpful", api.VoteCourtReviewHelpful)                       // 评价“有用”
+			authGroup.DELETE("/court-reviews/:id/helpful", api.UnvoteCourtReviewHelpful)                   // 取消“有用”
+			authGroup.POST("/court-reviews/:id/takedown", middleware.Moderator(), api.TakeDownCourtReview) // 下架评价（管理员）
+
+			// 约球：活动
+			authGroup.POST("/pickups", api.CreatePickupEvent)            // 发起约球
+			authGroup.POST("/pickups/:id/rsvp", api.JoinPickupEvent)     // 报名（满员进入候补）
+			authGroup.DELETE("/pickups/:id/rsvp", api.LeavePickupEvent)  // 退出报名
+			authGroup.POST("/pickups/:id/cancel", api.CancelPickupEvent) // 取消约球（仅发起人）
+			authGroup.GET("/users/me/pickups", api.GetMyPickupEvents)    // 我的约球
+
+			// 社区
+			authGroup.GET("/feed", api.GetFeed)                           // 关注流
+			authGroup.GET("/feed/ranked", api.GetRankedFeed)              // 个性化推荐流
+			authGroup.POST("/posts", api.CreatePost)                      // 发帖
+			authGroup.DELETE("/posts/:id", api.DeletePost)                // 删除帖子
+			authGroup.POST("/posts/:id/like", api.LikePost)               // 点赞
+			authGroup.DELETE("/posts/:id/like", api.UnlikePost)           // 取消点赞
+			authGroup.POST("/posts/:id/comments", api.CreatePostComment)  // 发表评论或回复
+			authGroup.DELETE("/post-comments/:id", api.DeletePostComment) // 删除评论
 		}
 	}
 
@@ -43,3 +43,15 @@ func Auth() gin.HandlerFunc {
 	}
 }
 
+// OptionalAuth 可选认证：携带有效 Token 时写入用户 ID，否则按未登录处理（用于公开接口按用户过滤内容）
+func OptionalAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		parts := strings.SplitN(c.GetHeader("Authorization"), " ", 2)
+		if len(parts) == 2 && parts[0] == "Bearer" {
+			if userID, err := util.ParseToken(parts[1]); err == nil {
+				c.Set("user_id", userID)
+			}
+		}
+		c.Next()
+	}
+}
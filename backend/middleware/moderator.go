@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"buzzerbeater/config"
+	"buzzerbeater/util"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Moderator 管理员权限中间件（需在 Auth 之后使用），管理员由 MODERATOR_USER_IDS 配置
+func Moderator() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetInt("user_id")
+		for _, id := range config.AppConfig.ModeratorIDs {
+			if id == userID {
+				c.Next()
+				return
+			}
+		}
+		util.ErrorResponse(c, http.StatusForbidden, "需要管理员权限")
+		c.Abort()
+	}
+}
@@ -0,0 +1,64 @@
+package model
+
+import "time"
+
+// 球场地面材质
+const (
+	CourtSurfaceWood     = "wood"     // 木地板
+	CourtSurfaceAcrylic  = "acrylic"  // 丙烯酸
+	CourtSurfaceRubber   = "rubber"   // 塑胶 / 悬浮拼装
+	CourtSurfaceConcrete = "concrete" // 水泥
+	CourtSurfaceAsphalt  = "asphalt"  // 沥青
+	CourtSurfaceOther    = "other"
+)
+
+// OpeningPeriod 营业时段（HH:MM，close 可以为 24:00）
+type OpeningPeriod struct {
+	Open  string `json:"open"`
+	Close string `json:"close"`
+}
+
+// OpeningHours 每周营业时间，键为 mon…sun，缺少的日期表示当天不开放
+type OpeningHours map[string][]OpeningPeriod
+
+// Court 球场
+type Court struct {
+	ID           int          `json:"id"`
+	Name         string       `json:"name"`
+	Address      string       `json:"address"`
+	Latitude     float64      `json:"latitude"`
+	Longitude    float64      `json:"longitude"`
+	Surface      string       `json:"surface"`
+	Indoor       bool         `json:"indoor"`
+	Free         bool         `json:"free"`
+	Price        string       `json:"price,omitempty"`
+	OpeningHours OpeningHours `json:"opening_hours"` // 未知时为 null
+	Photos       []string     `json:"photos"`
+	Rating       *float64     `json:"rating"` // 平均评分（保留一位小数），没有评价时为 null
+	ReviewCount  int          `json:"review_count"`
+	CreatedBy    int          `json:"created_by"`
+	CreatedAt    time.Time    `json:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at"`
+	Distance     *float64     `json:"distance,omitempty"` // 距搜索中心的距离（米），仅搜索结果返回
+}
+
+// 评价状态
+const (
+	ReviewVisible = "visible"
+	ReviewRemoved = "removed" // 管理员下架
+)
+
+// CourtReview 球场评价
+type CourtReview struct {
+	ID           int       `json:"id"`
+	CourtID      int       `json:"court_id"`
+	UserID       int       `json:"user_id"`
+	Nickname     string    `json:"nickname"`
+	Avatar       string    `json:"avatar"`
+	Rating       int       `json:"rating"`
+	Content      string    `json:"content"`
+	Photos       []string  `json:"photos"`
+	HelpfulCount int       `json:"helpful_count"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
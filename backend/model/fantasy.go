@@ -0,0 +1,107 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Fantasy 联赛计分方式
+const (
+	FantasyScoringPoints   = "points"   // 积分制：按权重累加各项数据
+	FantasyScoringCategory = "category" // 类别制：逐项比较，赢下类别多者胜
+)
+
+// Fantasy 联赛状态
+const (
+	FantasyLeaguePending  = "pending"  // 招募中，可以邀请成员
+	FantasyLeagueActive   = "active"   // 赛程已生成，按周计分
+	FantasyLeagueFinished = "finished" // 所有对阵已结束
+)
+
+// Fantasy 邀请状态
+const (
+	FantasyInvitationPending  = "pending"
+	FantasyInvitationAccepted = "accepted"
+	FantasyInvitationDeclined = "declined"
+)
+
+// Fantasy 对阵状态
+const (
+	FantasyMatchupScheduled = "scheduled"
+	FantasyMatchupLive      = "live"
+	FantasyMatchupFinal     = "final"
+)
+
+// FantasyLeague Fantasy 联赛
+type FantasyLeague struct {
+	ID          int            `json:"id"`
+	Name        string         `json:"name"`
+	OwnerID     int            `json:"owner_id"`
+	Season      int            `json:"season"`
+	ScoringType string         `json:"scoring_type"`
+	Scoring     FantasyScoring `json:"scoring"`
+	RosterSlots map[string]int `json:"roster_slots"`
+	MaxTeams    int            `json:"max_teams"`
+	StartDate   string         `json:"start_date"`
+	Weeks       int            `json:"weeks"`
+	Status      string         `json:"status"`
+	CreatedAt   time.Time      `json:"created_at"`
+	Teams       []FantasyTeam  `json:"teams,omitempty"`
+}
+
+// FantasyScoring 计分配置：积分制使用 Weights，类别制使用 Categories
+type FantasyScoring struct {
+	Weights    map[string]float64 `json:"weights,omitempty"`
+	Categories []string           `json:"categories,omitempty"`
+}
+
+// FantasyTeam 联赛中的球队
+type FantasyTeam struct {
+	ID            int       `json:"id"`
+	LeagueID      int       `json:"league_id"`
+	UserID        int       `json:"user_id"`
+	Name          string    `json:"name"`
+	Wins          int       `json:"wins"`
+	Losses        int       `json:"losses"`
+	Ties          int       `json:"ties"`
+	PointsFor     float64   `json:"points_for"`
+	PointsAgainst float64   `json:"points_against"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// FantasyInvitation 联赛邀请
+type FantasyInvitation struct {
+	ID          int        `json:"id"`
+	LeagueID    int        `json:"league_id"`
+	LeagueName  string     `json:"league_name"`
+	InviterID   int        `json:"inviter_id"`
+	InviteeID   int        `json:"invitee_id"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	RespondedAt *time.Time `json:"responded_at,omitempty"`
+}
+
+// FantasyRosterSpot 阵容中的一个位置
+type FantasyRosterSpot struct {
+	TeamID     int       `json:"team_id"`
+	PlayerID   int       `json:"player_id"`
+	Slot       string    `json:"slot"`
+	AcquiredAt time.Time `json:"acquired_at"`
+}
+
+// FantasyMatchup 每周对阵
+type FantasyMatchup struct {
+	ID           int             `json:"id"`
+	LeagueID     int             `json:"league_id"`
+	Week         int             `json:"week"`
+	StartDate    string          `json:"start_date"`
+	EndDate      string          `json:"end_date"`
+	HomeTeamID   int             `json:"home_team_id"`
+	AwayTeamID   int             `json:"away_team_id"`
+	HomeScore    float64         `json:"home_score"`
+	AwayScore    float64         `json:"away_score"`
+	WinnerTeamID *int            `json:"winner_team_id"`
+	Status       string          `json:"status"`
+	Details      json.RawMessage `json:"details"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+}
@@ -0,0 +1,57 @@
+package model
+
+import "time"
+
+// 推荐流内容类型
+const (
+	FeedKindPost        = "post"
+	FeedKindGameResult  = "game_result"
+	FeedKindPerformance = "player_performance"
+)
+
+// FeedItem 推荐流条目，按 kind 只有一个内容字段非空
+type FeedItem struct {
+	Kind        string           `json:"kind"`
+	Score       float64          `json:"score"`
+	OccurredAt  time.Time        `json:"occurred_at"`
+	Post        *Post            `json:"post,omitempty"`
+	Game        *FeedGame        `json:"game,omitempty"`
+	Performance *FeedPerformance `json:"performance,omitempty"`
+}
+
+// FeedGame 比赛结果
+type FeedGame struct {
+	GameID        int    `json:"game_id"`
+	Date          string `json:"date"`
+	HomeTeamID    int    `json:"home_team_id"`
+	HomeTeam      string `json:"home_team"` // 缩写
+	HomeScore     int    `json:"home_score"`
+	VisitorTeamID int    `json:"visitor_team_id"`
+	VisitorTeam   string `json:"visitor_team"`
+	VisitorScore  int    `json:"visitor_score"`
+	Postseason    bool   `json:"postseason"`
+}
+
+// FeedPerformance 关注球员的单场表现
+type FeedPerformance struct {
+	PlayerID int    `json:"player_id"`
+	Name     string `json:"name"`
+	TeamID   int    `json:"team_id"`
+	GameID   int    `json:"game_id"`
+	Date     string `json:"date"`
+	Opponent string `json:"opponent"` // 对手缩写
+	Min      string `json:"min"`
+	Pts      int    `json:"pts"`
+	Reb      int    `json:"reb"`
+	Ast      int    `json:"ast"`
+	Stl      int    `json:"stl"`
+	Blk      int    `json:"blk"`
+}
+
+// FeedPage 推荐流的一页
+type FeedPage struct {
+	Items      []FeedItem `json:"items"`
+	ComputedAt time.Time  `json:"computed_at"` // 推荐流的计算时间
+	NextCursor string     `json:"next_cursor"` // 下一页的游标，没有更多时为空
+	Restarted  bool       `json:"restarted"`   // 游标所属的推荐流已被重算，本页是新推荐流的第一页，客户端应清空已加载的条目
+}
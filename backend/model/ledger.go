@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+// 记账凭证类型
+const (
+	LedgerGrant      = "grant"       // 新钱包的初始金币
+	LedgerDailyBonus = "daily_bonus" // 每日登录奖励
+	LedgerWager      = "wager"       // 竞猜下注（用户 → 盘口托管）
+	LedgerPayout     = "payout"      // 竞猜派奖（盘口托管 → 用户）
+	LedgerRefund     = "refund"      // 盘口取消退款（盘口托管 → 用户）
+	LedgerAdjustment = "adjustment"  // 管理员调整
+)
+
+// CoinWallet 金币钱包（余额由账本分录汇总得出）
+type CoinWallet struct {
+	UserID       int               `json:"user_id"`
+	Balance      int               `json:"balance"`
+	Transactions []CoinTransaction `json:"transactions,omitempty"`
+}
+
+// CoinTransaction 钱包流水（一笔凭证在该用户账户上的分录）
+type CoinTransaction struct {
+	ID           int       `json:"id"` // 凭证 ID
+	Kind         string    `json:"kind"`
+	Amount       int       `json:"amount"` // 正数为收入，负数为支出
+	BalanceAfter int       `json:"balance_after"`
+	Memo         string    `json:"memo,omitempty"`
+	MarketID     *int      `json:"market_id"`
+	CreatedAt    time.Time `json:"created_at"`
+}
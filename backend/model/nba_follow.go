@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// 关注目标类型
+const (
+	FollowTargetPlayer = "player"
+	FollowTargetTeam   = "team"
+)
+
+// NBAFollow 关注的 NBA 球员/球队
+type NBAFollow struct {
+	UserID     int       `json:"-"`
+	TargetType string    `json:"target_type"`
+	TargetID   int       `json:"target_id"` // balldontlie ID
+	CreatedAt  time.Time `json:"created_at"`
+}
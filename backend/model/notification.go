@@ -0,0 +1,50 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// 通知类型
+const (
+	NotifyNewFollower       = "new_follower"       // 新粉丝
+	NotifyCommentReply      = "comment_reply"      // 帖子被评论、评论被回复
+	NotifyRSVPChange        = "rsvp_change"        // 候补转正、约球被取消
+	NotifyPredictionSettled = "prediction_settled" // 竞猜结算或退款
+	NotifyGameStarting      = "game_starting"      // 主队比赛即将开始
+)
+
+// NotificationTypes 全部通知类型
+var NotificationTypes = []string{
+	NotifyNewFollower,
+	NotifyCommentReply,
+	NotifyRSVPChange,
+	NotifyPredictionSettled,
+	NotifyGameStarting,
+}
+
+// Notification 站内通知
+type Notification struct {
+	ID        int                `json:"id"`
+	UserID    int                `json:"-"`
+	Type      string             `json:"type"`
+	Actor     *NotificationActor `json:"actor,omitempty"` // 触发通知的用户，系统通知为空
+	Title     string             `json:"title"`
+	Body      string             `json:"body"`
+	Data      json.RawMessage    `json:"data"` // 跳转所需的 ID 等，随类型不同
+	Read      bool               `json:"read"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+// NotificationActor 触发通知的用户
+type NotificationActor struct {
+	ID       int    `json:"id"`
+	Nickname string `json:"nickname"`
+	Avatar   string `json:"avatar"`
+}
+
+// NotificationInbox 通知列表
+type NotificationInbox struct {
+	Notifications []Notification `json:"notifications"`
+	UnreadCount   int            `json:"unread_count"`
+}
@@ -0,0 +1,60 @@
+package model
+
+import "time"
+
+// 约球水平要求
+const (
+	PickupSkillAny          = "any"
+	PickupSkillBeginner     = "beginner"
+	PickupSkillIntermediate = "intermediate"
+	PickupSkillAdvanced     = "advanced"
+)
+
+// 约球赛制
+const (
+	PickupFormat3v3 = "3v3"
+	PickupFormat5v5 = "5v5"
+)
+
+// 约球状态
+const (
+	PickupScheduled = "scheduled"
+	PickupCancelled = "cancelled"
+)
+
+// 报名状态
+const (
+	RSVPGoing    = "going"    // 已确认
+	RSVPWaitlist = "waitlist" // 候补，有人退出时按报名顺序自动递补
+)
+
+// PickupEvent 约球活动
+type PickupEvent struct {
+	ID            int                 `json:"id"`
+	CourtID       int                 `json:"court_id"`
+	CourtName     string              `json:"court_name"`
+	OrganizerID   int                 `json:"organizer_id"`
+	Title         string              `json:"title"`
+	StartsAt      time.Time           `json:"starts_at"`
+	EndsAt        time.Time           `json:"ends_at"`
+	SkillLevel    string              `json:"skill_level"`
+	Format        string              `json:"format"`
+	Capacity      int                 `json:"capacity"`
+	Note          string              `json:"note"`
+	Status        string              `json:"status"`
+	CancelReason  string              `json:"cancel_reason,omitempty"`
+	GoingCount    int                 `json:"going_count"`
+	WaitlistCount int                 `json:"waitlist_count"`
+	MyRSVP        string              `json:"my_rsvp,omitempty"`      // 当前用户的报名状态，仅“我的约球”返回
+	Participants  []PickupParticipant `json:"participants,omitempty"` // 仅详情返回
+	CreatedAt     time.Time           `json:"created_at"`
+}
+
+// PickupParticipant 报名者
+type PickupParticipant struct {
+	UserID    int       `json:"user_id"`
+	Nickname  string    `json:"nickname"`
+	Avatar    string    `json:"avatar"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"` // 报名时间，候补按此顺序递补
+}
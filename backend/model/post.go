@@ -0,0 +1,51 @@
+package model
+
+import "time"
+
+// 帖子标签类型
+const (
+	PostTagTeam   = "team"
+	PostTagPlayer = "player"
+	PostTagGame   = "game"
+)
+
+// Post 帖子
+type Post struct {
+	ID           int       `json:"id"`
+	UserID       int       `json:"user_id"`
+	Nickname     string    `json:"nickname"`
+	Avatar       string    `json:"avatar"`
+	Content      string    `json:"content"`
+	Images       []string  `json:"images"`
+	Tags         []PostTag `json:"tags"`
+	LikeCount    int       `json:"like_count"`
+	CommentCount int       `json:"comment_count"`
+	Liked        bool      `json:"liked"` // 当前用户是否点过赞，未登录为 false
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// PostTag 帖子关联的 NBA 球队、球员或比赛
+type PostTag struct {
+	Type string `json:"type"`
+	ID   int    `json:"id"` // balldontlie ID
+}
+
+// PostComment 评论；一级评论带回复列表，被删除但仍有回复的一级评论保留为占位
+type PostComment struct {
+	ID        int           `json:"id"`
+	PostID    int           `json:"post_id"`
+	UserID    int           `json:"user_id"`
+	Nickname  string        `json:"nickname"`
+	Avatar    string        `json:"avatar"`
+	ParentID  *int          `json:"parent_id,omitempty"`
+	Content   string        `json:"content"`
+	Deleted   bool          `json:"deleted,omitempty"`
+	Replies   []PostComment `json:"replies,omitempty"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+// PostPage 帖子列表的一页，next_cursor 为 0 表示没有更多
+type PostPage struct {
+	Posts      []*Post `json:"posts"`
+	NextCursor int     `json:"next_cursor"`
+}
@@ -0,0 +1,58 @@
+package model
+
+import "time"
+
+// 竞猜盘口类型
+const (
+	PredictionWinner       = "winner"        // 比赛胜负
+	PredictionPlayerPoints = "player_points" // 球员得分区间
+)
+
+// 竞猜盘口状态
+const (
+	PredictionOpen      = "open"      // 可下注
+	PredictionLocked    = "locked"    // 已开赛封盘，等待结算
+	PredictionSettled   = "settled"   // 已按比赛结果派奖
+	PredictionCancelled = "cancelled" // 比赛取消或无法判定，已退还全部下注
+)
+
+// PredictionMarket 竞猜盘口
+type PredictionMarket struct {
+	ID              int                `json:"id"`
+	GameID          int                `json:"game_id"`
+	GameDate        string             `json:"game_date"`
+	Type            string             `json:"type"`
+	PlayerID        int                `json:"player_id,omitempty"`
+	Title           string             `json:"title"`
+	LockAt          time.Time          `json:"lock_at"`
+	Status          string             `json:"status"`
+	WinningOptionID *int               `json:"winning_option_id"`
+	TotalPool       int                `json:"total_pool"`
+	CreatedAt       time.Time          `json:"created_at"`
+	SettledAt       *time.Time         `json:"settled_at"`
+	Options         []PredictionOption `json:"options"`
+}
+
+// PredictionOption 盘口选项；Odds 为按当前奖池计算的派彩倍数（含本金），该选项无人下注时为 null
+type PredictionOption struct {
+	ID        int      `json:"id"`
+	Label     string   `json:"label"`
+	TeamID    *int     `json:"team_id,omitempty"`
+	MinPoints *int     `json:"min_points,omitempty"`
+	MaxPoints *int     `json:"max_points,omitempty"`
+	Pool      int      `json:"pool"`
+	Odds      *float64 `json:"odds"`
+}
+
+// PredictionStake 下注记录
+type PredictionStake struct {
+	ID          int       `json:"id"`
+	MarketID    int       `json:"market_id"`
+	MarketTitle string    `json:"market_title"`
+	OptionID    int       `json:"option_id"`
+	OptionLabel string    `json:"option_label"`
+	Amount      int       `json:"amount"`
+	Payout      *int      `json:"payout"` // 未结算时为 null
+	Status      string    `json:"status"` // 盘口状态
+	CreatedAt   time.Time `json:"created_at"`
+}
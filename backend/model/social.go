@@ -0,0 +1,58 @@
+package model
+
+import "time"
+
+// SocialCounts 关注数据
+type SocialCounts struct {
+	Followers int `json:"followers"`
+	Following int `json:"following"`
+	Friends   int `json:"friends"` // 互相关注
+}
+
+// Relationship 当前用户与目标用户的关系
+type Relationship struct {
+	Following  bool `json:"following"`   // 我关注了对方
+	FollowedBy bool `json:"followed_by"` // 对方关注了我
+	Friend     bool `json:"friend"`      // 互相关注
+	Blocking   bool `json:"blocking"`    // 我拉黑了对方
+	BlockedBy  bool `json:"blocked_by"`  // 对方拉黑了我
+}
+
+// UserSummary 用户列表项（粉丝、关注、好友、黑名单）
+type UserSummary struct {
+	ID       int       `json:"id"`
+	Nickname string    `json:"nickname"`
+	Avatar   string    `json:"avatar"`
+	Friend   bool      `json:"friend"` // 与列表所属用户互相关注
+	Since    time.Time `json:"since"`  // 关注或拉黑的时间
+}
+
+// UserProfile 用户公开主页，用户在隐私设置中隐藏的字段不返回（本人查看时全部返回）
+type UserProfile struct {
+	ID           int           `json:"id"`
+	Nickname     string        `json:"nickname"`
+	Avatar       string        `json:"avatar"`
+	Team         *Team         `json:"team,omitempty"`
+	Counts       *SocialCounts `json:"counts,omitempty"`
+	Achievements []Achievement `json:"achievements,omitempty"`
+	Relationship *Relationship `json:"relationship,omitempty"` // 登录后查看他人主页时返回
+	CreatedAt    *time.Time    `json:"created_at,omitempty"`   // 注册时间
+}
+
+// PrivacySettings 主页隐私设置，false 表示对他人隐藏
+type PrivacySettings struct {
+	ShowTeam         bool `json:"show_team"`
+	ShowJoinDate     bool `json:"show_join_date"`
+	ShowCounts       bool `json:"show_counts"` // 关注数据及粉丝/关注列表
+	ShowAchievements bool `json:"show_achievements"`
+}
+
+// Achievement 成就及进度
+type Achievement struct {
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Progress    *int   `json:"progress"` // 用户未公开关注数据时，由关注数据得出的进度为 null
+	Target      int    `json:"target"`
+	Achieved    bool   `json:"achieved"`
+}
@@ -0,0 +1,105 @@
+package notify
+
+import (
+	"buzzerbeater/model"
+	"fmt"
+	"time"
+)
+
+// excerptLength 通知中引用的评论内容最多保留的字数
+const excerptLength = 50
+
+func excerpt(s string) string {
+	r := []rune(s)
+	if len(r) <= excerptLength {
+		return s
+	}
+	return string(r[:excerptLength]) + "…"
+}
+
+// NewFollower 新粉丝
+func NewFollower(userID, followerID int) Event {
+	return Event{
+		Type:      model.NotifyNewFollower,
+		UserID:    userID,
+		ActorID:   followerID,
+		Title:     "新粉丝",
+		Body:      "关注了你",
+		Data:      map[string]interface{}{"user_id": followerID},
+		DedupeKey: fmt.Sprintf("follower:%d", followerID),
+	}
+}
+
+// CommentReply 帖子被评论（reply 为 false）或评论被回复（reply 为 true）
+func CommentReply(userID, actorID, postID, commentID int, content string, reply bool) Event {
+	title, body := "新评论", "评论了你的帖子："
+	if reply {
+		title, body = "新回复", "回复了你的评论："
+	}
+	return Event{
+		Type:    model.NotifyCommentReply,
+		UserID:  userID,
+		ActorID: actorID,
+		Title:   title,
+		Body:    body + excerpt(content),
+		Data:    map[string]interface{}{"post_id": postID, "comment_id": commentID},
+	}
+}
+
+// RSVPPromoted 约球候补转正
+func RSVPPromoted(userID, eventID int, title string, startsAt time.Time) Event {
+	return Event{
+		Type:   model.NotifyRSVPChange,
+		UserID: userID,
+		Title:  "候补成功",
+		Body:   fmt.Sprintf("有人退出了「%s」，你已从候补转为正式报名，开始时间 %s", title, startsAt.Format(time.RFC3339)),
+		Data:   map[string]interface{}{"event_id": eventID, "status": model.RSVPGoing},
+	}
+}
+
+// PickupCancelled 报名的约球被发起人取消
+func PickupCancelled(userID, organizerID, eventID int, title, reason string) Event {
+	body := fmt.Sprintf("取消了约球「%s」", title)
+	if reason != "" {
+		body += "，原因：" + reason
+	}
+	return Event{
+		Type:    model.NotifyRSVPChange,
+		UserID:  userID,
+		ActorID: organizerID,
+		Title:   "约球已取消",
+		Body:    body,
+		Data:    map[string]interface{}{"event_id": eventID, "status": model.PickupCancelled},
+	}
+}
+
+// PredictionSettled 竞猜结算（payout 为 0 表示未猜中）或盘口取消退款；同一笔下注只通知一次
+func PredictionSettled(userID, marketID, stakeID int, title string, amount, payout int, cancelled bool) Event {
+	body := fmt.Sprintf("「%s」已结算，很遗憾没有猜中", title)
+	switch {
+	case cancelled:
+		body = fmt.Sprintf("「%s」已取消，%d 金币已退还", title, amount)
+	case payout > 0:
+		body = fmt.Sprintf("「%s」已结算，你猜中了，获得 %d 金币", title, payout)
+	}
+	return Event{
+		Type:      model.NotifyPredictionSettled,
+		UserID:    userID,
+		Title:     "竞猜结果",
+		Body:      body,
+		Data:      map[string]interface{}{"market_id": marketID, "stake_id": stakeID, "amount": amount, "payout": payout},
+		DedupeKey: fmt.Sprintf("stake:%d", stakeID),
+	}
+}
+
+// GameStarting 主队比赛即将开始；同一场比赛只提醒一次
+func GameStarting(userID, gameID int, matchup string, tipOff time.Time) Event {
+	return Event{
+		Type:      model.NotifyGameStarting,
+		UserID:    userID,
+		Title:     "主队比赛即将开始",
+		Body:      fmt.Sprintf("%s 将于 %s 开赛", matchup, tipOff.Format(time.RFC3339)),
+		Data:      map[string]interface{}{"game_id": gameID, "tip_off": tipOff},
+		DedupeKey: fmt.Sprintf("game:%d", gameID),
+	}
+}
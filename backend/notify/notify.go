@@ -0,0 +1,308 @@
+package notify
+
+import (
+	"buzzerbeater/db"
+	"buzzerbeater/model"
+	"buzzerbeater/social"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// 渠道投递参数：由固定数量的 worker 从队列中取出投递，队列满时丢弃（通知已在收件箱中，不影响站内查看）
+const (
+	deliverTimeout = 10 * time.Second // 单个渠道投递一条通知的超时时间
+	deliverWorkers = 4
+	deliverQueue   = 1000
+)
+
+// ErrNotFound 通知不存在
+var ErrNotFound = errors.New("notification not found")
+
+// Event 待发送的通知
+type Event struct {
+	Type      string
+	UserID    int // 接收者
+	ActorID   int // 触发者，系统通知为 0
+	Title     string
+	Body      string
+	Data      map[string]interface{}
+	DedupeKey string // 非空时同一接收者相同 key 只通知一次
+}
+
+// Channel 站内信之外的投递渠道（如 App 推送），通知写入收件箱后异步投递
+type Channel interface {
+	Name() string
+	Deliver(ctx context.Context, n model.Notification) error
+}
+
+var (
+	channelsMu sync.RWMutex
+	channels   []Channel
+)
+
+// delivery 一条待投递到某个渠道的通知
+type delivery struct {
+	ch Channel
+	n  model.Notification
+}
+
+var (
+	deliveries    = make(chan delivery, deliverQueue)
+	startDelivery sync.Once
+)
+
+// Register 注册投递渠道
+func Register(ch Channel) {
+	channelsMu.Lock()
+	defer channelsMu.Unlock()
+	channels = append(channels, ch)
+}
+
+// LogChannel 把通知写到日志，用于开发调试
+type LogChannel struct{}
+
+// Name 渠道名
+func (LogChannel) Name() string { return "log" }
+
+// Deliver 输出通知
+func (LogChannel) Deliver(ctx context.Context, n model.Notification) error {
+	log.Printf("Notification %d to user %d: [%s] %s %s", n.ID, n.UserID, n.Type, n.Title, n.Body)
+	return nil
+}
+
+// Send 写入接收者的收件箱并投递到已注册的渠道；以下情况不发送：
+// 接收者就是触发者、双方存在拉黑关系、接收者关闭了该类型、相同 dedupe key 已发送过
+func Send(e Event) error {
+	if e.ActorID != 0 {
+		if e.ActorID == e.UserID {
+			return nil
+		}
+		blocked, err := social.IsBlocked(db.GetDB(), e.UserID, e.ActorID)
+		if err != nil || blocked {
+			return err
+		}
+	}
+	enabled, err := Enabled(e.UserID, e.Type)
+	if err != nil || !enabled {
+		return err
+	}
+
+	data, err := json.Marshal(e.Data)
+	if err != nil {
+		return err
+	}
+	var actorID, dedupeKey interface{}
+	if e.ActorID != 0 {
+		actorID = e.ActorID
+	}
+	if e.DedupeKey != "" {
+		dedupeKey = e.DedupeKey
+	}
+	result, err := db.GetDB().Exec(`
+		INSERT INTO notifications (user_id, type, actor_id, title, body, data, dedupe_key)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(user_id, dedupe_key) DO NOTHING
+	`, e.UserID, e.Type, actorID, e.Title, e.Body, string(data), dedupeKey)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return nil
+	}
+	id, _ := result.LastInsertId()
+
+	channelsMu.RLock()
+	targets := append([]Channel(nil), channels...)
+	channelsMu.RUnlock()
+	if len(targets) == 0 {
+		return nil
+	}
+	n, err := get(int(id))
+	if err != nil {
+		return err
+	}
+	startDelivery.Do(func() {
+		for i := 0; i < deliverWorkers; i++ {
+			go deliverLoop()
+		}
+	})
+	for _, ch := range targets {
+		select {
+		case deliveries <- delivery{ch: ch, n: *n}:
+		default:
+			log.Printf("Notify: delivery queue full, dropping notification %d via %s", n.ID, ch.Name())
+		}
+	}
+	return nil
+}
+
+// Emit 发送通知，失败只记录日志；用于不应因通知失败而影响主流程的场景
+func Emit(e Event) {
+	if err := Send(e); err != nil {
+		log.Printf("Notify: send %s to user %d failed: %v", e.Type, e.UserID, err)
+	}
+}
+
+func deliverLoop() {
+	for d := range deliveries {
+		deliver(d.ch, d.n)
+	}
+}
+
+func deliver(ch Channel, n model.Notification) {
+	ctx, cancel := context.WithTimeout(context.Background(), deliverTimeout)
+	defer cancel()
+	if err := ch.Deliver(ctx, n); err != nil {
+		log.Printf("Notify: deliver notification %d via %s failed: %v", n.ID, ch.Name(), err)
+	}
+}
+
+const notificationColumns = `
+	n.id, n.user_id, n.type, n.actor_id, COALESCE(u.nickname, ''), COALESCE(u.avatar, ''), n.title, n.body, n.data,
+	n.read_at IS NOT NULL, n.created_at
+`
+
+func scanNotification(row interface{ Scan(...interface{}) error }) (*model.Notification, error) {
+	var n model.Notification
+	var actorID sql.NullInt64
+	var nickname, avatar, data string
+	if err := row.Scan(&n.ID, &n.UserID, &n.Type, &actorID, &nickname, &avatar, &n.Title, &n.Body, &data, &n.Read,
+		&n.CreatedAt); err != nil {
+		return nil, err
+	}
+	if actorID.Valid {
+		n.Actor = &model.NotificationActor{ID: int(actorID.Int64), Nickname: nickname, Avatar: avatar}
+	}
+	n.Data = json.RawMessage(data)
+	return &n, nil
+}
+
+func get(id int) (*model.Notification, error) {
+	return scanNotification(db.GetDB().QueryRow(
+		"SELECT "+notificationColumns+" FROM notifications n LEFT JOIN users u ON u.id = n.actor_id WHERE n.id = ?", id))
+}
+
+// Inbox 用户的通知（新的在前）和未读数；不展示之后被拉黑的用户触发的通知
+func Inbox(userID int, unreadOnly bool, limit, offset int) (*model.NotificationInbox, error) {
+	filter := social.BlockFilter("COALESCE(n.actor_id, 0)")
+	query := "SELECT " + notificationColumns + `
+		FROM notifications n LEFT JOIN users u ON u.id = n.actor_id
+		WHERE n.user_id = ? AND ` + filter
+	if unreadOnly {
+		query += " AND n.read_at IS NULL"
+	}
+	rows, err := db.GetDB().Query(query+" ORDER BY n.id DESC LIMIT ? OFFSET ?", userID, userID, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	inbox := &model.NotificationInbox{Notifications: []model.Notification{}}
+	for rows.Next() {
+		n, err := scanNotification(rows)
+		if err != nil {
+			return nil, err
+		}
+		inbox.Notifications = append(inbox.Notifications, *n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	err = db.GetDB().QueryRow(
+		"SELECT COUNT(*) FROM notifications n WHERE n.user_id = ? AND n.read_at IS NULL AND "+filter,
+		userID, userID, userID,
+	).Scan(&inbox.UnreadCount)
+	return inbox, err
+}
+
+// MarkRead 标记一条通知为已读，已读时不报错
+func MarkRead(userID, id int, now time.Time) error {
+	result, err := db.GetDB().Exec(
+		"UPDATE notifications SET read_at = COALESCE(read_at, ?) WHERE id = ? AND user_id = ?", now.UTC(), id, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// MarkAllRead 全部标记为已读，返回本次标记的条数
+func MarkAllRead(userID int, now time.Time) (int, error) {
+	result, err := db.GetDB().Exec("UPDATE notifications SET read_at = ? WHERE user_id = ? AND read_at IS NULL",
+		now.UTC(), userID)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := result.RowsAffected()
+	return int(n), nil
+}
+
+// ValidType 是否为支持的通知类型
+func ValidType(t string) bool {
+	for _, known := range model.NotificationTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Enabled 用户是否开启了该类型的通知
+func Enabled(userID int, t string) (bool, error) {
+	var enabled bool
+	err := db.GetDB().QueryRow(
+		"SELECT COALESCE((SELECT enabled FROM notification_preferences WHERE user_id = ? AND type = ?), 1)",
+		userID, t).Scan(&enabled)
+	return enabled, err
+}
+
+// Preferences 用户各类型通知的开关
+func Preferences(userID int) (map[string]bool, error) {
+	prefs := make(map[string]bool, len(model.NotificationTypes))
+	for _, t := range model.NotificationTypes {
+		prefs[t] = true
+	}
+	rows, err := db.GetDB().Query("SELECT type, enabled FROM notification_preferences WHERE user_id = ?", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var t string
+		var enabled bool
+		if err := rows.Scan(&t, &enabled); err != nil {
+			return nil, err
+		}
+		if _, ok := prefs[t]; ok {
+			prefs[t] = enabled
+		}
+	}
+	return prefs, rows.Err()
+}
+
+// SetPreferences 更新部分类型的开关，类型需事先用 ValidType 校验
+func SetPreferences(userID int, prefs map[string]bool) error {
+	tx, err := db.GetDB().Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for t, enabled := range prefs {
+		if _, err := tx.Exec(`
+			INSERT INTO notification_preferences (user_id, type, enabled) VALUES (?, ?, ?)
+			ON CONFLICT(user_id, type) DO UPDATE SET enabled = excluded.enabled, updated_at = CURRENT_TIMESTAMP
+		`, userID, t, enabled); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
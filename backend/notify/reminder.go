@@ -0,0 +1,100 @@
+package notify
+
+import (
+	"buzzerbeater/db"
+	"buzzerbeater/external"
+	"context"
+	"log"
+	"time"
+)
+
+// reminderLead 开赛前多久提醒
+const reminderLead = 30 * time.Minute
+
+// GameReminder 定时检查即将开始的比赛，提醒以比赛双方为主队的用户
+type GameReminder struct {
+	client   *external.NBAClient
+	interval time.Duration
+	now      func() time.Time
+}
+
+// NewGameReminder 创建开赛提醒任务
+func NewGameReminder(client *external.NBAClient, interval time.Duration) *GameReminder {
+	return &GameReminder{client: client, interval: interval, now: time.Now}
+}
+
+// Start 启动后台任务（立即执行一次，之后按间隔执行，ctx 结束时退出）
+func (r *GameReminder) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			if err := r.RunOnce(ctx); err != nil {
+				log.Printf("Notify: game reminders failed: %v", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// RunOnce 提醒未来 30 分钟内开赛的比赛（比赛日期按美国当地时间，因此前后各多查一天）
+func (r *GameReminder) RunOnce(ctx context.Context) error {
+	now := r.now().UTC()
+	games, _, err := r.client.GetGames(ctx, external.GameQuery{
+		Seasons:   []int{external.CurrentSeason(now)},
+		StartDate: now.AddDate(0, 0, -1).Format("2006-01-02"),
+		EndDate:   now.AddDate(0, 0, 1).Format("2006-01-02"),
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := range games {
+		game := &games[i]
+		if game.Datetime == "" || game.IsFinal() || game.IsCancelled() {
+			continue
+		}
+		tipOff, err := game.TipOff()
+		if err != nil || tipOff.Before(now) || tipOff.After(now.Add(reminderLead)) {
+			continue
+		}
+		users, err := homeTeamUsers(game.HomeTeam.Abbreviation, game.VisitorTeam.Abbreviation)
+		if err != nil {
+			return err
+		}
+		matchup := game.VisitorTeam.Abbreviation + " @ " + game.HomeTeam.Abbreviation
+		for _, userID := range users {
+			Emit(GameStarting(userID, game.ID, matchup, tipOff))
+		}
+	}
+	return nil
+}
+
+// homeTeamUsers 主队为比赛双方之一（按球队缩写匹配）的用户
+func homeTeamUsers(homeCode, visitorCode string) ([]int, error) {
+	rows, err := db.GetDB().Query(`
+		SELECT u.id FROM users u JOIN teams t ON t.id = u.team_id
+		WHERE t.code IN (?, ?)
+		ORDER BY u.id
+	`, homeCode, visitorCode)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		users = append(users, id)
+	}
+	return users, rows.Err()
+}
@@ -0,0 +1,383 @@
+package pickup
+
+import (
+	"buzzerbeater/db"
+	"buzzerbeater/model"
+	"buzzerbeater/social"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNotFound 约球或球场不存在
+	ErrNotFound = errors.New("pickup event not found")
+	// ErrClosed 约球已取消或已开始，不能再报名或退出
+	ErrClosed = errors.New("pickup event is closed")
+	// ErrNotOrganizer 只有发起人可以取消约球
+	ErrNotOrganizer = errors.New("only the organizer can cancel the event")
+	// ErrOrganizerLeave 发起人不能退出自己的约球，只能取消
+	ErrOrganizerLeave = errors.New("organizer cannot leave the event")
+	// ErrNotJoined 用户没有报名
+	ErrNotJoined = errors.New("not joined")
+	// ErrBlocked 报名者与发起人存在拉黑关系
+	ErrBlocked = errors.New("blocked by organizer")
+)
+
+// ConflictError 报名的约球与用户已报名（含候补）的约球时间重叠
+type ConflictError struct {
+	EventID  int
+	Title    string
+	StartsAt time.Time
+	EndsAt   time.Time
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("overlaps with pickup event %d", e.EventID)
+}
+
+// EventSpec 发起约球的参数
+type EventSpec struct {
+	CourtID     int
+	OrganizerID int
+	Title       string
+	StartsAt    time.Time
+	EndsAt      time.Time
+	SkillLevel  string
+	Format      string
+	Capacity    int
+	Note        string
+}
+
+// EventFilter 约球列表过滤条件，零值表示不过滤
+type EventFilter struct {
+	ViewerID   int // 不返回与该用户存在拉黑关系的发起人的约球
+	CourtID    int
+	From       time.Time
+	To         time.Time
+	SkillLevel string
+	Format     string
+	Status     string
+}
+
+const eventColumns = `
+	e.id, e.court_id, c.name, e.organizer_id, e.title, e.starts_at, e.ends_at, e.skill_level, e.format, e.capacity,
+	e.note, e.status, e.cancel_reason, e.created_at,
+	(SELECT COUNT(*) FROM pickup_rsvps WHERE event_id = e.id AND status = 'going'),
+	(SELECT COUNT(*) FROM pickup_rsvps WHERE event_id = e.id AND status = 'waitlist')
+`
+
+func scanEvent(row interface{ Scan(...interface{}) error }) (*model.PickupEvent, error) {
+	var e model.PickupEvent
+	if err := row.Scan(&e.ID, &e.CourtID, &e.CourtName, &e.OrganizerID, &e.Title, &e.StartsAt, &e.EndsAt, &e.SkillLevel,
+		&e.Format, &e.Capacity, &e.Note, &e.Status, &e.CancelReason, &e.CreatedAt, &e.GoingCount, &e.WaitlistCount); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// findConflict 查找用户已报名且与 [start, end) 时间重叠的约球，excludeID 为当前约球
+func findConflict(tx *sql.Tx, userID, excludeID int, start, end time.Time) error {
+	var c ConflictError
+	err := tx.QueryRow(`
+		SELECT e.id, e.title, e.starts_at, e.ends_at
+		FROM pickup_rsvps r
+		JOIN pickup_events e ON e.id = r.event_id
+		WHERE r.user_id = ? AND e.id != ? AND e.status = ? AND e.starts_at < ? AND e.ends_at > ?
+		ORDER BY e.starts_at
+		LIMIT 1
+	`, userID, excludeID, model.PickupScheduled, end.UTC(), start.UTC()).Scan(&c.EventID, &c.Title, &c.StartsAt, &c.EndsAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return &c
+}
+
+// CreateEvent 发起约球，发起人自动报名；发起人在该时段已有约球时返回 *ConflictError
+func CreateEvent(spec EventSpec) (int, error) {
+	tx, err := db.GetDB().Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM courts WHERE id = ?)", spec.CourtID).Scan(&exists); err != nil {
+		return 0, err
+	}
+	if !exists {
+		return 0, ErrNotFound
+	}
+	if err := findConflict(tx, spec.OrganizerID, 0, spec.StartsAt, spec.EndsAt); err != nil {
+		return 0, err
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO pickup_events (court_id, organizer_id, title, starts_at, ends_at, skill_level, format, capacity, note)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, spec.CourtID, spec.OrganizerID, spec.Title, spec.StartsAt.UTC(), spec.EndsAt.UTC(), spec.SkillLevel, spec.Format,
+		spec.Capacity, spec.Note)
+	if err != nil {
+		return 0, err
+	}
+	id, _ := result.LastInsertId()
+	if _, err := tx.Exec("INSERT INTO pickup_rsvps (event_id, user_id, status) VALUES (?, ?, ?)",
+		id, spec.OrganizerID, model.RSVPGoing); err != nil {
+		return 0, err
+	}
+	return int(id), tx.Commit()
+}
+
+// GetEvent 约球详情，含报名名单（已确认在前，各自按报名顺序）
+// 发起人与 viewerID 存在拉黑关系时返回 ErrNotFound，名单中也不包含与 viewerID 存在拉黑关系的用户
+func GetEvent(id, viewerID int) (*model.PickupEvent, error) {
+	e, err := scanEvent(db.GetDB().QueryRow(
+		"SELECT "+eventColumns+" FROM pickup_events e JOIN courts c ON c.id = e.court_id WHERE e.id = ? AND "+
+			social.BlockFilter("e.organizer_id"),
+		id, viewerID, viewerID,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.GetDB().Query(`
+		SELECT r.user_id, u.nickname, u.avatar, r.status, r.created_at
+		FROM pickup_rsvps r
+		JOIN users u ON u.id = r.user_id
+		WHERE r.event_id = ? AND `+social.BlockFilter("r.user_id")+`
+		ORDER BY CASE r.status WHEN 'going' THEN 0 ELSE 1 END, r.id
+	`, id, viewerID, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	e.Participants = []model.PickupParticipant{}
+	for rows.Next() {
+		var p model.PickupParticipant
+		if err := rows.Scan(&p.UserID, &p.Nickname, &p.Avatar, &p.Status, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.Participants = append(e.Participants, p)
+	}
+	return e, rows.Err()
+}
+
+// ListEvents 按开始时间排序的约球列表（最多 200 条）
+func ListEvents(filter EventFilter) ([]model.PickupEvent, error) {
+	conditions := []string{social.BlockFilter("e.organizer_id")}
+	args := []interface{}{filter.ViewerID, filter.ViewerID}
+	if filter.CourtID != 0 {
+		conditions = append(conditions, "e.court_id = ?")
+		args = append(args, filter.CourtID)
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "e.starts_at >= ?")
+		args = append(args, filter.From.UTC())
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "e.starts_at < ?")
+		args = append(args, filter.To.UTC())
+	}
+	if filter.SkillLevel != "" {
+		conditions = append(conditions, "e.skill_level = ?")
+		args = append(args, filter.SkillLevel)
+	}
+	if filter.Format != "" {
+		conditions = append(conditions, "e.format = ?")
+		args = append(args, filter.Format)
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, "e.status = ?")
+		args = append(args, filter.Status)
+	}
+
+	query := "SELECT " + eventColumns + " FROM pickup_events e JOIN courts c ON c.id = e.court_id WHERE " +
+		strings.Join(conditions, " AND ") + " ORDER BY e.starts_at, e.id LIMIT 200"
+	return queryEvents(query, args...)
+}
+
+// UserEvents 用户报名的、尚未结束的约球
+func UserEvents(userID int, now time.Time) ([]model.PickupEvent, error) {
+	rows, err := db.GetDB().Query(`
+		SELECT `+eventColumns+`, r.status
+		FROM pickup_rsvps r
+		JOIN pickup_events e ON e.id = r.event_id
+		JOIN courts c ON c.id = e.court_id
+		WHERE r.user_id = ? AND e.ends_at > ?
+		ORDER BY e.starts_at, e.id
+	`, userID, now.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []model.PickupEvent{}
+	for rows.Next() {
+		var e model.PickupEvent
+		if err := rows.Scan(&e.ID, &e.CourtID, &e.CourtName, &e.OrganizerID, &e.Title, &e.StartsAt, &e.EndsAt,
+			&e.SkillLevel, &e.Format, &e.Capacity, &e.Note, &e.Status, &e.CancelReason, &e.CreatedAt,
+			&e.GoingCount, &e.WaitlistCount, &e.MyRSVP); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+func queryEvents(query string, args ...interface{}) ([]model.PickupEvent, error) {
+	rows, err := db.GetDB().Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []model.PickupEvent{}
+	for rows.Next() {
+		e, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, *e)
+	}
+	return events, rows.Err()
+}
+
+// openEvent 在事务中读取可报名的约球（未取消且未开始）
+func openEvent(tx *sql.Tx, eventID int, now time.Time) (organizerID, capacity int, startsAt, endsAt time.Time, err error) {
+	var status string
+	err = tx.QueryRow("SELECT organizer_id, capacity, starts_at, ends_at, status FROM pickup_events WHERE id = ?", eventID).
+		Scan(&organizerID, &capacity, &startsAt, &endsAt, &status)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = ErrNotFound
+		return
+	}
+	if err == nil && (status != model.PickupScheduled || !now.Before(startsAt)) {
+		err = ErrClosed
+	}
+	return
+}
+
+// Join 报名：名额未满时为已确认，否则进入候补；已报名时直接返回当前状态
+// 与发起人存在拉黑关系时返回 ErrBlocked，与用户已报名（含候补）的其他约球时间重叠时返回 *ConflictError
+func Join(eventID, userID int, now time.Time) (string, error) {
+	tx, err := db.GetDB().Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	organizerID, capacity, startsAt, endsAt, err := openEvent(tx, eventID, now)
+	if err != nil {
+		return "", err
+	}
+	blocked, err := social.IsBlocked(tx, organizerID, userID)
+	if err != nil {
+		return "", err
+	}
+	if blocked {
+		return "", ErrBlocked
+	}
+
+	var status string
+	err = tx.QueryRow("SELECT status FROM pickup_rsvps WHERE event_id = ? AND user_id = ?", eventID, userID).Scan(&status)
+	if err == nil {
+		return status, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return "", err
+	}
+	if err := findConflict(tx, userID, eventID, startsAt, endsAt); err != nil {
+		return "", err
+	}
+
+	var going int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM pickup_rsvps WHERE event_id = ? AND status = ?",
+		eventID, model.RSVPGoing).Scan(&going); err != nil {
+		return "", err
+	}
+	status = model.RSVPGoing
+	if going >= capacity {
+		status = model.RSVPWaitlist
+	}
+	if _, err := tx.Exec("INSERT INTO pickup_rsvps (event_id, user_id, status) VALUES (?, ?, ?)",
+		eventID, userID, status); err != nil {
+		return "", err
+	}
+	return status, tx.Commit()
+}
+
+// Leave 退出报名；已确认的用户退出时候补第一位自动递补，返回被递补的用户 ID（没有时为 0）
+func Leave(eventID, userID int, now time.Time) (int, error) {
+	tx, err := db.GetDB().Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	organizerID, _, _, _, err := openEvent(tx, eventID, now)
+	if err != nil {
+		return 0, err
+	}
+	if organizerID == userID {
+		return 0, ErrOrganizerLeave
+	}
+
+	var status string
+	err = tx.QueryRow("SELECT status FROM pickup_rsvps WHERE event_id = ? AND user_id = ?", eventID, userID).Scan(&status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrNotJoined
+	}
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec("DELETE FROM pickup_rsvps WHERE event_id = ? AND user_id = ?", eventID, userID); err != nil {
+		return 0, err
+	}
+
+	promoted := 0
+	if status == model.RSVPGoing {
+		err := tx.QueryRow("SELECT user_id FROM pickup_rsvps WHERE event_id = ? AND status = ? ORDER BY id LIMIT 1",
+			eventID, model.RSVPWaitlist).Scan(&promoted)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return 0, err
+		}
+		if promoted != 0 {
+			if _, err := tx.Exec("UPDATE pickup_rsvps SET status = ? WHERE event_id = ? AND user_id = ?",
+				model.RSVPGoing, eventID, promoted); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return promoted, tx.Commit()
+}
+
+// Cancel 发起人取消约球，报名记录保留以便参与者查看取消原因
+func Cancel(eventID, userID int, reason string, now time.Time) error {
+	tx, err := db.GetDB().Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	organizerID, _, _, _, err := openEvent(tx, eventID, now)
+	if err != nil {
+		return err
+	}
+	if organizerID != userID {
+		return ErrNotOrganizer
+	}
+	if _, err := tx.Exec("UPDATE pickup_events SET status = ?, cancel_reason = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?",
+		model.PickupCancelled, reason, eventID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,475 @@
+package post
+
+import (
+	"buzzerbeater/db"
+	"buzzerbeater/model"
+	"buzzerbeater/social"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNotFound 帖子或评论不存在（含已删除）
+	ErrNotFound = errors.New("post not found")
+	// ErrNotAuthor 没有权限删除
+	ErrNotAuthor = errors.New("not the author")
+	// ErrBlocked 与帖子作者存在拉黑关系
+	ErrBlocked = errors.New("blocked by author")
+	// ErrParentNotFound 回复的评论不存在或不属于该帖子
+	ErrParentNotFound = errors.New("parent comment not found")
+)
+
+// Spec 发帖参数
+type Spec struct {
+	UserID  int
+	Content string
+	Images  []string
+	Tags    []model.PostTag
+}
+
+// FeedFilter 关注流的范围：当前用户自己、关注的用户，以及带主队标签的帖子
+type FeedFilter struct {
+	UserID     int
+	HomeTeamID int // 主队的 balldontlie ID，未知时为 0
+	Cursor     int // 上一页的 next_cursor，0 为第一页
+	Limit      int
+}
+
+const postColumns = `
+	p.id, p.user_id, u.nickname, u.avatar, p.content, p.like_count, p.comment_count, p.created_at,
+	EXISTS(SELECT 1 FROM post_likes l WHERE l.post_id = p.id AND l.user_id = ?)
+`
+
+func scanPost(row interface{ Scan(...interface{}) error }) (*model.Post, error) {
+	var p model.Post
+	if err := row.Scan(&p.ID, &p.UserID, &p.Nickname, &p.Avatar, &p.Content, &p.LikeCount, &p.CommentCount, &p.CreatedAt,
+		&p.Liked); err != nil {
+		return nil, err
+	}
+	p.Images = []string{}
+	p.Tags = []model.PostTag{}
+	return &p, nil
+}
+
+// loadDetails 批量填充帖子的图片和标签
+func loadDetails(posts []*model.Post) error {
+	if len(posts) == 0 {
+		return nil
+	}
+	byID := make(map[int]*model.Post, len(posts))
+	args := make([]interface{}, 0, len(posts))
+	for _, p := range posts {
+		byID[p.ID] = p
+		args = append(args, p.ID)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(posts)), ",")
+
+	rows, err := db.GetDB().Query(
+		"SELECT post_id, url FROM post_images WHERE post_id IN ("+placeholders+") ORDER BY post_id, position", args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var postID int
+		var url string
+		if err := rows.Scan(&postID, &url); err != nil {
+			return err
+		}
+		byID[postID].Images = append(byID[postID].Images, url)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	tagRows, err := db.GetDB().Query(
+		"SELECT post_id, tag_type, target_id FROM post_tags WHERE post_id IN ("+placeholders+") ORDER BY post_id, rowid",
+		args...)
+	if err != nil {
+		return err
+	}
+	defer tagRows.Close()
+	for tagRows.Next() {
+		var postID int
+		var tag model.PostTag
+		if err := tagRows.Scan(&postID, &tag.Type, &tag.ID); err != nil {
+			return err
+		}
+		byID[postID].Tags = append(byID[postID].Tags, tag)
+	}
+	return tagRows.Err()
+}
+
+// queryPage 按 ID 倒序查询一页帖子（多取一条判断是否还有下一页）
+func queryPage(conditions []string, args []interface{}, viewerID, cursor, limit int) (*model.PostPage, error) {
+	conditions = append(conditions, "p.deleted_at IS NULL", social.BlockFilter("p.user_id"))
+	args = append(args, viewerID, viewerID)
+	if cursor > 0 {
+		conditions = append(conditions, "p.id < ?")
+		args = append(args, cursor)
+	}
+	query := "SELECT " + postColumns + " FROM posts p JOIN users u ON u.id = p.user_id WHERE " +
+		strings.Join(conditions, " AND ") + " ORDER BY p.id DESC LIMIT ?"
+	args = append([]interface{}{viewerID}, append(args, limit+1)...)
+
+	rows, err := db.GetDB().Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	page := &model.PostPage{Posts: []*model.Post{}}
+	for rows.Next() {
+		p, err := scanPost(rows)
+		if err != nil {
+			return nil, err
+		}
+		page.Posts = append(page.Posts, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(page.Posts) > limit {
+		page.Posts = page.Posts[:limit]
+		page.NextCursor = page.Posts[limit-1].ID
+	}
+	return page, loadDetails(page.Posts)
+}
+
+// Feed 关注流：自己和关注的用户发的帖子，以及带主队标签的帖子，按发布时间倒序
+func Feed(filter FeedFilter) (*model.PostPage, error) {
+	condition := `(p.user_id = ?
+		OR p.user_id IN (SELECT followee_id FROM user_follows WHERE follower_id = ?)
+		OR EXISTS(SELECT 1 FROM post_tags t WHERE t.post_id = p.id AND t.tag_type = ? AND t.target_id = ?))`
+	args := []interface{}{filter.UserID, filter.UserID, model.PostTagTeam, filter.HomeTeamID}
+	return queryPage([]string{condition}, args, filter.UserID, filter.Cursor, filter.Limit)
+}
+
+// UserPosts 用户发的帖子；viewerID 为当前用户（未登录为 0）
+func UserPosts(userID, viewerID, cursor, limit int) (*model.PostPage, error) {
+	return queryPage([]string{"p.user_id = ?"}, []interface{}{userID}, viewerID, cursor, limit)
+}
+
+// Get 帖子详情；与 viewerID 存在拉黑关系的作者的帖子按不存在处理
+func Get(id, viewerID int) (*model.Post, error) {
+	p, err := scanPost(db.GetDB().QueryRow(`
+		SELECT `+postColumns+`
+		FROM posts p JOIN users u ON u.id = p.user_id
+		WHERE p.id = ? AND p.deleted_at IS NULL AND `+social.BlockFilter("p.user_id"),
+		viewerID, id, viewerID, viewerID,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p, loadDetails([]*model.Post{p})
+}
+
+// GetMany 批量读取帖子，按 ID 索引；已删除的和与 viewerID 存在拉黑关系的作者的帖子不在结果中
+func GetMany(ids []int, viewerID int) (map[int]*model.Post, error) {
+	posts := map[int]*model.Post{}
+	if len(ids) == 0 {
+		return posts, nil
+	}
+	args := []interface{}{viewerID}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	args = append(args, viewerID, viewerID)
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	rows, err := db.GetDB().Query(`
+		SELECT `+postColumns+`
+		FROM posts p JOIN users u ON u.id = p.user_id
+		WHERE p.id IN (`+placeholders+`) AND p.deleted_at IS NULL AND `+social.BlockFilter("p.user_id"),
+		args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []*model.Post{}
+	for rows.Next() {
+		p, err := scanPost(rows)
+		if err != nil {
+			return nil, err
+		}
+		posts[p.ID] = p
+		list = append(list, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return posts, loadDetails(list)
+}
+
+// Create 发帖
+func Create(spec Spec) (int, error) {
+	tx, err := db.GetDB().Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("INSERT INTO posts (user_id, content) VALUES (?, ?)", spec.UserID, spec.Content)
+	if err != nil {
+		return 0, err
+	}
+	id, _ := result.LastInsertId()
+	for i, url := range spec.Images {
+		if _, err := tx.Exec("INSERT INTO post_images (post_id, url, position) VALUES (?, ?, ?)", id, url, i); err != nil {
+			return 0, err
+		}
+	}
+	for _, tag := range spec.Tags {
+		if _, err := tx.Exec("INSERT INTO post_tags (post_id, tag_type, target_id) VALUES (?, ?, ?) ON CONFLICT DO NOTHING",
+			id, tag.Type, tag.ID); err != nil {
+			return 0, err
+		}
+	}
+	return int(id), tx.Commit()
+}
+
+// Delete 作者删除帖子（软删除，图片和评论保留）
+func Delete(postID, userID int, now time.Time) error {
+	result, err := db.GetDB().Exec("UPDATE posts SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL AND user_id = ?",
+		now.UTC(), postID, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n > 0 {
+		return nil
+	}
+	var authorID int
+	err = db.GetDB().QueryRow("SELECT user_id FROM posts WHERE id = ? AND deleted_at IS NULL", postID).Scan(&authorID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return ErrNotAuthor
+}
+
+// visiblePost 在事务中读取未删除的帖子作者，并检查与 userID 的拉黑关系
+func visiblePost(tx *sql.Tx, postID, userID int) (int, error) {
+	var authorID int
+	err := tx.QueryRow("SELECT user_id FROM posts WHERE id = ? AND deleted_at IS NULL", postID).Scan(&authorID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	blocked, err := social.IsBlocked(tx, authorID, userID)
+	if err != nil {
+		return 0, err
+	}
+	if blocked {
+		return 0, ErrBlocked
+	}
+	return authorID, nil
+}
+
+// SetLiked 点赞或取消点赞，重复操作无副作用；返回最新的点赞数
+func SetLiked(postID, userID int, liked bool) (int, error) {
+	tx, err := db.GetDB().Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := visiblePost(tx, postID, userID); err != nil {
+		return 0, err
+	}
+
+	var result sql.Result
+	delta := 1
+	if liked {
+		result, err = tx.Exec("INSERT INTO post_likes (post_id, user_id) VALUES (?, ?) ON CONFLICT DO NOTHING", postID, userID)
+	} else {
+		delta = -1
+		result, err = tx.Exec("DELETE FROM post_likes WHERE post_id = ? AND user_id = ?", postID, userID)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if n, _ := result.RowsAffected(); n > 0 {
+		if _, err := tx.Exec("UPDATE posts SET like_count = like_count + ? WHERE id = ?", delta, postID); err != nil {
+			return 0, err
+		}
+	}
+
+	var count int
+	if err := tx.QueryRow("SELECT like_count FROM posts WHERE id = ?", postID).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, tx.Commit()
+}
+
+const commentColumns = `c.id, c.post_id, c.user_id, u.nickname, u.avatar, c.parent_id, c.content, c.deleted_at, c.created_at`
+
+// scanComment 读取评论，extra 为 commentColumns 之后追加的列
+func scanComment(row interface{ Scan(...interface{}) error }, extra ...interface{}) (model.PostComment, error) {
+	var c model.PostComment
+	var parentID sql.NullInt64
+	var deletedAt sql.NullTime
+	dest := []interface{}{&c.ID, &c.PostID, &c.UserID, &c.Nickname, &c.Avatar, &parentID, &c.Content, &deletedAt,
+		&c.CreatedAt}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return c, err
+	}
+	if parentID.Valid {
+		id := int(parentID.Int64)
+		c.ParentID = &id
+	}
+	if deletedAt.Valid {
+		c.Deleted = true
+		c.UserID, c.Nickname, c.Avatar, c.Content = 0, "", "", ""
+	}
+	return c, nil
+}
+
+// Comments 帖子的一级评论（按时间正序分页）及其全部回复；被删除的一级评论仍有回复时保留为占位
+// 不含与 viewerID 存在拉黑关系的用户的评论
+func Comments(postID, viewerID, limit, offset int) ([]model.PostComment, error) {
+	rows, err := db.GetDB().Query(`
+		SELECT `+commentColumns+`
+		FROM post_comments c JOIN users u ON u.id = c.user_id
+		WHERE c.post_id = ? AND c.root_id IS NULL AND `+social.BlockFilter("c.user_id")+`
+			AND (c.deleted_at IS NULL OR EXISTS(
+				SELECT 1 FROM post_comments r WHERE r.root_id = c.id AND r.deleted_at IS NULL))
+		ORDER BY c.id
+		LIMIT ? OFFSET ?
+	`, postID, viewerID, viewerID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := []model.PostComment{}
+	index := map[int]int{}
+	var args []interface{}
+	for rows.Next() {
+		c, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		index[c.ID] = len(comments)
+		args = append(args, c.ID)
+		comments = append(comments, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(comments) == 0 {
+		return comments, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(args)), ",")
+	replyRows, err := db.GetDB().Query(`
+		SELECT `+commentColumns+`, c.root_id
+		FROM post_comments c JOIN users u ON u.id = c.user_id
+		WHERE c.root_id IN (`+placeholders+`) AND c.deleted_at IS NULL AND `+social.BlockFilter("c.user_id")+`
+		ORDER BY c.id
+	`, append(args, viewerID, viewerID)...)
+	if err != nil {
+		return nil, err
+	}
+	defer replyRows.Close()
+	for replyRows.Next() {
+		var rootID int
+		c, err := scanComment(replyRows, &rootID)
+		if err != nil {
+			return nil, err
+		}
+		root := &comments[index[rootID]]
+		root.Replies = append(root.Replies, c)
+	}
+	return comments, replyRows.Err()
+}
+
+// AddComment 发表评论；parentID 不为 0 时为回复，归入被回复评论所在的一级评论下
+// 同时返回应收到通知的用户：回复时为被回复评论的作者，否则为帖子作者
+func AddComment(postID, userID, parentID int, content string) (*model.PostComment, int, error) {
+	tx, err := db.GetDB().Begin()
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
+	recipientID, err := visiblePost(tx, postID, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var parent, root interface{}
+	if parentID != 0 {
+		var rootID sql.NullInt64
+		err := tx.QueryRow("SELECT user_id, root_id FROM post_comments WHERE id = ? AND post_id = ? AND deleted_at IS NULL",
+			parentID, postID).Scan(&recipientID, &rootID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, 0, ErrParentNotFound
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		parent, root = parentID, parentID
+		if rootID.Valid {
+			root = rootID.Int64
+		}
+	}
+
+	result, err := tx.Exec("INSERT INTO post_comments (post_id, user_id, parent_id, root_id, content) VALUES (?, ?, ?, ?, ?)",
+		postID, userID, parent, root, content)
+	if err != nil {
+		return nil, 0, err
+	}
+	id, _ := result.LastInsertId()
+	if _, err := tx.Exec("UPDATE posts SET comment_count = comment_count + 1 WHERE id = ?", postID); err != nil {
+		return nil, 0, err
+	}
+
+	c, err := scanComment(tx.QueryRow("SELECT "+commentColumns+
+		" FROM post_comments c JOIN users u ON u.id = c.user_id WHERE c.id = ?", id))
+	if err != nil {
+		return nil, 0, err
+	}
+	return &c, recipientID, tx.Commit()
+}
+
+// DeleteComment 删除评论（软删除），评论作者和帖子作者都可以删除
+func DeleteComment(commentID, userID int, now time.Time) error {
+	tx, err := db.GetDB().Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var postID, authorID, postAuthorID int
+	err = tx.QueryRow(`
+		SELECT c.post_id, c.user_id, p.user_id
+		FROM post_comments c JOIN posts p ON p.id = c.post_id
+		WHERE c.id = ? AND c.deleted_at IS NULL AND p.deleted_at IS NULL
+	`, commentID).Scan(&postID, &authorID, &postAuthorID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if userID != authorID && userID != postAuthorID {
+		return ErrNotAuthor
+	}
+
+	if _, err := tx.Exec("UPDATE post_comments SET deleted_at = ? WHERE id = ?", now.UTC(), commentID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE posts SET comment_count = comment_count - 1 WHERE id = ?", postID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,139 @@
+package prediction
+
+import (
+	"buzzerbeater/external"
+	"buzzerbeater/model"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// PointRange 球员得分区间（含首尾），Max 为 nil 表示不封顶
+type PointRange struct {
+	Min int  `json:"min"`
+	Max *int `json:"max"`
+}
+
+// DefaultPointRanges 默认得分区间：0-9、10-19、20-29、30+
+var DefaultPointRanges = []PointRange{
+	{Min: 0, Max: intPtr(9)},
+	{Min: 10, Max: intPtr(19)},
+	{Min: 20, Max: intPtr(29)},
+	{Min: 30},
+}
+
+// maxPointRanges 一个盘口最多的得分区间数
+const maxPointRanges = 10
+
+func intPtr(n int) *int {
+	return &n
+}
+
+// Contains 得分是否落在区间内
+func (r PointRange) Contains(points int) bool {
+	return points >= r.Min && (r.Max == nil || points <= *r.Max)
+}
+
+// Label 区间的展示文本，如 10-19、30+
+func (r PointRange) Label() string {
+	if r.Max == nil {
+		return fmt.Sprintf("%d+", r.Min)
+	}
+	return fmt.Sprintf("%d-%d", r.Min, *r.Max)
+}
+
+// ValidatePointRanges 校验得分区间：从 0 开始、首尾相接、最后一个不封顶，保证任何得分都恰好落在一个区间
+func ValidatePointRanges(ranges []PointRange) error {
+	if len(ranges) < 2 || len(ranges) > maxPointRanges {
+		return fmt.Errorf("得分区间数量必须在 2-%d 之间", maxPointRanges)
+	}
+	next := 0
+	for i, r := range ranges {
+		if r.Min != next {
+			return errors.New("得分区间必须从 0 开始且首尾相接")
+		}
+		last := i == len(ranges)-1
+		if last != (r.Max == nil) {
+			return errors.New("只有最后一个得分区间不设上限")
+		}
+		if r.Max != nil {
+			if *r.Max < r.Min {
+				return errors.New("得分区间上限不能小于下限")
+			}
+			next = *r.Max + 1
+		}
+	}
+	return nil
+}
+
+// OptionSpec 创建盘口时的选项定义
+type OptionSpec struct {
+	Label     string
+	TeamID    *int
+	MinPoints *int
+	MaxPoints *int
+}
+
+// MarketSpec 创建盘口时的定义
+type MarketSpec struct {
+	GameID    int
+	GameDate  string
+	Type      string
+	PlayerID  int
+	Title     string
+	LockAt    time.Time
+	CreatedBy int // 0 表示自动生成
+	Options   []OptionSpec
+}
+
+// matchupLabel 比赛的展示文本，如 BOS @ LAL
+func matchupLabel(game *external.NBAGame) string {
+	return fmt.Sprintf("%s @ %s", game.VisitorTeam.Abbreviation, game.HomeTeam.Abbreviation)
+}
+
+// teamLabel 球队的展示名称，优先使用中文名
+func teamLabel(team external.NBATeam) string {
+	if team.FullNameZh != "" {
+		return team.FullNameZh
+	}
+	return team.FullName
+}
+
+// WinnerMarket 比赛胜负盘口
+func WinnerMarket(game *external.NBAGame) (MarketSpec, error) {
+	lockAt, err := game.TipOff()
+	if err != nil {
+		return MarketSpec{}, err
+	}
+	return MarketSpec{
+		GameID:   game.ID,
+		GameDate: game.Date,
+		Type:     model.PredictionWinner,
+		Title:    matchupLabel(game) + " 谁会赢",
+		LockAt:   lockAt,
+		Options: []OptionSpec{
+			{Label: teamLabel(game.HomeTeam), TeamID: intPtr(game.HomeTeam.ID)},
+			{Label: teamLabel(game.VisitorTeam), TeamID: intPtr(game.VisitorTeam.ID)},
+		},
+	}, nil
+}
+
+// PlayerPointsMarket 球员得分区间盘口（ranges 需先经过 ValidatePointRanges 校验）
+func PlayerPointsMarket(game *external.NBAGame, player *external.NBAPlayer, ranges []PointRange) (MarketSpec, error) {
+	lockAt, err := game.TipOff()
+	if err != nil {
+		return MarketSpec{}, err
+	}
+	spec := MarketSpec{
+		GameID:   game.ID,
+		GameDate: game.Date,
+		Type:     model.PredictionPlayerPoints,
+		PlayerID: player.ID,
+		Title:    fmt.Sprintf("%s %s 得分（%s）", player.FirstName, player.LastName, matchupLabel(game)),
+		LockAt:   lockAt,
+	}
+	for _, r := range ranges {
+		spec.Options = append(spec.Options, OptionSpec{Label: r.Label(), MinPoints: intPtr(r.Min), MaxPoints: r.Max})
+	}
+	return spec, nil
+}
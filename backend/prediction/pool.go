@@ -0,0 +1,60 @@
+package prediction
+
+import "math"
+
+// Stake 参与结算的一笔下注
+type Stake struct {
+	ID       int
+	UserID   int
+	OptionID int
+	Amount   int
+}
+
+// Odds 彩池制派彩倍数（含本金）= 总奖池 / 选项奖池，选项无人下注时返回 nil
+// 虚拟币不抽水，所有下注全部返还给猜中的人
+func Odds(total, pool int) *float64 {
+	if pool <= 0 {
+		return nil
+	}
+	odds := math.Floor(float64(total)/float64(pool)*100) / 100
+	return &odds
+}
+
+// Payouts 按下注额占获胜选项奖池的比例瓜分总奖池，返回每笔下注（Stake.ID）的派奖金额
+// 金币为整数，按比例向下取整后剩余的零头按下注先后每笔补 1，保证派出的总额等于总奖池
+// 没有人猜中时 ok 为 false，此时应退还全部下注
+func Payouts(stakes []Stake, winningOptionID int) (payouts map[int]int, ok bool) {
+	total, winning := 0, 0
+	for _, s := range stakes {
+		total += s.Amount
+		if s.OptionID == winningOptionID {
+			winning += s.Amount
+		}
+	}
+	if winning == 0 {
+		return nil, false
+	}
+
+	payouts = make(map[int]int, len(stakes))
+	paid := 0
+	for _, s := range stakes {
+		if s.OptionID != winningOptionID {
+			payouts[s.ID] = 0
+			continue
+		}
+		p := int(int64(s.Amount) * int64(total) / int64(winning))
+		payouts[s.ID] = p
+		paid += p
+	}
+	// stakes 按下注先后排列
+	for _, s := range stakes {
+		if paid >= total {
+			break
+		}
+		if s.OptionID == winningOptionID {
+			payouts[s.ID]++
+			paid++
+		}
+	}
+	return payouts, true
+}
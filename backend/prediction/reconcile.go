@@ -0,0 +1,58 @@
+package prediction
+
+import (
+	"buzzerbeater/db"
+	"buzzerbeater/model"
+	"fmt"
+)
+
+// ReconcileEscrow 校验竞猜盘口与账本一致：选项奖池等于该选项的下注合计，
+// 未结算盘口的托管余额等于下注合计，已结算或已取消盘口的托管余额为 0
+func ReconcileEscrow() ([]string, error) {
+	var problems []string
+
+	rows, err := db.GetDB().Query(`
+		SELECT o.market_id, o.id, o.pool, COALESCE((SELECT SUM(amount) FROM prediction_stakes WHERE option_id = o.id), 0)
+		FROM prediction_options o
+	`)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var marketID, optionID, pool, staked int
+		if err := rows.Scan(&marketID, &optionID, &pool, &staked); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if pool != staked {
+			problems = append(problems, fmt.Sprintf("market %d option %d: pool %d but stakes total %d", marketID, optionID, pool, staked))
+		}
+	}
+	rows.Close()
+
+	rows, err = db.GetDB().Query(`
+		SELECT m.id, m.status,
+		       COALESCE((SELECT SUM(amount) FROM prediction_stakes WHERE market_id = m.id), 0),
+		       COALESCE((SELECT SUM(amount) FROM ledger_entries WHERE account = 'market:' || m.id), 0)
+		FROM prediction_markets m
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var marketID, staked, escrow int
+		var status string
+		if err := rows.Scan(&marketID, &status, &staked, &escrow); err != nil {
+			return nil, err
+		}
+		expected := staked
+		if status == model.PredictionSettled || status == model.PredictionCancelled {
+			expected = 0
+		}
+		if escrow != expected {
+			problems = append(problems, fmt.Sprintf("market %d (%s): escrow balance %d, expected %d", marketID, status, escrow, expected))
+		}
+	}
+	return problems, rows.Err()
+}
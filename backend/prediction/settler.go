@@ -0,0 +1,179 @@
+package prediction
+
+import (
+	"buzzerbeater/external"
+	"buzzerbeater/model"
+	"buzzerbeater/stats"
+	"context"
+	"log"
+	"time"
+)
+
+// upcomingDays 自动为未来几天（含今天）的比赛开设胜负盘口
+const upcomingDays = 2
+
+// statsGracePeriod 开赛后多久仍没有球员单场数据才按未出场退款（比赛约 3 小时，另留 6 小时给上游补数据）
+const statsGracePeriod = 9 * time.Hour
+
+// Settler 定时开设胜负盘口、开赛封盘，并在比赛结束后按结果结算
+type Settler struct {
+	client   *external.NBAClient
+	interval time.Duration
+	now      func() time.Time
+}
+
+// NewSettler 创建结算任务
+func NewSettler(client *external.NBAClient, interval time.Duration) *Settler {
+	return &Settler{client: client, interval: interval, now: time.Now}
+}
+
+// Start 启动后台任务（立即执行一次，之后按间隔执行，ctx 结束时退出）
+func (s *Settler) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			s.RunOnce(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// RunOnce 执行一轮：开设盘口 → 封盘 → 结算，某一步失败不影响后续步骤
+func (s *Settler) RunOnce(ctx context.Context) {
+	now := s.now().UTC()
+	if err := s.openUpcoming(ctx, now); err != nil {
+		log.Printf("Prediction: open markets failed: %v", err)
+	}
+	if err := lockDue(now); err != nil {
+		log.Printf("Prediction: lock markets failed: %v", err)
+	}
+	if err := s.settleLocked(ctx); err != nil {
+		log.Printf("Prediction: settle markets failed: %v", err)
+	}
+}
+
+// openUpcoming 为近期未开赛的比赛开设胜负盘口（已存在的跳过）
+func (s *Settler) openUpcoming(ctx context.Context, now time.Time) error {
+	games, _, err := s.client.GetGames(ctx, external.GameQuery{
+		Seasons:   []int{external.CurrentSeason(now)},
+		StartDate: now.Format("2006-01-02"),
+		EndDate:   now.AddDate(0, 0, upcomingDays).Format("2006-01-02"),
+	})
+	if err != nil {
+		return err
+	}
+	for i := range games {
+		game := &games[i]
+		if game.IsFinal() || game.IsCancelled() {
+			continue
+		}
+		spec, err := WinnerMarket(game)
+		if err != nil || !now.Before(spec.LockAt) {
+			continue
+		}
+		if _, _, err := CreateMarket(spec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// settleLocked 结算已封盘且比赛已结束的盘口，比赛取消时退款
+func (s *Settler) settleLocked(ctx context.Context) error {
+	markets, err := lockedMarkets()
+	if err != nil {
+		return err
+	}
+
+	// 同一场比赛的多个盘口只请求一次比赛结果
+	games := map[int]*external.NBAGame{}
+	for i := range markets {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		m := &markets[i]
+
+		game, ok := games[m.GameID]
+		if !ok {
+			// 结算必须基于最新比分，不使用缓存
+			game, _, err = s.client.GetGame(external.WithoutCache(ctx), m.GameID)
+			if err != nil {
+				log.Printf("Prediction: market %d: fetch game %d failed: %v", m.ID, m.GameID, err)
+				continue
+			}
+			games[m.GameID] = game
+		}
+
+		if err := s.resolve(ctx, m, game); err != nil {
+			log.Printf("Prediction: market %d failed: %v", m.ID, err)
+		}
+	}
+	return nil
+}
+
+// resolve 根据比赛结果结算单个盘口；比赛未结束时不做处理
+func (s *Settler) resolve(ctx context.Context, m *model.PredictionMarket, game *external.NBAGame) error {
+	if game.IsCancelled() {
+		return Cancel(m.ID)
+	}
+	if !game.IsFinal() {
+		return nil
+	}
+	if err := loadOptions(m); err != nil {
+		return err
+	}
+
+	switch m.Type {
+	case model.PredictionWinner:
+		winner := game.HomeTeam.ID
+		if game.VisitorTeamScore > game.HomeTeamScore {
+			winner = game.VisitorTeam.ID
+		}
+		for _, o := range m.Options {
+			if o.TeamID != nil && *o.TeamID == winner {
+				return Settle(m.ID, o.ID)
+			}
+		}
+
+	case model.PredictionPlayerPoints:
+		lines, _, err := s.client.GetStats(external.WithoutCache(ctx), external.GameQuery{
+			GameIDs:   []int{m.GameID},
+			PlayerIDs: []int{m.PlayerID},
+		})
+		if err != nil {
+			return err
+		}
+		// 比赛刚结束时上游可能还没有单场数据，下一轮再试；超过宽限期仍没有数据才按未出场处理
+		if len(lines) == 0 {
+			tipOff, err := game.TipOff()
+			if err == nil && s.now().Before(tipOff.Add(statsGracePeriod)) {
+				return nil
+			}
+			return Cancel(m.ID)
+		}
+		// 球员没有上场（上场时间为 0）时无法判定，退还下注
+		if stats.ParseMinutes(lines[0].Min) <= 0 {
+			return Cancel(m.ID)
+		}
+		points := lines[0].Pts
+		for _, o := range m.Options {
+			r := PointRange{Max: o.MaxPoints}
+			if o.MinPoints != nil {
+				r.Min = *o.MinPoints
+			}
+			if r.Contains(points) {
+				return Settle(m.ID, o.ID)
+			}
+		}
+	}
+
+	// 没有匹配的选项（数据异常）时退款，避免盘口一直挂起
+	return Cancel(m.ID)
+}
@@ -0,0 +1,467 @@
+package prediction
+
+import (
+	"buzzerbeater/db"
+	"buzzerbeater/ledger"
+	"buzzerbeater/model"
+	"buzzerbeater/notify"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNotFound 盘口或选项不存在
+	ErrNotFound = errors.New("prediction market not found")
+	// ErrMarketClosed 盘口已封盘（已开赛、已结算或已取消）
+	ErrMarketClosed = errors.New("prediction market is closed")
+	// ErrKeyReused 同一个 Idempotency-Key 被用于盘口、选项或金额不同的下注
+	ErrKeyReused = errors.New("idempotency key reused for a different stake")
+)
+
+const marketColumns = `
+	m.id, m.game_id, m.game_date, m.type, m.player_id, m.title, m.lock_at, m.status, m.winning_option_id,
+	m.created_at, m.settled_at, COALESCE((SELECT SUM(pool) FROM prediction_options WHERE market_id = m.id), 0)
+`
+
+func scanMarket(row interface{ Scan(...interface{}) error }) (*model.PredictionMarket, error) {
+	var m model.PredictionMarket
+	var winner sql.NullInt64
+	var settledAt sql.NullTime
+	if err := row.Scan(&m.ID, &m.GameID, &m.GameDate, &m.Type, &m.PlayerID, &m.Title, &m.LockAt, &m.Status, &winner,
+		&m.CreatedAt, &settledAt, &m.TotalPool); err != nil {
+		return nil, err
+	}
+	if winner.Valid {
+		id := int(winner.Int64)
+		m.WinningOptionID = &id
+	}
+	if settledAt.Valid {
+		m.SettledAt = &settledAt.Time
+	}
+	return &m, nil
+}
+
+// loadOptions 盘口选项，并按当前奖池计算派彩倍数
+func loadOptions(m *model.PredictionMarket) error {
+	rows, err := db.GetDB().Query(
+		"SELECT id, label, team_id, min_points, max_points, pool FROM prediction_options WHERE market_id = ? ORDER BY id",
+		m.ID,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	m.Options = []model.PredictionOption{}
+	for rows.Next() {
+		var o model.PredictionOption
+		var teamID, minPoints, maxPoints sql.NullInt64
+		if err := rows.Scan(&o.ID, &o.Label, &teamID, &minPoints, &maxPoints, &o.Pool); err != nil {
+			return err
+		}
+		o.TeamID, o.MinPoints, o.MaxPoints = nullInt(teamID), nullInt(minPoints), nullInt(maxPoints)
+		o.Odds = Odds(m.TotalPool, o.Pool)
+		m.Options = append(m.Options, o)
+	}
+	return rows.Err()
+}
+
+func nullInt(n sql.NullInt64) *int {
+	if !n.Valid {
+		return nil
+	}
+	v := int(n.Int64)
+	return &v
+}
+
+// CreateMarket 创建盘口；同一场比赛的同类盘口已存在时直接返回已有盘口的 ID，created 为 false
+func CreateMarket(spec MarketSpec) (id int, created bool, err error) {
+	tx, err := db.GetDB().Begin()
+	if err != nil {
+		return 0, false, err
+	}
+	defer tx.Rollback()
+
+	var createdBy interface{}
+	if spec.CreatedBy > 0 {
+		createdBy = spec.CreatedBy
+	}
+	result, err := tx.Exec(`
+		INSERT INTO prediction_markets (game_id, game_date, type, player_id, title, lock_at, created_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (game_id, type, player_id) DO NOTHING
+	`, spec.GameID, spec.GameDate, spec.Type, spec.PlayerID, spec.Title, spec.LockAt.UTC(), createdBy)
+	if err != nil {
+		return 0, false, err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		err := tx.QueryRow("SELECT id FROM prediction_markets WHERE game_id = ? AND type = ? AND player_id = ?",
+			spec.GameID, spec.Type, spec.PlayerID).Scan(&id)
+		return id, false, err
+	}
+	marketID, _ := result.LastInsertId()
+
+	for _, o := range spec.Options {
+		if _, err := tx.Exec(
+			"INSERT INTO prediction_options (market_id, label, team_id, min_points, max_points) VALUES (?, ?, ?, ?, ?)",
+			marketID, o.Label, o.TeamID, o.MinPoints, o.MaxPoints,
+		); err != nil {
+			return 0, false, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, false, err
+	}
+	return int(marketID), true, nil
+}
+
+// GetMarket 获取盘口及选项
+func GetMarket(id int) (*model.PredictionMarket, error) {
+	m, err := scanMarket(db.GetDB().QueryRow("SELECT "+marketColumns+" FROM prediction_markets m WHERE m.id = ?", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return m, loadOptions(m)
+}
+
+// MarketFilter 盘口查询条件
+type MarketFilter struct {
+	Date   string
+	GameID int
+	Status string
+}
+
+// maxListedMarkets 竞猜大厅一次最多返回的盘口数
+const maxListedMarkets = 200
+
+// ListMarkets 查询盘口（按封盘时间升序）
+func ListMarkets(filter MarketFilter) ([]model.PredictionMarket, error) {
+	conditions := []string{"1 = 1"}
+	args := []interface{}{}
+	if filter.Date != "" {
+		conditions = append(conditions, "m.game_date = ?")
+		args = append(args, filter.Date)
+	}
+	if filter.GameID > 0 {
+		conditions = append(conditions, "m.game_id = ?")
+		args = append(args, filter.GameID)
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, "m.status = ?")
+		args = append(args, filter.Status)
+	}
+	return queryMarkets(`
+		SELECT `+marketColumns+` FROM prediction_markets m
+		WHERE `+strings.Join(conditions, " AND ")+`
+		ORDER BY m.lock_at, m.id
+		LIMIT ?
+	`, append(args, maxListedMarkets)...)
+}
+
+func queryMarkets(query string, args ...interface{}) ([]model.PredictionMarket, error) {
+	rows, err := db.GetDB().Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	markets := []model.PredictionMarket{}
+	for rows.Next() {
+		m, err := scanMarket(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		markets = append(markets, *m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range markets {
+		if err := loadOptions(&markets[i]); err != nil {
+			return nil, err
+		}
+	}
+	return markets, nil
+}
+
+// PlaceStake 下注：金币从用户钱包转入盘口托管账户并计入选项奖池；已到封盘时间的盘口返回 ErrMarketClosed
+// key 为客户端提供的幂等键（可为空），同一用户重复提交相同的键时返回 ledger.ErrDuplicate，不会重复扣款
+func PlaceStake(userID, marketID, optionID, amount int, key string, now time.Time) error {
+	tx, err := db.GetDB().Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if key != "" {
+		// 客户端重试时请求必须与首次一致，否则说明键被误用于另一笔下注
+		var prevMarket, prevOption, prevAmount int
+		err := tx.QueryRow(`
+			SELECT s.market_id, s.option_id, s.amount
+			FROM prediction_stake_keys k JOIN prediction_stakes s ON s.id = k.stake_id
+			WHERE k.user_id = ? AND k.idempotency_key = ?
+		`, userID, key).Scan(&prevMarket, &prevOption, &prevAmount)
+		if err == nil {
+			if prevMarket != marketID || prevOption != optionID || prevAmount != amount {
+				return ErrKeyReused
+			}
+			return ledger.ErrDuplicate
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+	}
+
+	var status string
+	var lockAt time.Time
+	err = tx.QueryRow("SELECT status, lock_at FROM prediction_markets WHERE id = ?", marketID).Scan(&status, &lockAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if status != model.PredictionOpen || !now.Before(lockAt) {
+		return ErrMarketClosed
+	}
+
+	result, err := tx.Exec("UPDATE prediction_options SET pool = pool + ? WHERE id = ? AND market_id = ?", amount, optionID, marketID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	result, err = tx.Exec(
+		"INSERT INTO prediction_stakes (market_id, option_id, user_id, amount) VALUES (?, ?, ?, ?)",
+		marketID, optionID, userID, amount,
+	)
+	if err != nil {
+		return err
+	}
+	stakeID, _ := result.LastInsertId()
+
+	if err := ledger.EnsureWallet(tx, userID); err != nil {
+		return err
+	}
+	ledgerKey := fmt.Sprintf("wager:stake:%d", stakeID)
+	if key != "" {
+		ledgerKey = fmt.Sprintf("wager:%d:%s", userID, key)
+		if _, err := tx.Exec("INSERT INTO prediction_stake_keys (user_id, idempotency_key, stake_id) VALUES (?, ?, ?)",
+			userID, key, stakeID); err != nil {
+			return err
+		}
+	}
+	if _, err := ledger.Post(tx, ledger.Posting{
+		Key:      ledgerKey,
+		Kind:     model.LedgerWager,
+		MarketID: marketID,
+		Entries:  ledger.Transfer(ledger.UserAccount(userID), ledger.MarketAccount(marketID), amount),
+	}); err != nil {
+		// 重复提交时回滚本次插入的下注记录
+		return err
+	}
+	return tx.Commit()
+}
+
+// UserStakes 用户的下注记录（最新的在前）
+func UserStakes(userID int, limit int) ([]model.PredictionStake, error) {
+	rows, err := db.GetDB().Query(`
+		SELECT s.id, s.market_id, m.title, s.option_id, o.label, s.amount, s.payout, m.status, s.created_at
+		FROM prediction_stakes s
+		JOIN prediction_markets m ON m.id = s.market_id
+		JOIN prediction_options o ON o.id = s.option_id
+		WHERE s.user_id = ?
+		ORDER BY s.id DESC
+		LIMIT ?
+	`, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stakes := []model.PredictionStake{}
+	for rows.Next() {
+		var s model.PredictionStake
+		var payout sql.NullInt64
+		if err := rows.Scan(&s.ID, &s.MarketID, &s.MarketTitle, &s.OptionID, &s.OptionLabel, &s.Amount, &payout,
+			&s.Status, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		s.Payout = nullInt(payout)
+		stakes = append(stakes, s)
+	}
+	return stakes, rows.Err()
+}
+
+// lockDue 到开赛时间的盘口封盘
+func lockDue(now time.Time) error {
+	_, err := db.GetDB().Exec("UPDATE prediction_markets SET status = ? WHERE status = ? AND lock_at <= ?",
+		model.PredictionLocked, model.PredictionOpen, now.UTC())
+	return err
+}
+
+// lockedMarkets 已封盘等待结算的盘口（不含选项）
+func lockedMarkets() ([]model.PredictionMarket, error) {
+	rows, err := db.GetDB().Query("SELECT "+marketColumns+" FROM prediction_markets m WHERE m.status = ? ORDER BY m.game_id, m.id",
+		model.PredictionLocked)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	markets := []model.PredictionMarket{}
+	for rows.Next() {
+		m, err := scanMarket(rows)
+		if err != nil {
+			return nil, err
+		}
+		markets = append(markets, *m)
+	}
+	return markets, rows.Err()
+}
+
+// marketStakes 盘口的全部下注（按下注先后）
+func marketStakes(tx *sql.Tx, marketID int) ([]Stake, error) {
+	rows, err := tx.Query("SELECT id, user_id, option_id, amount FROM prediction_stakes WHERE market_id = ? ORDER BY id", marketID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stakes []Stake
+	for rows.Next() {
+		var s Stake
+		if err := rows.Scan(&s.ID, &s.UserID, &s.OptionID, &s.Amount); err != nil {
+			return nil, err
+		}
+		stakes = append(stakes, s)
+	}
+	return stakes, rows.Err()
+}
+
+// Settle 按获胜选项派奖；没有人猜中时退还全部下注并把盘口置为已取消
+func Settle(marketID, winningOptionID int) error {
+	tx, err := db.GetDB().Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stakes, err := marketStakes(tx, marketID)
+	if err != nil {
+		return err
+	}
+	payouts, ok := Payouts(stakes, winningOptionID)
+	if !ok {
+		tx.Rollback()
+		return Cancel(marketID)
+	}
+
+	// 只结算一次：盘口状态在同一事务中从 locked 改为 settled
+	result, err := tx.Exec(`
+		UPDATE prediction_markets SET status = ?, winning_option_id = ?, settled_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND status = ?
+	`, model.PredictionSettled, winningOptionID, marketID, model.PredictionLocked)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrMarketClosed
+	}
+
+	for _, s := range stakes {
+		payout := payouts[s.ID]
+		if _, err := tx.Exec("UPDATE prediction_stakes SET payout = ? WHERE id = ?", payout, s.ID); err != nil {
+			return err
+		}
+		if payout == 0 {
+			continue
+		}
+		if err := postOnce(tx, ledger.Posting{
+			Key:      fmt.Sprintf("payout:%d", s.ID),
+			Kind:     model.LedgerPayout,
+			MarketID: marketID,
+			Entries:  ledger.Transfer(ledger.MarketAccount(marketID), ledger.UserAccount(s.UserID), payout),
+		}); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	notifyStakes(marketID, stakes, payouts, false)
+	return nil
+}
+
+// Cancel 取消盘口并退还全部下注（比赛取消、球员未出场或无人猜中）
+func Cancel(marketID int) error {
+	tx, err := db.GetDB().Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE prediction_markets SET status = ?, settled_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND status IN (?, ?)
+	`, model.PredictionCancelled, marketID, model.PredictionOpen, model.PredictionLocked)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrMarketClosed
+	}
+
+	stakes, err := marketStakes(tx, marketID)
+	if err != nil {
+		return err
+	}
+	for _, s := range stakes {
+		if _, err := tx.Exec("UPDATE prediction_stakes SET payout = ? WHERE id = ?", s.Amount, s.ID); err != nil {
+			return err
+		}
+		if err := postOnce(tx, ledger.Posting{
+			Key:      fmt.Sprintf("refund:%d", s.ID),
+			Kind:     model.LedgerRefund,
+			MarketID: marketID,
+			Entries:  ledger.Transfer(ledger.MarketAccount(marketID), ledger.UserAccount(s.UserID), s.Amount),
+		}); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	notifyStakes(marketID, stakes, nil, true)
+	return nil
+}
+
+// notifyStakes 通知每笔下注的结算或退款结果
+func notifyStakes(marketID int, stakes []Stake, payouts map[int]int, cancelled bool) {
+	var title string
+	if err := db.GetDB().QueryRow("SELECT title FROM prediction_markets WHERE id = ?", marketID).Scan(&title); err != nil {
+		log.Printf("Notify: load prediction market %d failed: %v", marketID, err)
+		return
+	}
+	for _, s := range stakes {
+		notify.Emit(notify.PredictionSettled(s.UserID, marketID, s.ID, title, s.Amount, payouts[s.ID], cancelled))
+	}
+}
+
+// postOnce 记账，已经记过（幂等键重复）时视为成功
+func postOnce(tx *sql.Tx, p ledger.Posting) error {
+	_, err := ledger.Post(tx, p)
+	if errors.Is(err, ledger.ErrDuplicate) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,27 @@
+package review
+
+import (
+	"database/sql"
+	"math"
+)
+
+// Average 平均评分（保留一位小数），没有评价时为 nil
+func Average(count, total int) *float64 {
+	if count <= 0 {
+		return nil
+	}
+	avg := math.Round(float64(total)/float64(count)*10) / 10
+	return &avg
+}
+
+// applyStats 增量更新球场评分汇总：新增评价为 (1, rating)，修改评分为 (0, new-old)，删除或下架为 (-1, -rating)
+func applyStats(tx *sql.Tx, courtID, deltaCount, deltaTotal int) error {
+	_, err := tx.Exec(`
+		INSERT INTO court_rating_stats (court_id, review_count, rating_total) VALUES (?, ?, ?)
+		ON CONFLICT(court_id) DO UPDATE SET
+			review_count = review_count + excluded.review_count,
+			rating_total = rating_total + excluded.rating_total,
+			updated_at = CURRENT_TIMESTAMP
+	`, courtID, deltaCount, deltaTotal)
+	return err
+}
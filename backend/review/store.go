@@ -0,0 +1,363 @@
+package review
+
+import (
+	"buzzerbeater/db"
+	"buzzerbeater/model"
+	"buzzerbeater/social"
+	"database/sql"
+	"errors"
+	"strings"
+)
+
+var (
+	// ErrNotFound 评价或球场不存在
+	ErrNotFound = errors.New("review not found")
+	// ErrExists 用户已评价过该球场
+	ErrExists = errors.New("review already exists")
+	// ErrNotAuthor 只有作者可以修改或删除评价
+	ErrNotAuthor = errors.New("not the author of the review")
+	// ErrRemoved 评价已被管理员下架
+	ErrRemoved = errors.New("review has been removed")
+	// ErrOwnReview 不能给自己的评价投“有用”
+	ErrOwnReview = errors.New("cannot vote on own review")
+)
+
+// 评价列表排序方式
+const (
+	SortRecent  = "recent"
+	SortHelpful = "helpful"
+	SortRating  = "rating"
+)
+
+var sortOrders = map[string]string{
+	SortRecent:  "r.created_at DESC, r.id DESC",
+	SortHelpful: "r.helpful_count DESC, r.id DESC",
+	SortRating:  "r.rating DESC, r.id DESC",
+}
+
+// ValidSort 是否为支持的排序方式
+func ValidSort(sort string) bool {
+	_, ok := sortOrders[sort]
+	return ok
+}
+
+const reviewColumns = `
+	r.id, r.court_id, r.user_id, u.nickname, u.avatar, r.rating, r.content, r.helpful_count, r.created_at, r.updated_at
+`
+
+func scanReview(row interface{ Scan(...interface{}) error }) (*model.CourtReview, error) {
+	var r model.CourtReview
+	if err := row.Scan(&r.ID, &r.CourtID, &r.UserID, &r.Nickname, &r.Avatar, &r.Rating, &r.Content, &r.HelpfulCount,
+		&r.CreatedAt, &r.UpdatedAt); err != nil {
+		return nil, err
+	}
+	r.Photos = []string{}
+	return &r, nil
+}
+
+// loadPhotos 批量填充评价照片
+func loadPhotos(reviews []*model.CourtReview) error {
+	if len(reviews) == 0 {
+		return nil
+	}
+	byID := make(map[int]*model.CourtReview, len(reviews))
+	args := make([]interface{}, 0, len(reviews))
+	for _, r := range reviews {
+		byID[r.ID] = r
+		args = append(args, r.ID)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(reviews)), ",")
+	rows, err := db.GetDB().Query(
+		"SELECT review_id, url FROM court_review_photos WHERE review_id IN ("+placeholders+") ORDER BY review_id, position",
+		args...,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var reviewID int
+		var url string
+		if err := rows.Scan(&reviewID, &url); err != nil {
+			return err
+		}
+		byID[reviewID].Photos = append(byID[reviewID].Photos, url)
+	}
+	return rows.Err()
+}
+
+// Get 评价详情（不含已下架的）
+func Get(id int) (*model.CourtReview, error) {
+	r, err := scanReview(db.GetDB().QueryRow(
+		"SELECT "+reviewColumns+" FROM court_reviews r JOIN users u ON u.id = r.user_id WHERE r.id = ? AND r.status = ?",
+		id, model.ReviewVisible,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r, loadPhotos([]*model.CourtReview{r})
+}
+
+// List 球场的评价列表，不含与 viewerID 存在拉黑关系的用户的评价（未登录为 0）
+func List(courtID, viewerID int, sort string, limit, offset int) ([]*model.CourtReview, error) {
+	rows, err := db.GetDB().Query(`
+		SELECT `+reviewColumns+`
+		FROM court_reviews r
+		JOIN users u ON u.id = r.user_id
+		WHERE r.court_id = ? AND r.status = ? AND `+social.BlockFilter("r.user_id")+`
+		ORDER BY `+sortOrders[sort]+`
+		LIMIT ? OFFSET ?
+	`, courtID, model.ReviewVisible, viewerID, viewerID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reviews := []*model.CourtReview{}
+	for rows.Next() {
+		r, err := scanReview(rows)
+		if err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return reviews, loadPhotos(reviews)
+}
+
+func savePhotos(tx *sql.Tx, reviewID int, photos []string) error {
+	for i, url := range photos {
+		if _, err := tx.Exec("INSERT INTO court_review_photos (review_id, url, position) VALUES (?, ?, ?)",
+			reviewID, url, i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reviewPhotos 评价当前的照片地址
+func reviewPhotos(tx *sql.Tx, reviewID int) ([]string, error) {
+	rows, err := tx.Query("SELECT url FROM court_review_photos WHERE review_id = ? ORDER BY position", reviewID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var photos []string
+	for rows.Next() {
+		var url string
+		if err := rows.Scan(&url); err != nil {
+			return nil, err
+		}
+		photos = append(photos, url)
+	}
+	return photos, rows.Err()
+}
+
+// Create 发表评价并更新球场评分；已有未下架的评价时返回 ErrExists，原评价被下架后可以重新评价
+func Create(courtID, userID, rating int, content string, photos []string) (int, error) {
+	tx, err := db.GetDB().Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM courts WHERE id = ?)", courtID).Scan(&exists); err != nil {
+		return 0, err
+	}
+	if !exists {
+		return 0, ErrNotFound
+	}
+
+	result, err := tx.Exec(
+		"INSERT INTO court_reviews (court_id, user_id, rating, content) VALUES (?, ?, ?, ?) ON CONFLICT(court_id, user_id) WHERE status = 'visible' DO NOTHING",
+		courtID, userID, rating, content,
+	)
+	if err != nil {
+		return 0, err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return 0, ErrExists
+	}
+	id, _ := result.LastInsertId()
+	if err := savePhotos(tx, int(id), photos); err != nil {
+		return 0, err
+	}
+	if err := applyStats(tx, courtID, 1, rating); err != nil {
+		return 0, err
+	}
+	return int(id), tx.Commit()
+}
+
+// authorReview 在事务中读取作者本人的、未下架的评价
+func authorReview(tx *sql.Tx, reviewID, userID int) (courtID, rating int, err error) {
+	var authorID int
+	var status string
+	err = tx.QueryRow("SELECT court_id, user_id, rating, status FROM court_reviews WHERE id = ?", reviewID).
+		Scan(&courtID, &authorID, &rating, &status)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		err = ErrNotFound
+	case err != nil:
+	case authorID != userID:
+		err = ErrNotAuthor
+	case status == model.ReviewRemoved:
+		err = ErrRemoved
+	}
+	return
+}
+
+// Update 修改评价；photos 为 nil 时保留原有照片，否则整体替换并返回被替换掉的照片地址
+func Update(reviewID, userID, rating int, content string, photos []string) ([]string, error) {
+	tx, err := db.GetDB().Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	courtID, oldRating, err := authorReview(tx, reviewID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec("UPDATE court_reviews SET rating = ?, content = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?",
+		rating, content, reviewID); err != nil {
+		return nil, err
+	}
+
+	var replaced []string
+	if photos != nil {
+		if replaced, err = reviewPhotos(tx, reviewID); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec("DELETE FROM court_review_photos WHERE review_id = ?", reviewID); err != nil {
+			return nil, err
+		}
+		if err := savePhotos(tx, reviewID, photos); err != nil {
+			return nil, err
+		}
+	}
+	if rating != oldRating {
+		if err := applyStats(tx, courtID, 0, rating-oldRating); err != nil {
+			return nil, err
+		}
+	}
+	return replaced, tx.Commit()
+}
+
+// Delete 作者删除评价，返回需要清理的照片地址；已下架的评价保留以便申诉复核，不能删除
+func Delete(reviewID, userID int) ([]string, error) {
+	tx, err := db.GetDB().Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	courtID, rating, err := authorReview(tx, reviewID, userID)
+	if err != nil {
+		return nil, err
+	}
+	photos, err := reviewPhotos(tx, reviewID)
+	if err != nil {
+		return nil, err
+	}
+	for _, query := range []string{
+		"DELETE FROM court_review_photos WHERE review_id = ?",
+		"DELETE FROM court_review_votes WHERE review_id = ?",
+		"DELETE FROM court_reviews WHERE id = ?",
+	} {
+		if _, err := tx.Exec(query, reviewID); err != nil {
+			return nil, err
+		}
+	}
+	if err := applyStats(tx, courtID, -1, -rating); err != nil {
+		return nil, err
+	}
+	return photos, tx.Commit()
+}
+
+// SetHelpful 投或取消“有用”，重复操作无副作用；返回最新的有用数
+func SetHelpful(reviewID, userID int, helpful bool) (int, error) {
+	tx, err := db.GetDB().Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var authorID int
+	err = tx.QueryRow("SELECT user_id FROM court_reviews WHERE id = ? AND status = ?", reviewID, model.ReviewVisible).
+		Scan(&authorID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	if authorID == userID {
+		return 0, ErrOwnReview
+	}
+
+	var result sql.Result
+	delta := 1
+	if helpful {
+		result, err = tx.Exec("INSERT INTO court_review_votes (review_id, user_id) VALUES (?, ?) ON CONFLICT DO NOTHING",
+			reviewID, userID)
+	} else {
+		delta = -1
+		result, err = tx.Exec("DELETE FROM court_review_votes WHERE review_id = ? AND user_id = ?", reviewID, userID)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if n, _ := result.RowsAffected(); n > 0 {
+		if _, err := tx.Exec("UPDATE court_reviews SET helpful_count = helpful_count + ? WHERE id = ?", delta, reviewID); err != nil {
+			return 0, err
+		}
+	}
+
+	var count int
+	if err := tx.QueryRow("SELECT helpful_count FROM court_reviews WHERE id = ?", reviewID).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, tx.Commit()
+}
+
+// TakeDown 管理员下架评价，评分从球场汇总中扣除；照片保留以便申诉复核
+func TakeDown(reviewID, moderatorID int, reason string) error {
+	tx, err := db.GetDB().Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var courtID, rating int
+	var status string
+	err = tx.QueryRow("SELECT court_id, rating, status FROM court_reviews WHERE id = ?", reviewID).
+		Scan(&courtID, &rating, &status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if status == model.ReviewRemoved {
+		return ErrRemoved
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE court_reviews SET status = ?, removed_by = ?, removed_reason = ?, removed_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, model.ReviewRemoved, moderatorID, reason, reviewID); err != nil {
+		return err
+	}
+	if err := applyStats(tx, courtID, -1, -rating); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,37 @@
+package social
+
+import (
+	"buzzerbeater/db"
+	"buzzerbeater/model"
+)
+
+// DefaultPrivacy 未设置时全部公开
+var DefaultPrivacy = model.PrivacySettings{ShowTeam: true, ShowJoinDate: true, ShowCounts: true, ShowAchievements: true}
+
+// GetPrivacy 用户的主页隐私设置
+func GetPrivacy(userID int) (model.PrivacySettings, error) {
+	p := DefaultPrivacy
+	err := db.GetDB().QueryRow(`
+		SELECT
+			COALESCE((SELECT show_team FROM user_privacy WHERE user_id = ?), 1),
+			COALESCE((SELECT show_join_date FROM user_privacy WHERE user_id = ?), 1),
+			COALESCE((SELECT show_counts FROM user_privacy WHERE user_id = ?), 1),
+			COALESCE((SELECT show_achievements FROM user_privacy WHERE user_id = ?), 1)
+	`, userID, userID, userID, userID).Scan(&p.ShowTeam, &p.ShowJoinDate, &p.ShowCounts, &p.ShowAchievements)
+	return p, err
+}
+
+// SavePrivacy 保存主页隐私设置
+func SavePrivacy(userID int, p model.PrivacySettings) error {
+	_, err := db.GetDB().Exec(`
+		INSERT INTO user_privacy (user_id, show_team, show_join_date, show_counts, show_achievements)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET
+			show_team = excluded.show_team,
+			show_join_date = excluded.show_join_date,
+			show_counts = excluded.show_counts,
+			show_achievements = excluded.show_achievements,
+			updated_at = CURRENT_TIMESTAMP
+	`, userID, p.ShowTeam, p.ShowJoinDate, p.ShowCounts, p.ShowAchievements)
+	return err
+}
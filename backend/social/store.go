@@ -0,0 +1,229 @@
+package social
+
+import (
+	"buzzerbeater/db"
+	"buzzerbeater/model"
+	"database/sql"
+	"errors"
+)
+
+var (
+	// ErrUserNotFound 目标用户不存在
+	ErrUserNotFound = errors.New("user not found")
+	// ErrSelf 不能关注或拉黑自己
+	ErrSelf = errors.New("cannot target yourself")
+	// ErrBlocked 双方存在拉黑关系
+	ErrBlocked = errors.New("user is blocked")
+)
+
+type querier interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// BlockFilter 排除与 viewer 存在拉黑关系（任一方向）的用户，column 为内容作者的列名
+// 返回的条件包含两个 viewer 占位符；viewer 为 0（未登录）时不排除任何用户
+func BlockFilter(column string) string {
+	return "NOT EXISTS (SELECT 1 FROM user_blocks WHERE (blocker_id = ? AND blocked_id = " + column +
+		") OR (blocked_id = ? AND blocker_id = " + column + "))"
+}
+
+func userExists(q querier, userID int) (bool, error) {
+	var exists bool
+	err := q.QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE id = ?)", userID).Scan(&exists)
+	return exists, err
+}
+
+// IsBlocked 两个用户之间是否存在拉黑关系（任一方向）
+func IsBlocked(q querier, a, b int) (bool, error) {
+	var blocked bool
+	err := q.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM user_blocks WHERE (blocker_id = ? AND blocked_id = ?) OR (blocker_id = ? AND blocked_id = ?))
+	`, a, b, b, a).Scan(&blocked)
+	return blocked, err
+}
+
+// IsBlocking blockerID 是否拉黑了 blockedID
+func IsBlocking(blockerID, blockedID int) (bool, error) {
+	var blocking bool
+	err := db.GetDB().QueryRow("SELECT EXISTS(SELECT 1 FROM user_blocks WHERE blocker_id = ? AND blocked_id = ?)",
+		blockerID, blockedID).Scan(&blocking)
+	return blocking, err
+}
+
+// Follow 关注用户，已关注时 created 为 false；存在拉黑关系时返回 ErrBlocked
+func Follow(followerID, followeeID int) (created bool, err error) {
+	if followerID == followeeID {
+		return false, ErrSelf
+	}
+	tx, err := db.GetDB().Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	exists, err := userExists(tx, followeeID)
+	if err != nil {
+		return false, err
+	}
+	if !exists {
+		return false, ErrUserNotFound
+	}
+	blocked, err := IsBlocked(tx, followerID, followeeID)
+	if err != nil {
+		return false, err
+	}
+	if blocked {
+		return false, ErrBlocked
+	}
+
+	result, err := tx.Exec("INSERT INTO user_follows (follower_id, followee_id) VALUES (?, ?) ON CONFLICT DO NOTHING",
+		followerID, followeeID)
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, tx.Commit()
+}
+
+// Unfollow 取消关注，未关注时不报错
+func Unfollow(followerID, followeeID int) error {
+	_, err := db.GetDB().Exec("DELETE FROM user_follows WHERE follower_id = ? AND followee_id = ?", followerID, followeeID)
+	return err
+}
+
+// Block 拉黑用户，同时解除双方的关注关系
+func Block(blockerID, blockedID int) error {
+	if blockerID == blockedID {
+		return ErrSelf
+	}
+	tx, err := db.GetDB().Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	exists, err := userExists(tx, blockedID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrUserNotFound
+	}
+	if _, err := tx.Exec("INSERT INTO user_blocks (blocker_id, blocked_id) VALUES (?, ?) ON CONFLICT DO NOTHING",
+		blockerID, blockedID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(
+		"DELETE FROM user_follows WHERE (follower_id = ? AND followee_id = ?) OR (follower_id = ? AND followee_id = ?)",
+		blockerID, blockedID, blockedID, blockerID,
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Unblock 取消拉黑（不恢复之前的关注关系）
+func Unblock(blockerID, blockedID int) error {
+	_, err := db.GetDB().Exec("DELETE FROM user_blocks WHERE blocker_id = ? AND blocked_id = ?", blockerID, blockedID)
+	return err
+}
+
+// Counts 用户的粉丝、关注和互关好友数
+func Counts(userID int) (model.SocialCounts, error) {
+	var counts model.SocialCounts
+	err := db.GetDB().QueryRow(`
+		SELECT
+			(SELECT COUNT(*) FROM user_follows WHERE followee_id = ?),
+			(SELECT COUNT(*) FROM user_follows WHERE follower_id = ?),
+			(SELECT COUNT(*) FROM user_follows f
+			 JOIN user_follows b ON b.follower_id = f.followee_id AND b.followee_id = f.follower_id
+			 WHERE f.follower_id = ?)
+	`, userID, userID, userID).Scan(&counts.Followers, &counts.Following, &counts.Friends)
+	return counts, err
+}
+
+// GetRelationship 当前用户 viewerID 与 targetID 的关系
+func GetRelationship(viewerID, targetID int) (model.Relationship, error) {
+	var r model.Relationship
+	err := db.GetDB().QueryRow(`
+		SELECT
+			EXISTS(SELECT 1 FROM user_follows WHERE follower_id = ? AND followee_id = ?),
+			EXISTS(SELECT 1 FROM user_follows WHERE follower_id = ? AND followee_id = ?),
+			EXISTS(SELECT 1 FROM user_blocks WHERE blocker_id = ? AND blocked_id = ?),
+			EXISTS(SELECT 1 FROM user_blocks WHERE blocker_id = ? AND blocked_id = ?)
+	`, viewerID, targetID, targetID, viewerID, viewerID, targetID, targetID, viewerID).
+		Scan(&r.Following, &r.FollowedBy, &r.Blocking, &r.BlockedBy)
+	r.Friend = r.Following && r.FollowedBy
+	return r, err
+}
+
+// 用户列表类型
+const (
+	ListFollowers = "followers"
+	ListFollowing = "following"
+	ListFriends   = "friends"
+)
+
+// listQueries 各类列表的查询，参数依次为：列表所属用户、viewer、viewer（拉黑过滤）
+// 最后一列表示列表中的用户与所属用户是否互相关注
+var listQueries = map[string]string{
+	ListFollowers: `
+		SELECT u.id, u.nickname, u.avatar, f.created_at,
+			EXISTS(SELECT 1 FROM user_follows b WHERE b.follower_id = f.followee_id AND b.followee_id = u.id)
+		FROM user_follows f JOIN users u ON u.id = f.follower_id
+		WHERE f.followee_id = ? AND ` + BlockFilter("u.id") + `
+		ORDER BY f.created_at DESC, u.id DESC`,
+	ListFollowing: `
+		SELECT u.id, u.nickname, u.avatar, f.created_at,
+			EXISTS(SELECT 1 FROM user_follows b WHERE b.follower_id = u.id AND b.followee_id = f.follower_id)
+		FROM user_follows f JOIN users u ON u.id = f.followee_id
+		WHERE f.follower_id = ? AND ` + BlockFilter("u.id") + `
+		ORDER BY f.created_at DESC, u.id DESC`,
+	ListFriends: `
+		SELECT u.id, u.nickname, u.avatar, f.created_at, 1
+		FROM user_follows f
+		JOIN user_follows b ON b.follower_id = f.followee_id AND b.followee_id = f.follower_id
+		JOIN users u ON u.id = f.followee_id
+		WHERE f.follower_id = ? AND ` + BlockFilter("u.id") + `
+		ORDER BY f.created_at DESC, u.id DESC`,
+}
+
+// ValidList 是否为支持的列表类型
+func ValidList(kind string) bool {
+	_, ok := listQueries[kind]
+	return ok
+}
+
+// List 用户的粉丝、关注或好友；viewerID 为当前用户（未登录为 0），与其存在拉黑关系的用户不出现在列表中
+func List(kind string, userID, viewerID, limit, offset int) ([]model.UserSummary, error) {
+	return queryUsers(listQueries[kind]+" LIMIT ? OFFSET ?", userID, viewerID, viewerID, limit, offset)
+}
+
+// Blocks 用户的黑名单
+func Blocks(userID, limit, offset int) ([]model.UserSummary, error) {
+	return queryUsers(`
+		SELECT u.id, u.nickname, u.avatar, k.created_at, 0
+		FROM user_blocks k JOIN users u ON u.id = k.blocked_id
+		WHERE k.blocker_id = ?
+		ORDER BY k.created_at DESC, u.id DESC
+		LIMIT ? OFFSET ?
+	`, userID, limit, offset)
+}
+
+func queryUsers(query string, args ...interface{}) ([]model.UserSummary, error) {
+	rows, err := db.GetDB().Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []model.UserSummary{}
+	for rows.Next() {
+		var u model.UserSummary
+		if err := rows.Scan(&u.ID, &u.Nickname, &u.Avatar, &u.Since, &u.Friend); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
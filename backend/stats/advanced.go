@@ -0,0 +1,128 @@
+package stats
+
+import (
+	"buzzerbeater/external"
+	"math"
+)
+
+// 联盟平均参数（用于缺少球队数据时的估算，取近几个赛季的均值）
+const (
+	leaguePace           = 99.0  // 每 48 分钟回合数
+	leagueTeamPlaysPer48 = 112.0 // 球队每 48 分钟的 FGA + 0.44*FTA + TOV
+)
+
+// Line 一组计数数据（用于每 36 分钟、每百回合等口径）
+type Line struct {
+	Pts      float64 `json:"pts"`
+	Reb      float64 `json:"reb"`
+	Ast      float64 `json:"ast"`
+	Stl      float64 `json:"stl"`
+	Blk      float64 `json:"blk"`
+	Turnover float64 `json:"turnover"`
+	Fgm      float64 `json:"fgm"`
+	Fga      float64 `json:"fga"`
+	Fg3m     float64 `json:"fg3m"`
+	Fg3a     float64 `json:"fg3a"`
+	Ftm      float64 `json:"ftm"`
+	Fta      float64 `json:"fta"`
+}
+
+// Advanced 进阶数据
+type Advanced struct {
+	Minutes         float64 `json:"minutes"`           // 场均上场时间（分钟，已解析）
+	TrueShootingPct float64 `json:"true_shooting_pct"` // 真实命中率 TS%
+	EffectiveFGPct  float64 `json:"effective_fg_pct"`  // 有效命中率 eFG%
+	UsageRate       float64 `json:"usage_rate"`        // 球权使用率（按联盟平均节奏估算）
+	AstToRatio      float64 `json:"ast_to_ratio"`      // 助攻失误比
+	PER             float64 `json:"per"`               // 简化效率值（每 36 分钟 Game Score）
+	Per36           Line    `json:"per_36"`
+	Per100          Line    `json:"per_100"`
+}
+
+// Compute 由场均数据计算进阶数据
+func Compute(avg *external.NBASeasonAverage) Advanced {
+	minutes := ParseMinutes(avg.Min).Minutes()
+	perGame := Line{
+		Pts:      avg.Pts,
+		Reb:      avg.Reb,
+		Ast:      avg.Ast,
+		Stl:      avg.Stl,
+		Blk:      avg.Blk,
+		Turnover: avg.Turnover,
+		Fgm:      avg.Fgm,
+		Fga:      avg.Fga,
+		Fg3m:     avg.Fg3m,
+		Fg3a:     avg.Fg3a,
+		Ftm:      avg.Ftm,
+		Fta:      avg.Fta,
+	}
+
+	adv := Advanced{
+		Minutes:         round(minutes, 1),
+		TrueShootingPct: round(TrueShootingPct(avg.Pts, avg.Fga, avg.Fta), 3),
+		EffectiveFGPct:  round(EffectiveFGPct(avg.Fgm, avg.Fg3m, avg.Fga), 3),
+		UsageRate:       round(UsageRate(avg.Fga, avg.Fta, avg.Turnover, minutes), 3),
+		AstToRatio:      round(safeDiv(avg.Ast, avg.Turnover), 2),
+	}
+	if minutes > 0 {
+		adv.Per36 = perGame.scale(36 / minutes)
+		adv.Per100 = perGame.scale(100 / (minutes / 48 * leaguePace))
+		adv.PER = round(GameScore(avg)*36/minutes, 1)
+	}
+	return adv
+}
+
+// TrueShootingPct 真实命中率 = PTS / (2 * (FGA + 0.44 * FTA))
+func TrueShootingPct(pts, fga, fta float64) float64 {
+	return safeDiv(pts, 2*(fga+0.44*fta))
+}
+
+// EffectiveFGPct 有效命中率 = (FGM + 0.5 * 3PM) / FGA
+func EffectiveFGPct(fgm, fg3m, fga float64) float64 {
+	return safeDiv(fgm+0.5*fg3m, fga)
+}
+
+// UsageRate 球权使用率估算：球员在场时终结的回合占球队回合的比例
+// 没有球队总数据时，以联盟平均的球队每 48 分钟回合终结数代替
+func UsageRate(fga, fta, tov, minutes float64) float64 {
+	if minutes <= 0 {
+		return 0
+	}
+	return (fga + 0.44*fta + tov) / (minutes / 48 * leagueTeamPlaysPer48)
+}
+
+// GameScore Hollinger Game Score（缺少犯规数据，不计犯规项）
+func GameScore(avg *external.NBASeasonAverage) float64 {
+	return avg.Pts + 0.4*avg.Fgm - 0.7*avg.Fga - 0.4*(avg.Fta-avg.Ftm) +
+		0.7*avg.Oreb + 0.3*avg.Dreb + avg.Stl + 0.7*avg.Ast + 0.7*avg.Blk - avg.Turnover
+}
+
+// scale 按系数换算并保留一位小数
+func (l Line) scale(factor float64) Line {
+	return Line{
+		Pts:      round(l.Pts*factor, 1),
+		Reb:      round(l.Reb*factor, 1),
+		Ast:      round(l.Ast*factor, 1),
+		Stl:      round(l.Stl*factor, 1),
+		Blk:      round(l.Blk*factor, 1),
+		Turnover: round(l.Turnover*factor, 1),
+		Fgm:      round(l.Fgm*factor, 1),
+		Fga:      round(l.Fga*factor, 1),
+		Fg3m:     round(l.Fg3m*factor, 1),
+		Fg3a:     round(l.Fg3a*factor, 1),
+		Ftm:      round(l.Ftm*factor, 1),
+		Fta:      round(l.Fta*factor, 1),
+	}
+}
+
+func safeDiv(a, b float64) float64 {
+	if b == 0 {
+		return 0
+	}
+	return a / b
+}
+
+func round(v float64, digits int) float64 {
+	p := math.Pow(10, float64(digits))
+	return math.Round(v*p) / p
+}
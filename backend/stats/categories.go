@@ -0,0 +1,135 @@
+package stats
+
+import (
+	"buzzerbeater/external"
+	"sort"
+	"time"
+)
+
+// Category 统计类别（用于对比、排行榜）
+type Category struct {
+	Key           string  `json:"key"`
+	Name          string  `json:"name"`
+	LowerIsBetter bool    `json:"lower_is_better"`
+	MinAttempts   float64 `json:"min_attempts,omitempty"` // 命中率类别进入排行榜要求的场均出手（罚球）次数
+	value         func(*external.NBASeasonAverage) float64
+	attempts      func(*external.NBASeasonAverage) float64
+}
+
+// Value 取该类别的数值
+func (c Category) Value(avg *external.NBASeasonAverage) float64 {
+	return c.value(avg)
+}
+
+// Qualified 是否满足该类别的上榜门槛（命中率类别要求足够的场均出手，避免几投全中的球员排在前面）
+func (c Category) Qualified(avg *external.NBASeasonAverage) bool {
+	return c.attempts == nil || c.attempts(avg) >= c.MinAttempts
+}
+
+// Better a 是否优于 b
+func (c Category) Better(a, b float64) bool {
+	if c.LowerIsBetter {
+		return a < b
+	}
+	return a > b
+}
+
+// Categories 支持的统计类别
+var Categories = []Category{
+	{Key: "pts", Name: "得分", value: func(a *external.NBASeasonAverage) float64 { return a.Pts }},
+	{Key: "reb", Name: "篮板", value: func(a *external.NBASeasonAverage) float64 { return a.Reb }},
+	{Key: "ast", Name: "助攻", value: func(a *external.NBASeasonAverage) float64 { return a.Ast }},
+	{Key: "stl", Name: "抢断", value: func(a *external.NBASeasonAverage) float64 { return a.Stl }},
+	{Key: "blk", Name: "盖帽", value: func(a *external.NBASeasonAverage) float64 { return a.Blk }},
+	{Key: "fg3m", Name: "三分命中", value: func(a *external.NBASeasonAverage) float64 { return a.Fg3m }},
+	{Key: "fg_pct", Name: "投篮命中率", MinAttempts: 5, value: func(a *external.NBASeasonAverage) float64 { return a.FgPct },
+		attempts: func(a *external.NBASeasonAverage) float64 { return a.Fga }},
+	{Key: "fg3_pct", Name: "三分命中率", MinAttempts: 2, value: func(a *external.NBASeasonAverage) float64 { return a.Fg3Pct },
+		attempts: func(a *external.NBASeasonAverage) float64 { return a.Fg3a }},
+	{Key: "ft_pct", Name: "罚球命中率", MinAttempts: 1.5, value: func(a *external.NBASeasonAverage) float64 { return a.FtPct },
+		attempts: func(a *external.NBASeasonAverage) float64 { return a.Fta }},
+	{Key: "ts_pct", Name: "真实命中率", MinAttempts: 5, value: func(a *external.NBASeasonAverage) float64 { return TrueShootingPct(a.Pts, a.Fga, a.Fta) },
+		attempts: func(a *external.NBASeasonAverage) float64 { return a.Fga }},
+	{Key: "turnover", Name: "失误", LowerIsBetter: true, value: func(a *external.NBASeasonAverage) float64 { return a.Turnover }},
+}
+
+// CategoryByKey 按 key 查找类别
+func CategoryByKey(key string) (Category, bool) {
+	for _, c := range Categories {
+		if c.Key == key {
+			return c, true
+		}
+	}
+	return Category{}, false
+}
+
+// Percentile 计算 v 在 pool 中的百分位（0-100，越高越好；LowerIsBetter 的类别取反）
+func Percentile(pool []float64, v float64, lowerIsBetter bool) float64 {
+	if len(pool) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), pool...)
+	sort.Float64s(sorted)
+
+	// 不优于 v 的人数（并列算一半）
+	below := sort.SearchFloat64s(sorted, v)
+	equal := sort.SearchFloat64s(sorted, v+1e-9) - below
+	notBetter := float64(below) + float64(equal)/2
+	if lowerIsBetter {
+		notBetter = float64(len(sorted)-below-equal) + float64(equal)/2
+	}
+	return round(notBetter/float64(len(sorted))*100, 1)
+}
+
+// Combine 把多个赛季的场均数据按出场数加权合并为一条
+func Combine(lines []*external.NBASeasonAverage) *external.NBASeasonAverage {
+	total := &external.NBASeasonAverage{}
+	var minutes float64
+	for _, l := range lines {
+		if l == nil || l.GamesPlayed == 0 {
+			continue
+		}
+		gp := float64(l.GamesPlayed)
+		total.PlayerID = l.PlayerID
+		total.GamesPlayed += l.GamesPlayed
+		total.Pts += l.Pts * gp
+		total.Ast += l.Ast * gp
+		total.Reb += l.Reb * gp
+		total.Stl += l.Stl * gp
+		total.Blk += l.Blk * gp
+		total.Turnover += l.Turnover * gp
+		total.Fgm += l.Fgm * gp
+		total.Fga += l.Fga * gp
+		total.Fg3m += l.Fg3m * gp
+		total.Fg3a += l.Fg3a * gp
+		total.Ftm += l.Ftm * gp
+		total.Fta += l.Fta * gp
+		total.Oreb += l.Oreb * gp
+		total.Dreb += l.Dreb * gp
+		minutes += ParseMinutes(l.Min).Minutes() * gp
+	}
+	if total.GamesPlayed == 0 {
+		return nil
+	}
+
+	n := float64(total.GamesPlayed)
+	total.Pts /= n
+	total.Ast /= n
+	total.Reb /= n
+	total.Stl /= n
+	total.Blk /= n
+	total.Turnover /= n
+	total.FgPct = safeDiv(total.Fgm, total.Fga)
+	total.Fg3Pct = safeDiv(total.Fg3m, total.Fg3a)
+	total.FtPct = safeDiv(total.Ftm, total.Fta)
+	total.Fgm /= n
+	total.Fga /= n
+	total.Fg3m /= n
+	total.Fg3a /= n
+	total.Ftm /= n
+	total.Fta /= n
+	total.Oreb /= n
+	total.Dreb /= n
+	total.Min = FormatMinutes(time.Duration(minutes / n * float64(time.Minute)))
+	return total
+}
@@ -0,0 +1,40 @@
+package stats
+
+import (
+	"buzzerbeater/external"
+	"sort"
+)
+
+// LeaderEntry 排行榜条目
+type LeaderEntry struct {
+	PlayerID    int     `json:"player_id"`
+	GamesPlayed int     `json:"games_played"`
+	Value       float64 `json:"value"`
+}
+
+// RankLeaders 按类别对全联盟数据排序（最优在前，数值相同按出场数、球员ID），不满足上榜门槛的球员不参与排名
+func RankLeaders(pool []external.NBASeasonAverage, category Category) []LeaderEntry {
+	entries := make([]LeaderEntry, 0, len(pool))
+	for i := range pool {
+		if !category.Qualified(&pool[i]) {
+			continue
+		}
+		entries = append(entries, LeaderEntry{
+			PlayerID:    pool[i].PlayerID,
+			GamesPlayed: pool[i].GamesPlayed,
+			Value:       round(category.Value(&pool[i]), 3),
+		})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.Value != b.Value {
+			return category.Better(a.Value, b.Value)
+		}
+		if a.GamesPlayed != b.GamesPlayed {
+			return a.GamesPlayed > b.GamesPlayed
+		}
+		return a.PlayerID < b.PlayerID
+	})
+	return entries
+}
@@ -0,0 +1,45 @@
+package stats
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseMinutes 解析 balldontlie 的上场时间字符串
+// 支持 "32"、"32:15"、"34.5"、"" 等格式，无法解析时返回 0
+func ParseMinutes(min string) time.Duration {
+	min = strings.TrimSpace(min)
+	if min == "" {
+		return 0
+	}
+
+	parts := strings.SplitN(min, ":", 2)
+	minutes, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil || minutes < 0 {
+		return 0
+	}
+	d := time.Duration(minutes * float64(time.Minute))
+
+	if len(parts) == 2 {
+		seconds, err := strconv.Atoi(parts[1])
+		if err != nil || seconds < 0 {
+			return 0
+		}
+		d += time.Duration(seconds) * time.Second
+	}
+	return d
+}
+
+// FormatMinutes 格式化为 "mm:ss"
+func FormatMinutes(d time.Duration) string {
+	total := int(d.Round(time.Second) / time.Second)
+	return strconv.Itoa(total/60) + ":" + twoDigits(total%60)
+}
+
+func twoDigits(n int) string {
+	if n < 10 {
+		return "0" + strconv.Itoa(n)
+	}
+	return strconv.Itoa(n)
+}
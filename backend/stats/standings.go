@@ -0,0 +1,249 @@
+package stats
+
+import (
+	"buzzerbeater/external"
+	"fmt"
+	"sort"
+)
+
+// Record 胜负记录
+type Record struct {
+	Wins   int `json:"wins"`
+	Losses int `json:"losses"`
+}
+
+// Pct 胜率
+func (r Record) Pct() float64 {
+	return safeDiv(float64(r.Wins), float64(r.Wins+r.Losses))
+}
+
+func (r *Record) add(won bool) {
+	if won {
+		r.Wins++
+	} else {
+		r.Losses++
+	}
+}
+
+// Standing 球队战绩
+type Standing struct {
+	Team          external.NBATeam `json:"team"`
+	Wins          int              `json:"wins"`
+	Losses        int              `json:"losses"`
+	WinPct        float64          `json:"win_pct"`
+	GamesBehind   float64          `json:"games_behind"` // 相对所在分组第一名
+	Rank          int              `json:"rank"`         // 所在分组排名
+	Streak        string           `json:"streak"`       // 如 W3 / L2
+	Last10        Record           `json:"last_10"`
+	Home          Record           `json:"home"`
+	Away          Record           `json:"away"`
+	Conference    Record           `json:"conference"`
+	Division      Record           `json:"division"`
+	PointsFor     float64          `json:"points_for"`     // 场均得分
+	PointsAgainst float64          `json:"points_against"` // 场均失分
+	PointDiff     float64          `json:"point_diff"`     // 场均净胜分
+	results       []bool           // 按日期升序的胜负
+	totalFor      int
+	totalAgainst  int
+	headToHead    map[int]*Record
+}
+
+// StandingGroup 一个分组（东/西部或某个赛区）的排名
+type StandingGroup struct {
+	Name  string      `json:"name"`
+	Teams []*Standing `json:"teams"`
+}
+
+// ComputeStandings 由比赛结果计算战绩并分组排名
+// 只统计已结束的常规赛；groupBy 为 "conference" 或 "division"
+func ComputeStandings(teams []external.NBATeam, games []external.NBAGame, groupBy string) []StandingGroup {
+	standings := make(map[int]*Standing, len(teams))
+	for _, t := range teams {
+		standings[t.ID] = &Standing{Team: t, headToHead: map[int]*Record{}}
+	}
+
+	sorted := append([]external.NBAGame(nil), games...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Date != sorted[j].Date {
+			return sorted[i].Date < sorted[j].Date
+		}
+		return sorted[i].Datetime < sorted[j].Datetime
+	})
+
+	for _, g := range sorted {
+		if !g.IsFinal() || g.Postseason {
+			continue
+		}
+		home, away := standings[g.HomeTeam.ID], standings[g.VisitorTeam.ID]
+		if home == nil || away == nil {
+			continue
+		}
+		homeWon := g.HomeTeamScore > g.VisitorTeamScore
+		home.record(away, homeWon, g.HomeTeamScore, g.VisitorTeamScore, true)
+		away.record(home, !homeWon, g.VisitorTeamScore, g.HomeTeamScore, false)
+	}
+
+	groups := map[string][]*Standing{}
+	var names []string
+	for _, t := range teams {
+		s := standings[t.ID]
+		s.finish()
+		name := t.Conference
+		if groupBy == "division" {
+			name = t.Division
+		}
+		if _, ok := groups[name]; !ok {
+			names = append(names, name)
+		}
+		groups[name] = append(groups[name], s)
+	}
+	sort.Strings(names)
+
+	result := make([]StandingGroup, 0, len(names))
+	for _, name := range names {
+		ranked := rankStandings(groups[name])
+		result = append(result, StandingGroup{Name: name, Teams: ranked})
+	}
+	return result
+}
+
+// record 记录一场比赛
+func (s *Standing) record(opponent *Standing, won bool, pointsFor, pointsAgainst int, home bool) {
+	s.results = append(s.results, won)
+	s.totalFor += pointsFor
+	s.totalAgainst += pointsAgainst
+	if home {
+		s.Home.add(won)
+	} else {
+		s.Away.add(won)
+	}
+	if s.Team.Conference == opponent.Team.Conference {
+		s.Conference.add(won)
+	}
+	if s.Team.Division == opponent.Team.Division {
+		s.Division.add(won)
+	}
+	h2h, ok := s.headToHead[opponent.Team.ID]
+	if !ok {
+		h2h = &Record{}
+		s.headToHead[opponent.Team.ID] = h2h
+	}
+	h2h.add(won)
+}
+
+// finish 汇总胜负、连胜连败、近 10 场和场均得失分
+func (s *Standing) finish() {
+	for _, won := range s.results {
+		if won {
+			s.Wins++
+		} else {
+			s.Losses++
+		}
+	}
+	played := len(s.results)
+	s.WinPct = round(Record{Wins: s.Wins, Losses: s.Losses}.Pct(), 3)
+
+	start := max(played-10, 0)
+	for _, won := range s.results[start:] {
+		s.Last10.add(won)
+	}
+
+	if played > 0 {
+		last := s.results[played-1]
+		n := 0
+		for i := played - 1; i >= 0 && s.results[i] == last; i-- {
+			n++
+		}
+		prefix := "L"
+		if last {
+			prefix = "W"
+		}
+		s.Streak = fmt.Sprintf("%s%d", prefix, n)
+
+		s.PointsFor = round(float64(s.totalFor)/float64(played), 1)
+		s.PointsAgainst = round(float64(s.totalAgainst)/float64(played), 1)
+		s.PointDiff = round(float64(s.totalFor-s.totalAgainst)/float64(played), 1)
+	}
+}
+
+// rankStandings 按胜率排序并计算胜场差
+// 胜率相同时依次比较：相互交手胜率、分区冠军（同赛区内战绩最好者优先）、分区内战绩、东/西部内战绩、场均净胜分
+func rankStandings(group []*Standing) []*Standing {
+	divisionLeaders := divisionLeaders(group)
+
+	sort.SliceStable(group, func(i, j int) bool {
+		a, b := group[i], group[j]
+		if a.WinPct != b.WinPct {
+			return a.WinPct > b.WinPct
+		}
+		tied := tiedWith(group, a.WinPct)
+		if ha, hb := headToHeadPct(a, tied), headToHeadPct(b, tied); ha != hb {
+			return ha > hb
+		}
+		if la, lb := divisionLeaders[a.Team.ID], divisionLeaders[b.Team.ID]; la != lb {
+			return la
+		}
+		if a.Team.Division == b.Team.Division && a.Division.Pct() != b.Division.Pct() {
+			return a.Division.Pct() > b.Division.Pct()
+		}
+		if a.Conference.Pct() != b.Conference.Pct() {
+			return a.Conference.Pct() > b.Conference.Pct()
+		}
+		if a.PointDiff != b.PointDiff {
+			return a.PointDiff > b.PointDiff
+		}
+		return a.Team.ID < b.Team.ID
+	})
+
+	if len(group) == 0 {
+		return group
+	}
+	leader := group[0]
+	for i, s := range group {
+		s.Rank = i + 1
+		s.GamesBehind = float64((leader.Wins-s.Wins)+(s.Losses-leader.Losses)) / 2
+	}
+	return group
+}
+
+// tiedWith 胜率相同的球队
+func tiedWith(group []*Standing, pct float64) []*Standing {
+	var tied []*Standing
+	for _, s := range group {
+		if s.WinPct == pct {
+			tied = append(tied, s)
+		}
+	}
+	return tied
+}
+
+// headToHeadPct 对同胜率球队的合计交手胜率（多队并列时按小联赛计算）
+func headToHeadPct(s *Standing, tied []*Standing) float64 {
+	var total Record
+	for _, other := range tied {
+		if other == s {
+			continue
+		}
+		if r, ok := s.headToHead[other.Team.ID]; ok {
+			total.Wins += r.Wins
+			total.Losses += r.Losses
+		}
+	}
+	return total.Pct()
+}
+
+// divisionLeaders 每个赛区战绩最好的球队
+func divisionLeaders(group []*Standing) map[int]bool {
+	best := map[string]*Standing{}
+	for _, s := range group {
+		cur, ok := best[s.Team.Division]
+		if !ok || s.WinPct > cur.WinPct || (s.WinPct == cur.WinPct && s.Division.Pct() > cur.Division.Pct()) {
+			best[s.Team.Division] = s
+		}
+	}
+	leaders := map[int]bool{}
+	for _, s := range best {
+		leaders[s.Team.ID] = true
+	}
+	return leaders
+}
@@ -0,0 +1,103 @@
+package stats
+
+import "buzzerbeater/external"
+
+// TeamSeasonAverages 球队赛季场均数据
+type TeamSeasonAverages struct {
+	TeamID      int     `json:"team_id"`
+	Season      int     `json:"season"`
+	GamesPlayed int     `json:"games_played"`
+	Pts         float64 `json:"pts"`
+	OppPts      float64 `json:"opp_pts"`
+	PointDiff   float64 `json:"point_diff"`
+	Reb         float64 `json:"reb"`
+	Oreb        float64 `json:"oreb"`
+	Dreb        float64 `json:"dreb"`
+	Ast         float64 `json:"ast"`
+	Stl         float64 `json:"stl"`
+	Blk         float64 `json:"blk"`
+	Turnover    float64 `json:"turnover"`
+	Fgm         float64 `json:"fgm"`
+	Fga         float64 `json:"fga"`
+	FgPct       float64 `json:"fg_pct"`
+	Fg3m        float64 `json:"fg3m"`
+	Fg3a        float64 `json:"fg3a"`
+	Fg3Pct      float64 `json:"fg3_pct"`
+	Ftm         float64 `json:"ftm"`
+	Fta         float64 `json:"fta"`
+	FtPct       float64 `json:"ft_pct"`
+}
+
+// ComputeTeamAverages 计算球队赛季场均
+// 得失分来自比赛比分，其余数据由球员单场数据按比赛汇总（只统计已结束的常规赛）
+func ComputeTeamAverages(teamID int, season int, games []external.NBAGame, lines []external.NBAStat) TeamSeasonAverages {
+	avg := TeamSeasonAverages{TeamID: teamID, Season: season}
+
+	counted := map[int]bool{}
+	var pts, oppPts int
+	for _, g := range games {
+		if !g.IsFinal() || g.Postseason {
+			continue
+		}
+		switch teamID {
+		case g.HomeTeam.ID:
+			pts += g.HomeTeamScore
+			oppPts += g.VisitorTeamScore
+		case g.VisitorTeam.ID:
+			pts += g.VisitorTeamScore
+			oppPts += g.HomeTeamScore
+		default:
+			continue
+		}
+		counted[g.ID] = true
+	}
+	avg.GamesPlayed = len(counted)
+	if avg.GamesPlayed == 0 {
+		return avg
+	}
+
+	var box Line
+	var oreb, dreb float64
+	for _, s := range lines {
+		if s.Team.ID != teamID || !counted[s.Game.ID] {
+			continue
+		}
+		box.Pts += float64(s.Pts)
+		box.Reb += float64(s.Reb)
+		box.Ast += float64(s.Ast)
+		box.Stl += float64(s.Stl)
+		box.Blk += float64(s.Blk)
+		box.Turnover += float64(s.Turnover)
+		box.Fgm += float64(s.Fgm)
+		box.Fga += float64(s.Fga)
+		box.Fg3m += float64(s.Fg3m)
+		box.Fg3a += float64(s.Fg3a)
+		box.Ftm += float64(s.Ftm)
+		box.Fta += float64(s.Fta)
+		oreb += float64(s.Oreb)
+		dreb += float64(s.Dreb)
+	}
+
+	n := float64(avg.GamesPlayed)
+	perGame := box.scale(1 / n)
+	avg.Pts = round(float64(pts)/n, 1)
+	avg.OppPts = round(float64(oppPts)/n, 1)
+	avg.PointDiff = round(float64(pts-oppPts)/n, 1)
+	avg.Reb = perGame.Reb
+	avg.Oreb = round(oreb/n, 1)
+	avg.Dreb = round(dreb/n, 1)
+	avg.Ast = perGame.Ast
+	avg.Stl = perGame.Stl
+	avg.Blk = perGame.Blk
+	avg.Turnover = perGame.Turnover
+	avg.Fgm = perGame.Fgm
+	avg.Fga = perGame.Fga
+	avg.Fg3m = perGame.Fg3m
+	avg.Fg3a = perGame.Fg3a
+	avg.Ftm = perGame.Ftm
+	avg.Fta = perGame.Fta
+	avg.FgPct = round(safeDiv(box.Fgm, box.Fga), 3)
+	avg.Fg3Pct = round(safeDiv(box.Fg3m, box.Fg3a), 3)
+	avg.FtPct = round(safeDiv(box.Ftm, box.Fta), 3)
+	return avg
+}
@@ -13,11 +13,16 @@ import (
 
 const (
 	maxFileSize = 5 * 1024 * 1024 // 5MB
-	uploadDir   = "./uploads/avatars"
+	uploadRoot  = "./uploads"
 )
 
 // SaveAvatar 保存头像文件
 func SaveAvatar(file *multipart.FileHeader) (string, error) {
+	return SaveImage(file, "avatars")
+}
+
+// SaveImage 校验并保存图片到 uploads 下的子目录，返回访问路径
+func SaveImage(file *multipart.FileHeader, dir string) (string, error) {
 	// 验证文件大小
 	if file.Size > maxFileSize {
 		return "", errors.New("文件大小不能超过5MB")
@@ -30,6 +35,7 @@ func SaveAvatar(file *multipart.FileHeader) (string, error) {
 	}
 
 	// 确保上传目录存在
+	uploadDir := filepath.Join(uploadRoot, dir)
 	if err := os.MkdirAll(uploadDir, 0755); err != nil {
 		return "", err
 	}
@@ -59,15 +65,20 @@ func SaveAvatar(file *multipart.FileHeader) (string, error) {
 	}
 
 	// 返回相对路径（用于存储在数据库和提供给前端）
-	return fmt.Sprintf("/uploads/avatars/%s", filename), nil
+	return fmt.Sprintf("/uploads/%s/%s", dir, filename), nil
 }
 
 // DeleteAvatar 删除头像文件
 func DeleteAvatar(avatarPath string) error {
-	if avatarPath == "" {
+	return DeleteUpload(avatarPath)
+}
+
+// DeleteUpload 删除 SaveImage 保存的文件
+func DeleteUpload(path string) error {
+	if path == "" {
 		return nil
 	}
-	fullPath := "." + avatarPath
+	fullPath := "." + path
 	return os.Remove(fullPath)
 }
 
@@ -82,4 +93,3 @@ func isAllowedImageType(contentType string) bool {
 	}
 	return false
 }
-
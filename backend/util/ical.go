@@ -0,0 +1,87 @@
+package util
+
+import (
+	"strings"
+	"time"
+)
+
+// CalendarEvent 日历事件
+type CalendarEvent struct {
+	UID         string
+	Start       time.Time
+	End         time.Time
+	AllDay      bool // 开赛时间未定时按全天事件处理
+	Summary     string
+	Location    string
+	Description string
+	Updated     time.Time
+}
+
+// BuildICalendar 生成 iCalendar (RFC 5545) 文本
+func BuildICalendar(name string, events []CalendarEvent) string {
+	var b strings.Builder
+	writeLine := func(line string) {
+		b.WriteString(foldLine(line))
+		b.WriteString("\r\n")
+	}
+
+	writeLine("BEGIN:VCALENDAR")
+	writeLine("VERSION:2.0")
+	writeLine("PRODID:-//BuzzerBeater//Schedule//CN")
+	writeLine("CALSCALE:GREGORIAN")
+	writeLine("METHOD:PUBLISH")
+	writeLine("X-WR-CALNAME:" + escapeText(name))
+	writeLine("X-PUBLISHED-TTL:PT6H")
+	writeLine("REFRESH-INTERVAL;VALUE=DURATION:PT6H")
+
+	for _, e := range events {
+		writeLine("BEGIN:VEVENT")
+		writeLine("UID:" + e.UID)
+		writeLine("DTSTAMP:" + e.Updated.UTC().Format("20060102T150405Z"))
+		if e.AllDay {
+			writeLine("DTSTART;VALUE=DATE:" + e.Start.Format("20060102"))
+			writeLine("DTEND;VALUE=DATE:" + e.Start.AddDate(0, 0, 1).Format("20060102"))
+		} else {
+			writeLine("DTSTART:" + e.Start.UTC().Format("20060102T150405Z"))
+			writeLine("DTEND:" + e.End.UTC().Format("20060102T150405Z"))
+		}
+		writeLine("SUMMARY:" + escapeText(e.Summary))
+		if e.Location != "" {
+			writeLine("LOCATION:" + escapeText(e.Location))
+		}
+		if e.Description != "" {
+			writeLine("DESCRIPTION:" + escapeText(e.Description))
+		}
+		writeLine("END:VEVENT")
+	}
+
+	writeLine("END:VCALENDAR")
+	return b.String()
+}
+
+// escapeText 转义 TEXT 类型的特殊字符
+func escapeText(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	return r.Replace(s)
+}
+
+// foldLine 超过 75 字节的行需要折行（续行以空格开头），且不能截断 UTF-8 字符
+func foldLine(line string) string {
+	const limit = 75
+	if len(line) <= limit {
+		return line
+	}
+
+	var b strings.Builder
+	width := 0
+	for _, r := range line {
+		size := len(string(r))
+		if width+size > limit {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	return b.String()
+}
@@ -0,0 +1,688 @@
+package warehouse
+
+import (
+	"buzzerbeater/db"
+	"buzzerbeater/external"
+	"buzzerbeater/stats"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SaveTeams 保存球队
+func SaveTeams(teams []external.NBATeam) error {
+	tx, err := db.GetDB().Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT OR REPLACE INTO nba_teams
+		(id, conference, division, city, name, full_name, full_name_zh, abbreviation, logo_url, bg_color, synced_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, t := range teams {
+		if _, err := stmt.Exec(t.ID, t.Conference, t.Division, t.City, t.Name, t.FullName,
+			t.FullNameZh, t.Abbreviation, t.LogoURL, t.BgColor); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// SavePlayers 保存球员
+func SavePlayers(players []external.NBAPlayer) error {
+	tx, err := db.GetDB().Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT OR REPLACE INTO nba_players
+		(id, first_name, last_name, position, height, weight, jersey_number, college, country,
+		 draft_year, draft_round, draft_number, team_id, synced_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, p := range players {
+		if _, err := stmt.Exec(p.ID, p.FirstName, p.LastName, p.Position, p.Height, p.Weight,
+			p.JerseyNumber, p.College, p.Country, p.DraftYear, p.DraftRound, p.DraftNumber, p.Team.ID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// SaveGames 保存比赛
+func SaveGames(games []external.NBAGame) error {
+	tx, err := db.GetDB().Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT OR REPLACE INTO nba_games
+		(id, date, datetime, season, status, period, time, postseason,
+		 home_team_id, home_team_score, visitor_team_id, visitor_team_score, synced_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, g := range games {
+		if _, err := stmt.Exec(g.ID, g.Date, g.Datetime, g.Season, g.Status, g.Period, g.Time, g.Postseason,
+			g.HomeTeam.ID, g.HomeTeamScore, g.VisitorTeam.ID, g.VisitorTeamScore); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// SaveStats 保存球员单场数据
+func SaveStats(stats []external.NBAStat) error {
+	tx, err := db.GetDB().Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT OR REPLACE INTO nba_player_stats
+		(id, game_id, player_id, team_id, season, min, fgm, fga, fg3m, fg3a, ftm, fta,
+		 oreb, dreb, reb, ast, stl, blk, turnover, pf, pts, synced_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, s := range stats {
+		if _, err := stmt.Exec(s.ID, s.Game.ID, s.Player.ID, s.Team.ID, s.Game.Season, s.Min,
+			s.Fgm, s.Fga, s.Fg3m, s.Fg3a, s.Ftm, s.Fta, s.Oreb, s.Dreb, s.Reb, s.Ast, s.Stl, s.Blk,
+			s.Turnover, s.Pf, s.Pts); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Checkpoint 同步检查点
+type Checkpoint struct {
+	Resource     string     `json:"resource"`
+	Cursor       int        `json:"cursor"`
+	LastDate     string     `json:"last_date"`
+	LastSyncedAt *time.Time `json:"last_synced_at"`
+	LastError    string     `json:"last_error"`
+}
+
+// GetCheckpoint 读取检查点（不存在时返回空检查点）
+func GetCheckpoint(resource string) (*Checkpoint, error) {
+	cp := &Checkpoint{Resource: resource}
+	var syncedAt sql.NullTime
+	err := db.GetDB().QueryRow(
+		"SELECT cursor, last_date, last_synced_at, last_error FROM nba_sync_checkpoints WHERE resource = ?",
+		resource,
+	).Scan(&cp.Cursor, &cp.LastDate, &syncedAt, &cp.LastError)
+	if err == sql.ErrNoRows {
+		return cp, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if syncedAt.Valid {
+		cp.LastSyncedAt = &syncedAt.Time
+	}
+	return cp, nil
+}
+
+// ListCheckpoints 获取全部检查点（用于查看同步状态）
+func ListCheckpoints() ([]Checkpoint, error) {
+	rows, err := db.GetDB().Query(
+		"SELECT resource, cursor, last_date, last_synced_at, last_error FROM nba_sync_checkpoints ORDER BY resource",
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	checkpoints := []Checkpoint{}
+	for rows.Next() {
+		var cp Checkpoint
+		var syncedAt sql.NullTime
+		if err := rows.Scan(&cp.Resource, &cp.Cursor, &cp.LastDate, &syncedAt, &cp.LastError); err != nil {
+			return nil, err
+		}
+		if syncedAt.Valid {
+			cp.LastSyncedAt = &syncedAt.Time
+		}
+		checkpoints = append(checkpoints, cp)
+	}
+	return checkpoints, rows.Err()
+}
+
+// saveCursor 记录分页进度
+func saveCursor(resource string, cursor int) error {
+	_, err := db.GetDB().Exec(`
+		INSERT INTO nba_sync_checkpoints (resource, cursor, updated_at) VALUES (?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(resource) DO UPDATE SET cursor = excluded.cursor, updated_at = CURRENT_TIMESTAMP
+	`, resource, cursor)
+	return err
+}
+
+// markSynced 记录一次完整同步完成
+func markSynced(resource string, lastDate string) error {
+	_, err := db.GetDB().Exec(`
+		INSERT INTO nba_sync_checkpoints (resource, cursor, last_date, last_synced_at, last_error, updated_at)
+		VALUES (?, 0, ?, CURRENT_TIMESTAMP, '', CURRENT_TIMESTAMP)
+		ON CONFLICT(resource) DO UPDATE SET cursor = 0, last_date = excluded.last_date,
+			last_synced_at = CURRENT_TIMESTAMP, last_error = '', updated_at = CURRENT_TIMESTAMP
+	`, resource, lastDate)
+	return err
+}
+
+// markFailed 记录同步错误（保留游标，下次从断点继续）
+func markFailed(resource string, syncErr error) error {
+	_, err := db.GetDB().Exec(`
+		INSERT INTO nba_sync_checkpoints (resource, last_error, updated_at) VALUES (?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(resource) DO UPDATE SET last_error = excluded.last_error, updated_at = CURRENT_TIMESTAMP
+	`, resource, syncErr.Error())
+	return err
+}
+
+// Ready 某类数据是否至少完整同步过一次（未同步过的数据应继续走在线接口）
+func Ready(resource string) bool {
+	var exists bool
+	db.GetDB().QueryRow(
+		"SELECT EXISTS(SELECT 1 FROM nba_sync_checkpoints WHERE resource = ? AND last_synced_at IS NOT NULL)",
+		resource,
+	).Scan(&exists)
+	return exists
+}
+
+// GamesResource 某赛季比赛的检查点名称
+func GamesResource(season int) string {
+	return fmt.Sprintf("games:%d", season)
+}
+
+// StatsResource 某赛季球员数据的检查点名称
+func StatsResource(season int) string {
+	return fmt.Sprintf("stats:%d", season)
+}
+
+const teamColumns = `id, conference, division, city, name, full_name, full_name_zh, abbreviation, logo_url, bg_color`
+
+// scanTeam 扫描球队
+func scanTeam(scanner interface{ Scan(...interface{}) error }) (external.NBATeam, error) {
+	var t external.NBATeam
+	err := scanner.Scan(&t.ID, &t.Conference, &t.Division, &t.City, &t.Name, &t.FullName,
+		&t.FullNameZh, &t.Abbreviation, &t.LogoURL, &t.BgColor)
+	return t, err
+}
+
+// Teams 获取全部球队
+func Teams() ([]external.NBATeam, error) {
+	rows, err := db.GetDB().Query("SELECT " + teamColumns + " FROM nba_teams ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	teams := []external.NBATeam{}
+	for rows.Next() {
+		team, err := scanTeam(rows)
+		if err != nil {
+			return nil, err
+		}
+		teams = append(teams, team)
+	}
+	return teams, rows.Err()
+}
+
+// Team 获取单支球队
+func Team(id int) (*external.NBATeam, error) {
+	team, err := scanTeam(db.GetDB().QueryRow("SELECT "+teamColumns+" FROM nba_teams WHERE id = ?", id))
+	if err != nil {
+		return nil, err
+	}
+	return &team, nil
+}
+
+// teamsByID 球队 ID 到球队的映射，用于补全球员/比赛中的球队信息
+func teamsByID() (map[int]external.NBATeam, error) {
+	teams, err := Teams()
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[int]external.NBATeam, len(teams))
+	for _, t := range teams {
+		m[t.ID] = t
+	}
+	return m, nil
+}
+
+const playerColumns = `id, first_name, last_name, position, height, weight, jersey_number, college, country,
+	draft_year, draft_round, draft_number, team_id`
+
+// scanPlayer 扫描球员
+func scanPlayer(scanner interface{ Scan(...interface{}) error }) (external.NBAPlayer, error) {
+	var p external.NBAPlayer
+	err := scanner.Scan(&p.ID, &p.FirstName, &p.LastName, &p.Position, &p.Height, &p.Weight,
+		&p.JerseyNumber, &p.College, &p.Country, &p.DraftYear, &p.DraftRound, &p.DraftNumber, &p.Team.ID)
+	return p, err
+}
+
+// Players 获取球员列表（teamID 为 0 时不按球队过滤，limit 为 -1 时不限数量）
+func Players(teamID int, limit int) ([]external.NBAPlayer, error) {
+	query := "SELECT " + playerColumns + " FROM nba_players"
+	args := []interface{}{}
+	if teamID > 0 {
+		query += " WHERE team_id = ?"
+		args = append(args, teamID)
+	}
+	query += " ORDER BY id LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := db.GetDB().Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	teams, err := teamsByID()
+	if err != nil {
+		return nil, err
+	}
+
+	players := []external.NBAPlayer{}
+	for rows.Next() {
+		player, err := scanPlayer(rows)
+		if err != nil {
+			return nil, err
+		}
+		player.Team = teams[player.Team.ID]
+		players = append(players, player)
+	}
+	return players, rows.Err()
+}
+
+// PlayersByID 批量获取球员，不存在的 ID 会被忽略
+func PlayersByID(ids []int) ([]external.NBAPlayer, error) {
+	if len(ids) == 0 {
+		return []external.NBAPlayer{}, nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	rows, err := db.GetDB().Query("SELECT "+playerColumns+" FROM nba_players WHERE id IN ("+placeholders+")", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	teams, err := teamsByID()
+	if err != nil {
+		return nil, err
+	}
+
+	players := []external.NBAPlayer{}
+	for rows.Next() {
+		player, err := scanPlayer(rows)
+		if err != nil {
+			return nil, err
+		}
+		player.Team = teams[player.Team.ID]
+		players = append(players, player)
+	}
+	return players, rows.Err()
+}
+
+// ActivePlayers 本赛季有单场数据（含未上场的记录）的球员，teamID 为 0 时不按球队过滤
+// 本地只同步全部球员名单，没有现役标记，以本赛季的出场记录判定
+func ActivePlayers(teamID int, season int) ([]external.NBAPlayer, error) {
+	query := "SELECT " + playerColumns + ` FROM nba_players p
+		WHERE EXISTS (SELECT 1 FROM nba_player_stats s WHERE s.player_id = p.id AND s.season = ?)`
+	args := []interface{}{season}
+	if teamID > 0 {
+		query += " AND p.team_id = ?"
+		args = append(args, teamID)
+	}
+	rows, err := db.GetDB().Query(query+" ORDER BY p.id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	teams, err := teamsByID()
+	if err != nil {
+		return nil, err
+	}
+
+	players := []external.NBAPlayer{}
+	for rows.Next() {
+		player, err := scanPlayer(rows)
+		if err != nil {
+			return nil, err
+		}
+		player.Team = teams[player.Team.ID]
+		players = append(players, player)
+	}
+	return players, rows.Err()
+}
+
+// Player 获取单个球员
+func Player(id int) (*external.NBAPlayer, error) {
+	player, err := scanPlayer(db.GetDB().QueryRow("SELECT "+playerColumns+" FROM nba_players WHERE id = ?", id))
+	if err != nil {
+		return nil, err
+	}
+	if team, err := Team(player.Team.ID); err == nil {
+		player.Team = *team
+	}
+	return &player, nil
+}
+
+// GameFilter 本地比赛查询条件
+type GameFilter struct {
+	Season    int
+	TeamID    int
+	StartDate string
+	EndDate   string
+}
+
+// Games 查询比赛（按日期升序）
+func Games(filter GameFilter) ([]external.NBAGame, error) {
+	conditions := []string{"1 = 1"}
+	args := []interface{}{}
+	if filter.Season > 0 {
+		conditions = append(conditions, "season = ?")
+		args = append(args, filter.Season)
+	}
+	if filter.TeamID > 0 {
+		conditions = append(conditions, "(home_team_id = ? OR visitor_team_id = ?)")
+		args = append(args, filter.TeamID, filter.TeamID)
+	}
+	if filter.StartDate != "" {
+		conditions = append(conditions, "date >= ?")
+		args = append(args, filter.StartDate)
+	}
+	if filter.EndDate != "" {
+		conditions = append(conditions, "date <= ?")
+		args = append(args, filter.EndDate)
+	}
+
+	rows, err := db.GetDB().Query(`
+		SELECT id, date, datetime, season, status, period, time, postseason,
+		       home_team_id, home_team_score, visitor_team_id, visitor_team_score
+		FROM nba_games
+		WHERE `+strings.Join(conditions, " AND ")+`
+		ORDER BY date, datetime, id
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	teams, err := teamsByID()
+	if err != nil {
+		return nil, err
+	}
+
+	games := []external.NBAGame{}
+	for rows.Next() {
+		var g external.NBAGame
+		if err := rows.Scan(&g.ID, &g.Date, &g.Datetime, &g.Season, &g.Status, &g.Period, &g.Time, &g.Postseason,
+			&g.HomeTeam.ID, &g.HomeTeamScore, &g.VisitorTeam.ID, &g.VisitorTeamScore); err != nil {
+			return nil, err
+		}
+		if t, ok := teams[g.HomeTeam.ID]; ok {
+			g.HomeTeam = t
+		}
+		if t, ok := teams[g.VisitorTeam.ID]; ok {
+			g.VisitorTeam = t
+		}
+		games = append(games, g)
+	}
+	return games, rows.Err()
+}
+
+// PlayerStats 获取球员某赛季的全部单场数据（按比赛日期升序）
+func PlayerStats(playerID int, season int) ([]external.NBAStat, error) {
+	return queryPlayerStats("s.player_id = ? AND s.season = ?", playerID, season)
+}
+
+// StatsBetween 获取一批球员在日期范围内（含首尾）的单场数据（按比赛日期升序）
+func StatsBetween(playerIDs []int, startDate, endDate string) ([]external.NBAStat, error) {
+	if len(playerIDs) == 0 {
+		return []external.NBAStat{}, nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(playerIDs)), ",")
+	args := make([]interface{}, 0, len(playerIDs)+2)
+	for _, id := range playerIDs {
+		args = append(args, id)
+	}
+	args = append(args, startDate, endDate)
+	return queryPlayerStats("s.player_id IN ("+placeholders+") AND g.date >= ? AND g.date <= ?", args...)
+}
+
+// queryPlayerStats 按条件查询单场数据并附带比赛信息
+func queryPlayerStats(where string, args ...interface{}) ([]external.NBAStat, error) {
+	rows, err := db.GetDB().Query(`
+		SELECT s.id, s.min, s.fgm, s.fga, s.fg3m, s.fg3a, s.ftm, s.fta, s.oreb, s.dreb, s.reb,
+		       s.ast, s.stl, s.blk, s.turnover, s.pf, s.pts, s.player_id, s.team_id,
+		       g.id, g.date, g.season, g.status, g.postseason,
+		       g.home_team_id, g.visitor_team_id, g.home_team_score, g.visitor_team_score
+		FROM nba_player_stats s
+		JOIN nba_games g ON g.id = s.game_id
+		WHERE `+where+`
+		ORDER BY g.date, g.id
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := []external.NBAStat{}
+	for rows.Next() {
+		var s external.NBAStat
+		if err := rows.Scan(&s.ID, &s.Min, &s.Fgm, &s.Fga, &s.Fg3m, &s.Fg3a, &s.Ftm, &s.Fta, &s.Oreb, &s.Dreb, &s.Reb,
+			&s.Ast, &s.Stl, &s.Blk, &s.Turnover, &s.Pf, &s.Pts, &s.Player.ID, &s.Team.ID,
+			&s.Game.ID, &s.Game.Date, &s.Game.Season, &s.Game.Status, &s.Game.Postseason,
+			&s.Game.HomeTeamID, &s.Game.VisitorTeamID, &s.Game.HomeTeamScore, &s.Game.VisitorTeamScore); err != nil {
+			return nil, err
+		}
+		fillPercentages(&s)
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
+
+// SeasonAverage 由单场数据计算球员赛季平均（与 balldontlie season_averages 口径一致：只统计上场的比赛）
+func SeasonAverage(playerID int, season int) (*external.NBASeasonAverage, error) {
+	stats, err := PlayerStats(playerID, season)
+	if err != nil {
+		return nil, err
+	}
+	avg := AverageStats(stats)
+	if avg.GamesPlayed == 0 {
+		return nil, sql.ErrNoRows
+	}
+	avg.PlayerID = playerID
+	avg.Season = season
+	return avg, nil
+}
+
+// TeamStats 获取某队球员某赛季的全部单场数据
+func TeamStats(teamID int, season int) ([]external.NBAStat, error) {
+	rows, err := db.GetDB().Query(`
+		SELECT id, game_id, player_id, team_id, min, fgm, fga, fg3m, fg3a, ftm, fta,
+		       oreb, dreb, reb, ast, stl, blk, turnover, pf, pts
+		FROM nba_player_stats
+		WHERE team_id = ? AND season = ?
+	`, teamID, season)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lines := []external.NBAStat{}
+	for rows.Next() {
+		var s external.NBAStat
+		if err := rows.Scan(&s.ID, &s.Game.ID, &s.Player.ID, &s.Team.ID, &s.Min, &s.Fgm, &s.Fga, &s.Fg3m, &s.Fg3a,
+			&s.Ftm, &s.Fta, &s.Oreb, &s.Dreb, &s.Reb, &s.Ast, &s.Stl, &s.Blk, &s.Turnover, &s.Pf, &s.Pts); err != nil {
+			return nil, err
+		}
+		s.Game.Season = season
+		fillPercentages(&s)
+		lines = append(lines, s)
+	}
+	return lines, rows.Err()
+}
+
+// SeasonTeamPlayers 某赛季为某队出场过的球员（赛季中被交易的球员在两队都算）
+func SeasonTeamPlayers(teamID int, season int) (map[int]bool, error) {
+	rows, err := db.GetDB().Query(
+		"SELECT DISTINCT player_id FROM nba_player_stats WHERE season = ? AND team_id = ?", season, teamID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	players := map[int]bool{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		players[id] = true
+	}
+	return players, rows.Err()
+}
+
+// LeagueAverages 由单场数据计算全联盟球员的赛季平均
+func LeagueAverages(season int) ([]external.NBASeasonAverage, error) {
+	rows, err := db.GetDB().Query(`
+		SELECT player_id, min, fgm, fga, fg3m, fg3a, ftm, fta, oreb, dreb, reb, ast, stl, blk, turnover, pf, pts
+		FROM nba_player_stats
+		WHERE season = ?
+		ORDER BY player_id
+	`, season)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byPlayer := map[int][]external.NBAStat{}
+	order := []int{}
+	for rows.Next() {
+		var s external.NBAStat
+		if err := rows.Scan(&s.Player.ID, &s.Min, &s.Fgm, &s.Fga, &s.Fg3m, &s.Fg3a, &s.Ftm, &s.Fta,
+			&s.Oreb, &s.Dreb, &s.Reb, &s.Ast, &s.Stl, &s.Blk, &s.Turnover, &s.Pf, &s.Pts); err != nil {
+			return nil, err
+		}
+		if _, ok := byPlayer[s.Player.ID]; !ok {
+			order = append(order, s.Player.ID)
+		}
+		byPlayer[s.Player.ID] = append(byPlayer[s.Player.ID], s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	averages := make([]external.NBASeasonAverage, 0, len(order))
+	for _, playerID := range order {
+		avg := AverageStats(byPlayer[playerID])
+		if avg.GamesPlayed == 0 {
+			continue
+		}
+		avg.PlayerID = playerID
+		avg.Season = season
+		averages = append(averages, *avg)
+	}
+	return averages, nil
+}
+
+// AverageStats 计算一组单场数据的场均（未上场的比赛不计入）
+func AverageStats(lines []external.NBAStat) *external.NBASeasonAverage {
+	avg := &external.NBASeasonAverage{}
+	var played time.Duration
+	var fgm, fga, fg3m, fg3a, ftm, fta int
+	for _, s := range lines {
+		min := stats.ParseMinutes(s.Min)
+		if min == 0 {
+			continue
+		}
+		avg.GamesPlayed++
+		played += min
+		fgm += s.Fgm
+		fga += s.Fga
+		fg3m += s.Fg3m
+		fg3a += s.Fg3a
+		ftm += s.Ftm
+		fta += s.Fta
+		avg.Pts += float64(s.Pts)
+		avg.Ast += float64(s.Ast)
+		avg.Reb += float64(s.Reb)
+		avg.Stl += float64(s.Stl)
+		avg.Blk += float64(s.Blk)
+		avg.Turnover += float64(s.Turnover)
+		avg.Oreb += float64(s.Oreb)
+		avg.Dreb += float64(s.Dreb)
+	}
+	if avg.GamesPlayed == 0 {
+		return avg
+	}
+
+	n := float64(avg.GamesPlayed)
+	avg.Pts /= n
+	avg.Ast /= n
+	avg.Reb /= n
+	avg.Stl /= n
+	avg.Blk /= n
+	avg.Turnover /= n
+	avg.Oreb /= n
+	avg.Dreb /= n
+	avg.Fgm = float64(fgm) / n
+	avg.Fga = float64(fga) / n
+	avg.Fg3m = float64(fg3m) / n
+	avg.Fg3a = float64(fg3a) / n
+	avg.Ftm = float64(ftm) / n
+	avg.Fta = float64(fta) / n
+	avg.FgPct = ratio(fgm, fga)
+	avg.Fg3Pct = ratio(fg3m, fg3a)
+	avg.FtPct = ratio(ftm, fta)
+
+	avg.Min = stats.FormatMinutes(played / time.Duration(avg.GamesPlayed))
+	return avg
+}
+
+// fillPercentages 本地只存命中/出手数，读出时补全命中率
+func fillPercentages(s *external.NBAStat) {
+	s.FgPct = ratio(s.Fgm, s.Fga)
+	s.Fg3Pct = ratio(s.Fg3m, s.Fg3a)
+	s.FtPct = ratio(s.Ftm, s.Fta)
+}
+
+func ratio(made, attempted int) float64 {
+	if attempted == 0 {
+		return 0
+	}
+	return float64(made) / float64(attempted)
+}
@@ -0,0 +1,228 @@
+package warehouse
+
+import (
+	"buzzerbeater/db"
+	"buzzerbeater/external"
+	"context"
+	"log"
+	"time"
+)
+
+// 球队和球员变化少，一天全量刷新一次即可
+const rosterRefreshInterval = 24 * time.Hour
+
+// Syncer 定时把 balldontlie 的球队、球员、比赛和单场数据同步到本地表
+type Syncer struct {
+	client   *external.NBAClient
+	interval time.Duration
+	season   int // 0 表示按日期跟随当前赛季
+}
+
+// NewSyncer 创建同步器
+func NewSyncer(client *external.NBAClient, interval time.Duration, season int) *Syncer {
+	return &Syncer{
+		client:   client,
+		interval: interval,
+		season:   season,
+	}
+}
+
+// Start 启动后台同步（立即执行一次，之后按间隔执行，ctx 结束时退出）
+func (s *Syncer) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			s.RunOnce(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// RunOnce 执行一轮同步，某类数据失败不影响其余数据
+func (s *Syncer) RunOnce(ctx context.Context) {
+	// 同步数据只用一次，不进客户端的内存缓存
+	ctx = external.WithoutCache(ctx)
+
+	season := s.season
+	if season == 0 {
+		season = external.CurrentSeason(time.Now())
+	}
+
+	tasks := []struct {
+		resource string
+		run      func(context.Context) error
+	}{
+		{"teams", s.syncTeams},
+		{"players", s.syncPlayers},
+		{GamesResource(season), func(ctx context.Context) error { return s.syncGames(ctx, season) }},
+		{StatsResource(season), func(ctx context.Context) error { return s.syncStats(ctx, season) }},
+	}
+
+	for _, task := range tasks {
+		if ctx.Err() != nil {
+			return
+		}
+		start := time.Now()
+		if err := task.run(ctx); err != nil {
+			log.Printf("NBA sync %s failed: %v", task.resource, err)
+			markFailed(task.resource, err)
+			continue
+		}
+		log.Printf("NBA sync %s done in %s", task.resource, time.Since(start).Round(time.Millisecond))
+	}
+}
+
+// dueForRefresh 距上次完整同步是否已超过 interval
+func dueForRefresh(resource string, interval time.Duration) (*Checkpoint, bool, error) {
+	cp, err := GetCheckpoint(resource)
+	if err != nil {
+		return nil, false, err
+	}
+	if cp.Cursor > 0 || cp.LastSyncedAt == nil {
+		return cp, true, nil
+	}
+	return cp, time.Since(*cp.LastSyncedAt) >= interval, nil
+}
+
+// syncTeams 全量同步现役球队
+func (s *Syncer) syncTeams(ctx context.Context) error {
+	if _, due, err := dueForRefresh("teams", rosterRefreshInterval); err != nil || !due {
+		return err
+	}
+
+	teams, _, err := s.client.GetTeams(ctx)
+	if err != nil {
+		return err
+	}
+	if err := SaveTeams(teams); err != nil {
+		return err
+	}
+	return markSynced("teams", "")
+}
+
+// syncPlayers 分页全量同步球员，每页完成后记录游标，中断后从断点继续
+func (s *Syncer) syncPlayers(ctx context.Context) error {
+	cp, due, err := dueForRefresh("players", rosterRefreshInterval)
+	if err != nil || !due {
+		return err
+	}
+
+	cursor := cp.Cursor
+	for {
+		page, _, err := s.client.GetPlayersPage(ctx, cursor, 100)
+		if err != nil {
+			return err
+		}
+		if err := SavePlayers(page.Data); err != nil {
+			return err
+		}
+		cursor = page.Meta.NextCursor
+		if cursor == 0 {
+			break
+		}
+		if err := saveCursor("players", cursor); err != nil {
+			return err
+		}
+	}
+	return markSynced("players", "")
+}
+
+// syncGames 同步本赛季比赛
+// 首次同步整个赛季（含未开赛的赛程）；之后从最早一场未结束的比赛开始增量刷新，以更新比分和状态
+func (s *Syncer) syncGames(ctx context.Context, season int) error {
+	resource := GamesResource(season)
+	cp, err := GetCheckpoint(resource)
+	if err != nil {
+		return err
+	}
+
+	query := external.GameQuery{Seasons: []int{season}, Cursor: cp.Cursor}
+	if cp.LastSyncedAt != nil && cp.Cursor == 0 {
+		var earliestOpen string
+		db.GetDB().QueryRow(
+			"SELECT COALESCE(MIN(date), '') FROM nba_games WHERE season = ? AND status != 'Final'",
+			season,
+		).Scan(&earliestOpen)
+		if earliestOpen == "" {
+			// 赛季已全部结束
+			return markSynced(resource, cp.LastDate)
+		}
+		query.StartDate = earliestOpen
+	}
+
+	for {
+		page, _, err := s.client.GetGamesPage(ctx, query)
+		if err != nil {
+			return err
+		}
+		if err := SaveGames(page.Data); err != nil {
+			return err
+		}
+		query.Cursor = page.Meta.NextCursor
+		if query.Cursor == 0 {
+			break
+		}
+		if err := saveCursor(resource, query.Cursor); err != nil {
+			return err
+		}
+	}
+	return markSynced(resource, time.Now().UTC().Format("2006-01-02"))
+}
+
+// syncStats 同步已结束比赛的球员单场数据
+// last_date 记录已同步到的比赛日期，每次从该日期（含）同步到最近一场已结束比赛的日期
+func (s *Syncer) syncStats(ctx context.Context, season int) error {
+	resource := StatsResource(season)
+	cp, err := GetCheckpoint(resource)
+	if err != nil {
+		return err
+	}
+
+	var latestFinal string
+	db.GetDB().QueryRow(
+		"SELECT COALESCE(MAX(date), '') FROM nba_games WHERE season = ? AND status = 'Final'",
+		season,
+	).Scan(&latestFinal)
+	if latestFinal == "" {
+		// 比赛尚未同步完成时不能标记为已同步，否则查询会信任空的本地数据而不再请求上游
+		if !Ready(GamesResource(season)) {
+			return nil
+		}
+		// 赛季还没有结束的比赛
+		return markSynced(resource, cp.LastDate)
+	}
+	if cp.Cursor == 0 && cp.LastDate != "" && cp.LastDate >= latestFinal && cp.LastSyncedAt != nil {
+		return nil
+	}
+
+	query := external.GameQuery{
+		Seasons:   []int{season},
+		StartDate: cp.LastDate,
+		EndDate:   latestFinal,
+		Cursor:    cp.Cursor,
+	}
+	for {
+		page, _, err := s.client.GetStatsPage(ctx, query)
+		if err != nil {
+			return err
+		}
+		if err := SaveStats(page.Data); err != nil {
+			return err
+		}
+		query.Cursor = page.Meta.NextCursor
+		if query.Cursor == 0 {
+			break
+		}
+		if err := saveCursor(resource, query.Cursor); err != nil {
+			return err
+		}
+	}
+	return markSynced(resource, latestFinal)
+}